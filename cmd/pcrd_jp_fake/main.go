@@ -0,0 +1,104 @@
+// Command pcrd_jp_fake runs the fake JP CDN of pcrd_jp_cdn for local runs:
+// point PCRD_JP_ENDPOINT at it.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/SpeedxPz/pcrd-version-updater/src/fake/pcrd_jp_cdn"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func main() {
+	addr := flag.String("addr", ":8091", "listen address")
+	publish := flag.String("publish", "", "comma-separated versions to publish, each as <version>[/<platform>][@<delay after start>], e.g. 10000010,10000020@5m,10000020/iOS@10m")
+	latency := flag.Duration("latency", 0, "delay the answers of versions without another fault")
+	fail := flag.String("fail", "", "comma-separated <version>=<status> answered instead of the manifest, e.g. 10000020=503")
+	bogus := flag.String("bogus", "", "comma-separated versions answered with a 200 error page")
+	flag.Parse()
+
+	start := time.Now()
+	cdn := pcrd_jp_cdn.New(nil)
+	if err := configure(cdn, start, *publish, *latency, *fail, *bogus); err != nil {
+		log.Printf("%s", err)
+		os.Exit(2)
+	}
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen := len(cdn.Requests())
+		cdn.ServeHTTP(w, r)
+		if requests := cdn.Requests(); len(requests) > seen {
+			req := requests[len(requests)-1]
+			log.Printf("%s %d %s/%s status=%d bogus=%t", r.Method, req.Version, req.Locale, req.Platform, req.Status, req.Bogus)
+		}
+	})
+
+	log.Printf("fake JP CDN listening on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, handler))
+}
+
+func configure(cdn *pcrd_jp_cdn.CDN, start time.Time, publish string, latency time.Duration, fail string, bogus string) error {
+	for _, item := range split(publish) {
+		version, delay, platform := item, "0s", ""
+		if i := strings.IndexByte(version, '@'); i >= 0 {
+			version, delay = version[:i], version[i+1:]
+		}
+		if i := strings.IndexByte(version, '/'); i >= 0 {
+			version, platform = version[:i], version[i+1:]
+		}
+		v, err := strconv.ParseInt(version, 10, 64)
+		if err != nil {
+			return fmt.Errorf("--publish: cannot parse:[%s] as version", version)
+		}
+		d, err := time.ParseDuration(delay)
+		if err != nil {
+			return fmt.Errorf("--publish: cannot parse:[%s] as delay", delay)
+		}
+		cdn.PublishOn(platform, v, start.Add(d))
+	}
+
+	for _, item := range split(fail) {
+		parts := strings.SplitN(item, "=", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("--fail: cannot parse:[%s] as version=status", item)
+		}
+		v, err := strconv.ParseInt(parts[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("--fail: cannot parse:[%s] as version", parts[0])
+		}
+		status, err := strconv.Atoi(parts[1])
+		if err != nil {
+			return fmt.Errorf("--fail: cannot parse:[%s] as status", parts[1])
+		}
+		cdn.Inject(pcrd_jp_cdn.Fault{Version: v, Status: status})
+	}
+
+	for _, item := range split(bogus) {
+		v, err := strconv.ParseInt(item, 10, 64)
+		if err != nil {
+			return fmt.Errorf("--bogus: cannot parse:[%s] as version", item)
+		}
+		cdn.Inject(pcrd_jp_cdn.Fault{Version: v, Bogus: true})
+	}
+
+	// Added last, so that a version's own fault applies first.
+	if latency > 0 {
+		cdn.Inject(pcrd_jp_cdn.Fault{Latency: latency})
+	}
+	return nil
+}
+
+func split(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); len(item) > 0 {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,56 @@
+// Command pcrd_th_fake runs the fake TH game server of pcrd_th_server for
+// local runs: point PCRD_TH_ENDPOINT at it and create a setting with the
+// same credential.
+package main
+
+import (
+	"flag"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/credential"
+	"github.com/SpeedxPz/pcrd-version-updater/src/fake/pcrd_th_server"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+)
+
+func main() {
+	addr := flag.String("addr", ":8090", "listen address")
+	salt := flag.String("salt", "", "salt the client signs SID with, as PCRD_TH_SALT")
+	udid := flag.String("udid", "udid", "account udid")
+	shortUdid := flag.Int("short-udid", 1, "account short udid")
+	viewerID := flag.Int("viewer-id", 1, "account viewer id")
+	sessionID := flag.String("session-id", "", "account session id, when the client signs SID with one")
+	responses := flag.String("responses", "maintenance", "comma-separated responses served in order, the last one repeated: a resource version, maintenance or code:<n>")
+	flag.Parse()
+
+	server := pcrd_th_server.New(nil, *salt, credential.Credential{
+		Udid:      *udid,
+		ShortUdid: int32(*shortUdid),
+		ViewerID:  int32(*viewerID),
+		SessionID: *sessionID,
+	})
+	var script []pcrd_th_server.Response
+	for _, s := range strings.Split(*responses, ",") {
+		response, err := pcrd_th_server.ParseResponse(strings.TrimSpace(s))
+		if err != nil {
+			log.Printf("--responses: %s", err)
+			os.Exit(2)
+		}
+		script = append(script, response)
+	}
+	server.Script(script...)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen := len(server.Requests())
+		server.ServeHTTP(w, r)
+		if requests := server.Requests(); len(requests) > seen {
+			req := requests[len(requests)-1]
+			log.Printf("%s %s udid=%q app=%s res=%s result=%d version=%q rejected=%q",
+				r.Method, r.URL.Path, req.UDID, req.AppVersion, req.ResVersion,
+				req.Response.ResultCode, req.Response.RequiredResVer, req.Rejected)
+		}
+	})
+
+	log.Printf("fake TH game server listening on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, handler))
+}
@@ -0,0 +1,353 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/cloudevent"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/configuration"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/setting"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/tracing"
+	"github.com/SpeedxPz/pcrd-version-updater/src/interface/cli"
+	"github.com/SpeedxPz/pcrd-version-updater/src/repository/sql_schema"
+	"github.com/SpeedxPz/pcrd-version-updater/src/repository/version_event_repository"
+	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v2"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// config is read from CONFIG_FILE, when set, under the environment. Each
+// yaml key is where the field goes in that file.
+type config struct {
+	AppName        string `yaml:"app_name" env:"APP_NAME" envDefault:"pcrd-version-updater"`
+	AppVersion     string `yaml:"app_version" env:"APP_VERSION"`
+	Environment    string `yaml:"environment" env:"ENVIRONMENT" envDefault:"development"`
+	Port           uint   `yaml:"port" env:"PORT" envDefault:"8081"`
+	Debuglog       bool   `yaml:"debug_log" env:"DEBUG_LOG" envDefault:"true"`
+	JaegerEndpoint string `yaml:"jaeger_endpoint" env:"JAEGER_ENDPOINT"`
+	Trace          struct {
+		Exporter     string  `yaml:"exporter" env:"TRACE_EXPORTER"`
+		OTLPEndpoint string  `yaml:"otlp_endpoint" env:"TRACE_OTLP_ENDPOINT"`
+		OTLPInsecure bool    `yaml:"otlp_insecure" env:"TRACE_OTLP_INSECURE" envDefault:"false"`
+		Sampler      string  `yaml:"sampler" env:"TRACE_SAMPLER" envDefault:"parentbased_always_on"`
+		SamplerRatio float64 `yaml:"sampler_ratio" env:"TRACE_SAMPLER_RATIO" envDefault:"1"`
+	} `yaml:"trace"`
+	MongoDbUri          string        `yaml:"mongo_db_uri" env:"MONGO_DB_URI" envDefault:"mongodb://localhost:27017" secret:"dsn"`
+	MongoDbStoreVersion string        `yaml:"mongo_db_pcrd_version" env:"MONGO_DB_PCRD_VERSION" envDefault:"develop-store-version"`
+	StorageBackend      string        `yaml:"storage_backend" env:"STORAGE_BACKEND" envDefault:"mongodb"`
+	BoltPath            string        `yaml:"bolt_path" env:"BOLT_PATH" envDefault:"pcrd-version-updater.db"`
+	SQLDialect          string        `yaml:"sql_dialect" env:"SQL_DIALECT" envDefault:"sqlite"`
+	SQLDsn              string        `yaml:"sql_dsn" env:"SQL_DSN" envDefault:"pcrd-version-updater.sqlite" secret:"dsn"`
+	SQLAutoMigrate      bool          `yaml:"sql_auto_migrate" env:"SQL_AUTO_MIGRATE" envDefault:"true"`
+	TargetAppId         string        `yaml:"target_appid" env:"TARGET_APPID"`
+	ReportFormat        string        `yaml:"report_format" env:"REPORT_FORMAT" envDefault:"json"`
+	ReportFile          string        `yaml:"report_file" env:"REPORT_FILE"`
+	RunTimeout          time.Duration `yaml:"run_timeout" env:"RUN_TIMEOUT" envDefault:"5m"`
+	ShutdownTimeout     time.Duration `yaml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT" envDefault:"30s"`
+	StepTimeout         struct {
+		Storage     time.Duration `yaml:"storage" env:"STEP_TIMEOUT_STORAGE" envDefault:"10s"`
+		Application time.Duration `yaml:"application" env:"STEP_TIMEOUT_APPLICATION" envDefault:"30s"`
+		Resource    time.Duration `yaml:"resource" env:"STEP_TIMEOUT_RESOURCE" envDefault:"3m"`
+		Publish     time.Duration `yaml:"publish" env:"STEP_TIMEOUT_PUBLISH" envDefault:"30s"`
+	} `yaml:"step_timeout"`
+	Alert struct {
+		ConsecutiveFailures int           `yaml:"consecutive_failures" env:"ALERT_CONSECUTIVE_FAILURES" envDefault:"3"`
+		StaleAfter          time.Duration `yaml:"stale_after" env:"ALERT_STALE_AFTER" envDefault:"336h"`
+	} `yaml:"alert"`
+	CheckRetention    time.Duration `yaml:"check_retention" env:"CHECK_RETENTION" envDefault:"720h"`
+	AdminToken        string        `yaml:"admin_token" env:"ADMIN_TOKEN" secret:"true"`
+	CredentialKeys    string        `yaml:"credential_keys" env:"CREDENTIAL_KEYS" secret:"true"`
+	CredentialKeyFile string        `yaml:"credential_key_file" env:"CREDENTIAL_KEY_FILE"`
+	// Regions are the server codes whose endpoint, and salt for TH, are
+	// required up front; none are by default.
+	Regions []string `yaml:"regions" env:"REGIONS" envSeparator:","`
+	Service struct {
+		Application string `yaml:"application_baseurl" env:"SERVICE_APPLICATION_BASEURL"`
+	} `yaml:"service"`
+	PCRD struct {
+		JPEndpoint string `yaml:"jp_endpoint" env:"PCRD_JP_ENDPOINT" envDefault:"http://prd-priconne-redive.akamaized.net"`
+		JPSalt     string `yaml:"jp_salt" env:"PCRD_JP_SALT" envDefault:"" secret:"true"`
+		THEndpoint string `yaml:"th_endpoint" env:"PCRD_TH_ENDPOINT" envDefault:"https://pcc-game.i3play.com"`
+		THSalt     string `yaml:"th_salt" env:"PCRD_TH_SALT" envDefault:"" secret:"true"`
+	} `yaml:"pcrd"`
+	Kafka struct {
+		Brokers               []string      `yaml:"brokers" env:"KAFKA_SERVER" envSeparator:"," envDefault:"localhost:9092"`
+		TLS                   bool          `yaml:"tls" env:"KAFKA_TLS" envDefault:"false"`
+		TLSCAFile             string        `yaml:"tls_ca_file" env:"KAFKA_TLS_CA_FILE"`
+		TLSInsecureSkipVerify bool          `yaml:"tls_insecure_skip_verify" env:"KAFKA_TLS_INSECURE_SKIP_VERIFY" envDefault:"false"`
+		SASLMechanism         string        `yaml:"sasl_mechanism" env:"KAFKA_SASL_MECHANISM"`
+		SASLUsername          string        `yaml:"sasl_username" env:"KAFKA_SASL_USERNAME"`
+		SASLPassword          string        `yaml:"sasl_password" env:"KAFKA_SASL_PASSWORD" secret:"true"`
+		RequiredAcks          string        `yaml:"required_acks" env:"KAFKA_REQUIRED_ACKS" envDefault:"all"`
+		Compression           string        `yaml:"compression" env:"KAFKA_COMPRESSION" envDefault:"snappy"`
+		BatchTimeout          time.Duration `yaml:"batch_timeout" env:"KAFKA_BATCH_TIMEOUT" envDefault:"10ms"`
+	} `yaml:"kafka"`
+	KafkaTopicVersionEvent string `yaml:"kafka_topic_version_event" env:"KAFKA_TOPIC_VERSION_EVENT"`
+	KafkaTopicSnapshot     string `yaml:"kafka_topic_version_snapshot" env:"KAFKA_TOPIC_VERSION_SNAPSHOT"`
+	KafkaEventMode         string `yaml:"kafka_event_mode" env:"KAFKA_EVENT_MODE" envDefault:"binary"`
+	EventSource            string `yaml:"event_source" env:"EVENT_SOURCE" envDefault:"/pcrd-version-updater"`
+	EventSinks             string `yaml:"event_sinks" env:"EVENT_SINKS" envDefault:"kafka:required"`
+	Webhook                struct {
+		URLs        []string      `yaml:"urls" env:"WEBHOOK_URLS" envSeparator:","`
+		Secret      string        `yaml:"secret" env:"WEBHOOK_SECRET" secret:"true"`
+		MaxAttempts int           `yaml:"max_attempts" env:"WEBHOOK_MAX_ATTEMPTS" envDefault:"5"`
+		Backoff     time.Duration `yaml:"backoff" env:"WEBHOOK_BACKOFF" envDefault:"1s"`
+	} `yaml:"webhook"`
+}
+
+// initEnvironment loads the configuration and returns it with the command
+// line left after the global flags. Those flags override CONFIG_FILE and
+// every configuration variable.
+func initEnvironment(args []string) (config, []string) {
+	log.SetFlags(log.Flags() &^ (log.Ldate | log.Ltime))
+
+	err := godotenv.Load()
+	if err != nil {
+		log.Printf("Error loading .env file: %s\n", err)
+	}
+
+	var cfg config
+	fs := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), cli.Usage)
+		fs.PrintDefaults()
+	}
+	configFile := fs.String("config", os.Getenv("CONFIG_FILE"), "YAML configuration file, overrides CONFIG_FILE")
+	flags := configuration.Flags(fs, &cfg)
+	err = fs.Parse(args)
+	if err == flag.ErrHelp {
+		os.Exit(cli.ExitUnchanged)
+	}
+	if err != nil {
+		os.Exit(cli.ExitConfiguration)
+	}
+	if fs.Arg(0) == "help" {
+		fs.SetOutput(os.Stdout)
+		fs.Usage()
+		os.Exit(cli.ExitUnchanged)
+	}
+
+	environ := configuration.Environ()
+	for k, v := range flags() {
+		environ[k] = v
+	}
+	err = configuration.Load(&cfg, *configFile, environ)
+	if err != nil {
+		log.Printf("Error load config: %s\n", err)
+		os.Exit(cli.ExitConfiguration)
+	}
+	// Deployments from before TRACE_EXPORTER only set JAEGER_ENDPOINT.
+	if len(cfg.Trace.Exporter) <= 0 {
+		cfg.Trace.Exporter = string(tracing.ExporterOTLPGRPC)
+		if len(cfg.JaegerEndpoint) > 0 {
+			cfg.Trace.Exporter = string(tracing.ExporterJaeger)
+		}
+	}
+
+	return cfg, fs.Args()
+}
+
+// usage is what a command uses of the configuration besides the storage,
+// tracing and timeouts, which every command validated here uses.
+type usage struct {
+	// remotes are the application service and the game servers.
+	remotes bool
+	sinks   bool
+}
+
+// everything is the usage of a check, and what config check validates.
+var everything = usage{remotes: true, sinks: true}
+
+// commandUsage is the usage of the command in args. Commands that only read
+// or write the storage validate nothing else, so that an incomplete event
+// or region configuration does not lock operators out of them.
+func commandUsage(args []string) usage {
+	if len(args) <= 0 {
+		return everything
+	}
+	switch args[0] {
+	case "check", "plan":
+		return everything
+	case "health", "serve", "webhook":
+		// They check or redeliver through the sinks; the remotes are
+		// only probed, and report their own failures.
+		return usage{sinks: true}
+	}
+	return usage{}
+}
+
+// validate reports every problem at once: values that do not parse, and
+// what the regions, sinks and storage backend need but lack. Only what u
+// uses is checked.
+func (c config) validate(u usage) error {
+	var problems []string
+	problemf := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+	requireURL := func(name string, value string) {
+		if len(value) <= 0 {
+			problemf("%s is required", name)
+		} else if u, err := url.Parse(value); err != nil || len(u.Scheme) <= 0 || len(u.Host) <= 0 {
+			problemf("%s %q is not an absolute URL", name, value)
+		}
+	}
+
+	var regions []string
+	if u.remotes {
+		requireURL("SERVICE_APPLICATION_BASEURL", c.Service.Application)
+		regions = c.Regions
+	}
+	for _, region := range regions {
+		serverCode, err := setting.ParseServerCode(region)
+		if err != nil {
+			problemf("REGIONS: %s", err)
+			continue
+		}
+		switch serverCode {
+		case setting.ServerCodeTH:
+			requireURL("PCRD_TH_ENDPOINT", c.PCRD.THEndpoint)
+			if len(c.PCRD.THSalt) <= 0 {
+				problemf("PCRD_TH_SALT is required for region th")
+			}
+		case setting.ServerCodeJP:
+			requireURL("PCRD_JP_ENDPOINT", c.PCRD.JPEndpoint)
+		}
+	}
+
+	problems = append(problems, c.storageProblems()...)
+
+	var specs []version_event_repository.SinkSpec
+	if u.sinks {
+		var err error
+		specs, err = version_event_repository.ParseSinkSpecs(c.EventSinks)
+		if err != nil {
+			problemf("EVENT_SINKS: %s", err)
+		}
+	}
+	for _, spec := range specs {
+		switch spec.Name {
+		case "kafka":
+			if _, err := cloudevent.ParseMode(c.KafkaEventMode); err != nil {
+				problemf("KAFKA_EVENT_MODE: %s", err)
+			}
+			if err := c.kafkaConfig(c.KafkaTopicVersionEvent).Validate(); err != nil {
+				problemf("kafka sink: %s", err)
+			}
+		case "webhook":
+			if len(c.Webhook.URLs) <= 0 || len(c.Webhook.Secret) <= 0 {
+				problemf("webhook sink needs WEBHOOK_URLS and WEBHOOK_SECRET")
+			}
+			for _, u := range c.Webhook.URLs {
+				requireURL("WEBHOOK_URLS", u)
+			}
+			if c.Webhook.MaxAttempts <= 0 {
+				problemf("WEBHOOK_MAX_ATTEMPTS must be positive")
+			}
+		default:
+			problemf("unknown event sink %q", spec.Name)
+		}
+	}
+
+	if _, err := tracing.ParseExporter(c.Trace.Exporter); err != nil {
+		problemf("TRACE_EXPORTER: %s", err)
+	}
+	if _, err := tracing.ParseSampler(c.Trace.Sampler, c.Trace.SamplerRatio); err != nil {
+		problemf("TRACE_SAMPLER: %s", err)
+	}
+	if _, err := cli.ParseReportFormat(c.ReportFormat); err != nil {
+		problemf("REPORT_FORMAT: %s", err)
+	}
+	for name, d := range map[string]time.Duration{
+		"RUN_TIMEOUT":              c.RunTimeout,
+		"SHUTDOWN_TIMEOUT":         c.ShutdownTimeout,
+		"STEP_TIMEOUT_STORAGE":     c.StepTimeout.Storage,
+		"STEP_TIMEOUT_APPLICATION": c.StepTimeout.Application,
+		"STEP_TIMEOUT_RESOURCE":    c.StepTimeout.Resource,
+		"STEP_TIMEOUT_PUBLISH":     c.StepTimeout.Publish,
+		"ALERT_STALE_AFTER":        c.Alert.StaleAfter,
+		"CHECK_RETENTION":          c.CheckRetention,
+	} {
+		if d < 0 {
+			problemf("%s is negative", name)
+		}
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("%s: %w", strings.Join(problems, "; "), configuration.ErrInvalidConfig)
+	}
+	return nil
+}
+
+// validateStorage checks only what the storage backend needs, for commands
+// such as migrate that use nothing else.
+func (c config) validateStorage() error {
+	if problems := c.storageProblems(); len(problems) > 0 {
+		return fmt.Errorf("%s: %w", strings.Join(problems, "; "), configuration.ErrInvalidConfig)
+	}
+	return nil
+}
+
+func (c config) storageProblems() []string {
+	switch c.StorageBackend {
+	case "mongodb":
+		if len(c.MongoDbUri) <= 0 || len(c.MongoDbStoreVersion) <= 0 {
+			return []string{"storage mongodb needs MONGO_DB_URI and MONGO_DB_PCRD_VERSION"}
+		}
+	case "bolt":
+		if len(c.BoltPath) <= 0 {
+			return []string{"storage bolt needs BOLT_PATH"}
+		}
+	case "sql":
+		var problems []string
+		if _, err := sql_schema.ParseDialect(c.SQLDialect); err != nil {
+			problems = append(problems, fmt.Sprintf("SQL_DIALECT: %s", err))
+		}
+		if len(c.SQLDsn) <= 0 {
+			problems = append(problems, "storage sql needs SQL_DSN")
+		}
+		return problems
+	default:
+		return []string{fmt.Sprintf("unknown STORAGE_BACKEND %q", c.StorageBackend)}
+	}
+	return nil
+}
+
+func (c config) kafkaConfig(topic string) version_event_repository.KafkaConfig {
+	return version_event_repository.KafkaConfig{
+		Brokers:               c.Kafka.Brokers,
+		Topic:                 topic,
+		TLS:                   c.Kafka.TLS,
+		TLSCAFile:             c.Kafka.TLSCAFile,
+		TLSInsecureSkipVerify: c.Kafka.TLSInsecureSkipVerify,
+		SASLMechanism:         c.Kafka.SASLMechanism,
+		SASLUsername:          c.Kafka.SASLUsername,
+		SASLPassword:          c.Kafka.SASLPassword,
+		RequiredAcks:          c.Kafka.RequiredAcks,
+		Compression:           c.Kafka.Compression,
+		BatchTimeout:          c.Kafka.BatchTimeout,
+	}
+}
+
+// configCheck runs `config check`: it writes the effective configuration as
+// YAML, secrets redacted, and returns the validation problems.
+func configCheck(cfg config, out io.Writer, args []string) error {
+	if len(args) <= 0 || args[0] != "check" {
+		return fmt.Errorf("config: missing action (check): %w", use_case.ErrInvalidRequestParam)
+	}
+
+	data, err := yaml.Marshal(configuration.Render(cfg))
+	if err != nil {
+		return err
+	}
+	if _, err := out.Write(data); err != nil {
+		return err
+	}
+	return cfg.validate(everything)
+}
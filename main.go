@@ -2,92 +2,214 @@ package main
 
 import (
 	"context"
+	"database/sql"
+	"fmt"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/clock"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/cloudevent"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/cryptography"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/tracing"
+	"github.com/SpeedxPz/pcrd-version-updater/src/interface/cli"
+	"github.com/SpeedxPz/pcrd-version-updater/src/interface/fiber_server"
 	"github.com/SpeedxPz/pcrd-version-updater/src/repository/application_repository"
+	"github.com/SpeedxPz/pcrd-version-updater/src/repository/check_repository"
+	"github.com/SpeedxPz/pcrd-version-updater/src/repository/check_state_repository"
+	"github.com/SpeedxPz/pcrd-version-updater/src/repository/delivery_repository"
 	"github.com/SpeedxPz/pcrd-version-updater/src/repository/history_repository"
 	"github.com/SpeedxPz/pcrd-version-updater/src/repository/pcrd_jp_repository"
 	"github.com/SpeedxPz/pcrd-version-updater/src/repository/pcrd_th_repository"
 	"github.com/SpeedxPz/pcrd-version-updater/src/repository/setting_repository"
+	"github.com/SpeedxPz/pcrd-version-updater/src/repository/sql_schema"
 	"github.com/SpeedxPz/pcrd-version-updater/src/repository/version_event_repository"
 	"github.com/SpeedxPz/pcrd-version-updater/src/repository/version_repository"
 	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
-	"github.com/caarlos0/env/v6"
-	"github.com/joho/godotenv"
+	_ "github.com/lib/pq"
+	"go.etcd.io/bbolt"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.mongodb.org/mongo-driver/mongo/readpref"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/exporters/jaeger"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/sdk/resource"
 	"go.opentelemetry.io/otel/sdk/trace"
 	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+	"io"
 	"log"
+	_ "modernc.org/sqlite"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 )
 
-type config struct {
-	AppName             string `env:"APP_NAME" envDefault:"pcrd-version-updater"`
-	AppVersion          string `env:"APP_VERSION"`
-	Environment         string `env:"ENVIRONMENT" envDefault:"development"`
-	Port                uint   `env:"PORT" envDefault:"8081"`
-	Debuglog            bool   `env:"DEBUG_LOG" envDefault:"true"`
-	JaegerEndpoint      string `env:"JAEGER_ENDPOINT" envDefault:"http://localhost:14268/api/traces"`
-	MongoDbUri          string `env:"MONGO_DB_URI" envDefault:"mongodb://localhost:27017"`
-	MongoDbStoreVersion string `env:"MONGO_DB_PCRD_VERSION" envDefault:"develop-store-version"`
-	TargetAppId         string `env:"TARGET_APPID"`
-	Service             struct {
-		Application string `env:"SERVICE_APPLICATION_BASEURL"`
-	}
-	PCRD struct {
-		JPEndpoint string `env:"PCRD_JP_ENDPOINT" envDefault:"http://prd-priconne-redive.akamaized.net"`
-		JPSalt     string `env:"PCRD_JP_SALT" envDefault:""`
-		THEndpoint string `env:"PCRD_TH_ENDPOINT" envDefault:"https://pcc-game.i3play.com"`
-		THSalt     string `env:"PCRD_TH_SALT" envDefault:""`
-	}
-	KafkaServer            string `env:"KAFKA_SERVER" envDefault:"localhost:9092"`
-	KafkaTopicVersionEvent string `env:"KAFKA_TOPIC_VERSION_EVENT"`
-}
-
 func main() {
-	cfg := initEnvironment()
+	cfg, args := initEnvironment(os.Args[1:])
+	shutdownTimeout = cfg.ShutdownTimeout
 	initLogger(cfg)
+
+	// config, version and migrate need no connection, or only the storage,
+	// so they run before the configuration the command uses is validated.
+	if handled, err := runStandalone(cfg, args); handled {
+		code := cli.ExitCode("", err)
+		if err != nil {
+			zap.L().Error("Error run command: ", zap.Error(err), zap.Int("exitCode", code))
+		}
+		exit(code)
+	}
+	u := commandUsage(args)
+	if err := cfg.validate(u); err != nil {
+		exitWith(cli.ExitConfiguration, "Invalid configuration: ", zap.Error(err))
+	}
+
 	initTracer(cfg)
-	appRepo,
-		settingRepo,
-		pcrdTHRepo,
-		pcrdJPRepo,
-		versionRepo,
-		historyRepo,
-		versionEventRepo := initRepositories(cfg)
-	useCase := use_case.New(appRepo, settingRepo, pcrdTHRepo, pcrdJPRepo, versionRepo, historyRepo, versionEventRepo)
+	clk := clock.Real()
+	appRepo, pcrdTHRepo, pcrdJPRepo, store, versionEventRepo := initRepositories(cfg, clk, u)
+	useCase := use_case.New(appRepo, store.settings, pcrdTHRepo, pcrdJPRepo, store.versions, store.histories, versionEventRepo, clk)
+	useCase.SetStepBudgets(use_case.StepBudgets{
+		Storage:     cfg.StepTimeout.Storage,
+		Application: cfg.StepTimeout.Application,
+		Resource:    cfg.StepTimeout.Resource,
+		Publish:     cfg.StepTimeout.Publish,
+	})
+	useCase.SetAlerting(store.checkStates, use_case.AlertThresholds{
+		ConsecutiveFailures: cfg.Alert.ConsecutiveFailures,
+		StaleAfter:          cfg.Alert.StaleAfter,
+	})
+	useCase.SetCheckLog(store.checks, cfg.CheckRetention)
 
-	ctx := context.Background()
-	err := useCase.UpdateResourceVersion(ctx, cfg.TargetAppId)
-	tp := otel.GetTracerProvider()
-	tp.(*trace.TracerProvider).ForceFlush(ctx)
+	// The first SIGINT or SIGTERM cancels ctx and lets the command wind
+	// down; once it has, a second one kills the process.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	outcome, err := runRecovered(ctx, cfg, useCase, versionEventRepo, args)
+	code := cli.ExitCode(outcome, err)
 	if err != nil {
-		panic(err)
+		zap.L().Error("Error run command: ", zap.Error(err), zap.Int("exitCode", code))
 	}
+	exit(code)
 }
 
-func initEnvironment() config {
-	log.SetFlags(log.Flags() &^ (log.Ldate | log.Ltime))
+// runStandalone runs the commands that main runs before validating the
+// configuration, and reports whether args was one of them.
+func runStandalone(cfg config, args []string) (bool, error) {
+	if len(args) <= 0 {
+		return false, nil
+	}
 
-	err := godotenv.Load()
-	if err != nil {
-		log.Printf("Error loading .env file: %s\n", err)
+	switch args[0] {
+	case "config":
+		// config check reports an invalid configuration instead of
+		// refusing it.
+		return true, configCheck(cfg, os.Stdout, args[1:])
+	case "version":
+		return true, cli.Version(os.Stdout, args[1:], cli.ReadBuildInfo(cfg.AppName, cfg.AppVersion))
+	case "migrate":
+		if err := cfg.validateStorage(); err != nil {
+			return true, err
+		}
+		return true, migrate(cfg, os.Stdout, args[1:])
 	}
+	return false, nil
+}
 
-	var cfg config
-	err = env.Parse(&cfg)
-	if err != nil {
-		log.Fatalf("Error parse env: %s\n", err)
+// runRecovered turns a panic in run into an error, so main still shuts down
+// cleanly and the process exits with cli.ExitFailure.
+func runRecovered(ctx context.Context, cfg config, useCase *use_case.UseCase, versionEventRepo *version_event_repository.FanOut, args []string) (outcome use_case.RunOutcome, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			zap.L().Error("Recovered from panic: ", zap.Any("panic", r), zap.Stack("stack"))
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return run(ctx, cfg, useCase, versionEventRepo, args)
+}
+
+func run(ctx context.Context, cfg config, useCase *use_case.UseCase, versionEventRepo *version_event_repository.FanOut, args []string) (use_case.RunOutcome, error) {
+	if (len(args) <= 0 || args[0] != "serve") && cfg.RunTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.RunTimeout)
+		defer cancel()
+	}
+
+	reportOptions := cli.ReportOptions{Format: cfg.ReportFormat, File: cfg.ReportFile}
+	if len(args) <= 0 {
+		return cli.Check(ctx, useCase, os.Stdout, nil, cfg.TargetAppId, reportOptions)
+	}
+
+	var err error
+	switch args[0] {
+	case "check":
+		return cli.Check(ctx, useCase, os.Stdout, args[1:], cfg.TargetAppId, reportOptions)
+	case "plan":
+		return cli.Plan(ctx, useCase, os.Stdout, args[1:], cfg.TargetAppId, reportOptions)
+	case "versions":
+		err = cli.Versions(ctx, useCase, os.Stdout, args[1:])
+	case "history":
+		err = cli.History(ctx, useCase, os.Stdout, args[1:])
+	case "settings":
+		err = cli.Settings(ctx, useCase, os.Stdout, args[1:])
+	case "health":
+		err = cli.Health(ctx, useCase, os.Stdout, args[1:])
+	case "checks":
+		err = cli.Checks(ctx, useCase, os.Stdout, args[1:])
+	case "webhook":
+		err = cli.Webhook(ctx, versionEventRepo, os.Stdout, args[1:])
+	case "republish":
+		snapshotTopic := cfg.KafkaTopicSnapshot
+		if len(snapshotTopic) <= 0 {
+			snapshotTopic = cfg.KafkaTopicVersionEvent
+		}
+		snapshot := initKafkaSink(cfg, snapshotTopic)
+		defer closeSink(snapshot)
+		history := initKafkaSink(cfg, cfg.KafkaTopicVersionEvent)
+		defer closeSink(history)
+		err = cli.Republish(ctx, useCase, snapshot, history, os.Stdout, args[1:])
+	case "serve":
+		err = serve(ctx, cfg, useCase)
+	default:
+		err = fmt.Errorf("unknown command: %s: %w", args[0], use_case.ErrInvalidRequestParam)
 	}
+	return "", err
+}
+
+// serve runs the admin API until ctx is cancelled, then stops accepting
+// connections and waits for the open ones.
+func serve(ctx context.Context, cfg config, useCase *use_case.UseCase) error {
+	app := fiber_server.New(useCase, cfg.AdminToken)
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			if err := app.Shutdown(); err != nil {
+				zap.L().Error("Error shutdown server: ", zap.Error(err))
+			}
+		case <-done:
+		}
+	}()
 
-	return cfg
+	return app.Listen(fmt.Sprintf(":%d", cfg.Port))
 }
+
+// exitWith logs a start-up failure and exits with code, which is
+// cli.ExitConfiguration for invalid settings and cli.ExitTransient for a
+// dependency that could not be reached.
+func exitWith(code int, msg string, fields ...zap.Field) {
+	zap.L().Error(msg, fields...)
+	exit(code)
+}
+
 func initLogger(cfg config) {
 	config := zap.NewProductionConfig()
 	config.EncoderConfig.EncodeLevel = zapcore.LowercaseLevelEncoder
@@ -103,7 +225,8 @@ func initLogger(cfg config) {
 
 	logger, err := config.Build()
 	if err != nil {
-		log.Fatalf("Error build logger: %s\n", err)
+		log.Printf("Error build logger: %s\n", err)
+		os.Exit(cli.ExitConfiguration)
 	}
 	defer logger.Sync()
 
@@ -111,9 +234,9 @@ func initLogger(cfg config) {
 }
 
 func initTracer(cfg config) {
-	exp, err := jaeger.New(jaeger.WithCollectorEndpoint(jaeger.WithEndpoint(cfg.JaegerEndpoint)))
+	sampler, err := tracing.ParseSampler(cfg.Trace.Sampler, cfg.Trace.SamplerRatio)
 	if err != nil {
-		zap.S().Fatal("Error init Jaeger exporter: ", zap.Error(err))
+		exitWith(cli.ExitConfiguration, "Error parse trace sampler: ", zap.Error(err))
 	}
 
 	r, err := resource.Merge(
@@ -126,48 +249,339 @@ func initTracer(cfg config) {
 		),
 	)
 	if err != nil {
-		zap.S().Fatal("Error init Jaeger resource: ", zap.Error(err))
+		exitWith(cli.ExitFailure, "Error init trace resource: ", zap.Error(err))
 	}
 
-	tp := trace.NewTracerProvider(
-		// Always be sure to batch in production.
-		trace.WithBatcher(exp),
+	options := []trace.TracerProviderOption{
+		trace.WithSampler(sampler),
 		// Record information about this application in a Resource.
 		trace.WithResource(r),
-	)
+	}
+	exp := initTraceExporter(cfg)
+	if exp != nil {
+		// Always be sure to batch in production.
+		options = append(options, trace.WithBatcher(exp))
+	}
+	tp := trace.NewTracerProvider(options...)
+	// Without a span processor there is nothing to flush, and Shutdown
+	// reports that as an error.
+	if exp != nil {
+		onShutdown("tracer", tp.Shutdown)
+	}
 
 	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+}
+
+// initTraceExporter returns the exporter selected by TRACE_EXPORTER, or nil
+// for none. The OTLP exporters also honour the standard OTEL_EXPORTER_OTLP_*
+// variables.
+func initTraceExporter(cfg config) trace.SpanExporter {
+	exporter, err := tracing.ParseExporter(cfg.Trace.Exporter)
+	if err != nil {
+		exitWith(cli.ExitConfiguration, "Error parse trace exporter: ", zap.Error(err))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var exp trace.SpanExporter
+	switch exporter {
+	case tracing.ExporterOTLPGRPC:
+		var opts []otlptracegrpc.Option
+		if len(cfg.Trace.OTLPEndpoint) > 0 {
+			opts = append(opts, otlptracegrpc.WithEndpoint(cfg.Trace.OTLPEndpoint))
+		}
+		if cfg.Trace.OTLPInsecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		exp, err = otlptracegrpc.New(ctx, opts...)
+	case tracing.ExporterOTLPHTTP:
+		var opts []otlptracehttp.Option
+		if len(cfg.Trace.OTLPEndpoint) > 0 {
+			opts = append(opts, otlptracehttp.WithEndpoint(cfg.Trace.OTLPEndpoint))
+		}
+		if cfg.Trace.OTLPInsecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		exp, err = otlptracehttp.New(ctx, opts...)
+	case tracing.ExporterStdout:
+		// stderr, since stdout carries command output.
+		exp, err = stdouttrace.New(stdouttrace.WithWriter(os.Stderr))
+	case tracing.ExporterJaeger:
+		var opts []jaeger.CollectorEndpointOption
+		if len(cfg.JaegerEndpoint) > 0 {
+			opts = append(opts, jaeger.WithEndpoint(cfg.JaegerEndpoint))
+		}
+		exp, err = jaeger.New(jaeger.WithCollectorEndpoint(opts...))
+	case tracing.ExporterNone:
+		return nil
+	}
+	if err != nil {
+		exitWith(cli.ExitConfiguration, "Error init trace exporter: ", zap.Error(err))
+	}
+
+	return exp
 }
 
-func initRepositories(cfg config) (
+// initRepositories builds the event sinks only when u uses them; otherwise
+// nothing is published.
+func initRepositories(cfg config, clk clock.Clock, u usage) (
 	use_case.ApplicationRepository,
-	use_case.SettingRepository,
 	use_case.PcrdTHRepository,
 	use_case.PcrdJPRepository,
-	use_case.VersionRepository,
-	use_case.HistoryRepository,
-	use_case.VersionEventRepository,
+	storage,
+	*version_event_repository.FanOut,
 ) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	appRepo := application_repository.NewRest(cfg.Service.Application)
+	pcrdTHRepo := pcrd_th_repository.NewRest(cfg.PCRD.THEndpoint, cfg.PCRD.THSalt)
+	pcrdJPRepo := pcrd_jp_repository.NewRest(cfg.PCRD.JPEndpoint, clk)
+	store := initStorage(cfg, clk)
+	versionEventRepo := version_event_repository.NewFanOut(clk)
+	if u.sinks {
+		versionEventRepo = initEventSinks(cfg, store.deliveries, clk)
+	}
+	return appRepo, pcrdTHRepo, pcrdJPRepo, store, versionEventRepo
+}
 
-	client, err := mongo.Connect(ctx, options.Client().ApplyURI(cfg.MongoDbUri))
+// initEventSinks builds the fan-out publisher from EVENT_SINKS.
+func initEventSinks(cfg config, deliveryRepo use_case.DeliveryRepository, clk clock.Clock) *version_event_repository.FanOut {
+	specs, err := version_event_repository.ParseSinkSpecs(cfg.EventSinks)
 	if err != nil {
-		zap.L().Fatal("Error init mongo client: ", zap.Error(err))
+		exitWith(cli.ExitConfiguration, "Error parse event sinks: ", zap.Error(err))
 	}
 
-	err = client.Ping(ctx, readpref.Primary())
+	sinks := make([]version_event_repository.Sink, len(specs))
+	for i, spec := range specs {
+		sinks[i] = version_event_repository.Sink{SinkSpec: spec, Publisher: initEventSink(cfg, spec.Name, deliveryRepo, clk)}
+	}
+
+	fanOut := version_event_repository.NewFanOut(clk, sinks...)
+	onShutdown("event sinks", fanOut.Close)
+	return fanOut
+}
+
+func initEventSink(cfg config, name string, deliveryRepo use_case.DeliveryRepository, clk clock.Clock) use_case.VersionEventRepository {
+	switch name {
+	case "kafka":
+		return initKafkaSink(cfg, cfg.KafkaTopicVersionEvent)
+	case "webhook":
+		if len(cfg.Webhook.URLs) <= 0 || len(cfg.Webhook.Secret) <= 0 {
+			exitWith(cli.ExitConfiguration, "Webhook sink requires WEBHOOK_URLS and WEBHOOK_SECRET")
+		}
+		return version_event_repository.NewWebhook(cfg.Webhook.URLs, cfg.Webhook.Secret, cfg.EventSource, cfg.Webhook.MaxAttempts, cfg.Webhook.Backoff, deliveryRepo, clk)
+	default:
+		exitWith(cli.ExitConfiguration, "Unknown event sink", zap.String("sink", name))
+		return nil
+	}
+}
+
+func initKafkaSink(cfg config, topic string) use_case.VersionEventRepository {
+	eventMode, err := cloudevent.ParseMode(cfg.KafkaEventMode)
 	if err != nil {
-		zap.L().Fatal("Error ping mongo client: ", zap.Error(err))
+		exitWith(cli.ExitConfiguration, "Error parse kafka event mode: ", zap.Error(err))
 	}
 
-	appRepo := application_repository.NewRest(cfg.Service.Application)
-	settingRepo := setting_repository.NewMongoDb(client.Database(cfg.MongoDbStoreVersion))
-	pcrdTHRepo := pcrd_th_repository.NewRest(cfg.PCRD.THEndpoint, cfg.PCRD.THSalt)
-	pcrdJPRepo := pcrd_jp_repository.NewRest(cfg.PCRD.JPEndpoint)
-	versionRepo := version_repository.NewMongoDb(client.Database(cfg.MongoDbStoreVersion))
-	historyRepo := history_repository.NewMongoDb(client.Database(cfg.MongoDbStoreVersion))
+	sink, err := version_event_repository.NewKafkaMQ(cfg.kafkaConfig(topic), eventMode, cfg.EventSource)
+	if err != nil {
+		exitWith(cli.ExitConfiguration, "Error init kafka producer: ", zap.Error(err))
+	}
+	return sink
+}
+
+func closeSink(sink use_case.VersionEventRepository) {
+	if closer, ok := sink.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			zap.L().Error("Error close event sink: ", zap.Error(err))
+		}
+	}
+}
+
+// storage is every repository kept on the STORAGE_BACKEND.
+type storage struct {
+	settings    use_case.SettingRepository
+	versions    use_case.VersionRepository
+	histories   use_case.HistoryRepository
+	deliveries  use_case.DeliveryRepository
+	checkStates use_case.CheckStateRepository
+	checks      use_case.CheckRepository
+}
+
+// initStorage builds the repositories on the backend selected by
+// STORAGE_BACKEND.
+func initStorage(cfg config, clk clock.Clock) storage {
+	keyring := initKeyring(cfg)
+
+	switch cfg.StorageBackend {
+	case "mongodb":
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		client, err := mongo.Connect(ctx, options.Client().ApplyURI(cfg.MongoDbUri))
+		if err != nil {
+			exitWith(cli.ExitTransient, "Error init mongo client: ", zap.Error(err))
+		}
+		onShutdown("mongo", client.Disconnect)
+
+		err = client.Ping(ctx, readpref.Primary())
+		if err != nil {
+			exitWith(cli.ExitTransient, "Error ping mongo client: ", zap.Error(err))
+		}
+
+		db := client.Database(cfg.MongoDbStoreVersion)
+		store := storage{
+			versions:    version_repository.NewMongoDb(db, clk),
+			histories:   history_repository.NewMongoDb(db, clk),
+			deliveries:  delivery_repository.NewMongoDb(db),
+			checkStates: check_state_repository.NewMongoDb(db),
+		}
+		store.settings, err = setting_repository.NewMongoDb(ctx, db, keyring)
+		if err != nil {
+			exitWith(cli.ExitTransient, "Error init mongo setting repository: ", zap.Error(err))
+		}
+		store.checks, err = check_repository.NewMongoDb(ctx, db)
+		if err != nil {
+			exitWith(cli.ExitTransient, "Error init mongo check repository: ", zap.Error(err))
+		}
+		return store
+	case "bolt":
+		db, err := bbolt.Open(cfg.BoltPath, 0600, &bbolt.Options{Timeout: 10 * time.Second})
+		if err != nil {
+			exitWith(cli.ExitTransient, "Error open bolt file: ", zap.Error(err))
+		}
+		onShutdown("bolt", func(ctx context.Context) error { return db.Close() })
+
+		var store storage
+		store.settings, err = setting_repository.NewBolt(db, keyring)
+		if err != nil {
+			exitWith(cli.ExitTransient, "Error init bolt setting repository: ", zap.Error(err))
+		}
+		store.versions, err = version_repository.NewBolt(db, clk)
+		if err != nil {
+			exitWith(cli.ExitTransient, "Error init bolt version repository: ", zap.Error(err))
+		}
+		store.histories, err = history_repository.NewBolt(db, clk)
+		if err != nil {
+			exitWith(cli.ExitTransient, "Error init bolt history repository: ", zap.Error(err))
+		}
+		store.deliveries, err = delivery_repository.NewBolt(db)
+		if err != nil {
+			exitWith(cli.ExitTransient, "Error init bolt delivery repository: ", zap.Error(err))
+		}
+		store.checkStates, err = check_state_repository.NewBolt(db)
+		if err != nil {
+			exitWith(cli.ExitTransient, "Error init bolt check state repository: ", zap.Error(err))
+		}
+		store.checks, err = check_repository.NewBolt(db)
+		if err != nil {
+			exitWith(cli.ExitTransient, "Error init bolt check repository: ", zap.Error(err))
+		}
+		return store
+	case "sql":
+		db, dialect := openSQL(cfg)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		if cfg.SQLAutoMigrate {
+			err := sql_schema.Migrate(ctx, db, dialect)
+			if err != nil {
+				exitWith(cli.ExitTransient, "Error migrate sql database: ", zap.Error(err))
+			}
+		} else {
+			current, err := sql_schema.Version(ctx, db)
+			if err != nil {
+				exitWith(cli.ExitTransient, "Error read sql schema version: ", zap.Error(err))
+			}
+			if current != sql_schema.Latest() {
+				exitWith(cli.ExitConfiguration, "SQL schema is out of date, run migrate: ",
+					zap.Int("version", current), zap.Int("latest", sql_schema.Latest()))
+			}
+		}
+
+		return storage{
+			settings:    setting_repository.NewSQL(db, dialect, keyring),
+			versions:    version_repository.NewSQL(db, dialect, clk),
+			histories:   history_repository.NewSQL(db, dialect, clk),
+			deliveries:  delivery_repository.NewSQL(db, dialect),
+			checkStates: check_state_repository.NewSQL(db, dialect),
+			checks:      check_repository.NewSQL(db, dialect),
+		}
+	default:
+		exitWith(cli.ExitConfiguration, "Unknown storage backend", zap.String("backend", cfg.StorageBackend))
+		return storage{}
+	}
+}
+
+// openSQL opens the SQL_DSN database of SQL_DIALECT.
+func openSQL(cfg config) (*sql.DB, sql_schema.Dialect) {
+	dialect, err := sql_schema.ParseDialect(cfg.SQLDialect)
+	if err != nil {
+		exitWith(cli.ExitConfiguration, "Error parse sql dialect: ", zap.Error(err))
+	}
+
+	// Driver names match the dialect names: modernc.org/sqlite
+	// registers "sqlite" and lib/pq registers "postgres".
+	db, err := sql.Open(string(dialect), cfg.SQLDsn)
+	if err != nil {
+		exitWith(cli.ExitTransient, "Error open sql database: ", zap.Error(err))
+	}
+	onShutdown("sql", func(ctx context.Context) error { return db.Close() })
+	return db, dialect
+}
+
+// migrate runs the migrate command. Only the SQL backend has a schema;
+// without SQL_AUTO_MIGRATE, it is the only way to update it.
+func migrate(cfg config, out io.Writer, args []string) error {
+	var migrator cli.SchemaMigrator
+	if cfg.StorageBackend == "sql" {
+		db, dialect := openSQL(cfg)
+		migrator = func(ctx context.Context) (int, int, error) {
+			from, err := sql_schema.Version(ctx, db)
+			if err != nil {
+				return 0, 0, fmt.Errorf("%s: %w", err, use_case.ErrDependencyUnavailable)
+			}
+			if err := sql_schema.Migrate(ctx, db, dialect); err != nil {
+				return from, from, fmt.Errorf("%s: %w", err, use_case.ErrDependencyUnavailable)
+			}
+			return from, sql_schema.Latest(), nil
+		}
+	}
+
+	ctx := context.Background()
+	if cfg.RunTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.RunTimeout)
+		defer cancel()
+	}
+	return cli.Migrate(ctx, out, args, cfg.StorageBackend, migrator)
+}
+
+// initKeyring loads the credential keys from CREDENTIAL_KEYS, or from
+// CREDENTIAL_KEY_FILE when the keys are mounted as a file. Without either,
+// credentials are stored in plaintext.
+func initKeyring(cfg config) *cryptography.Keyring {
+	keys := cfg.CredentialKeys
+	if len(cfg.CredentialKeyFile) > 0 {
+		data, err := os.ReadFile(cfg.CredentialKeyFile)
+		if err != nil {
+			exitWith(cli.ExitConfiguration, "Error read credential key file: ", zap.Error(err))
+		}
+		keys = string(data)
+	}
+
+	if len(keys) <= 0 {
+		zap.L().Warn("No credential key configured, credentials are stored in plaintext")
+		return nil
+	}
+
+	keyring, err := cryptography.ParseKeyring(keys)
+	if err != nil {
+		exitWith(cli.ExitConfiguration, "Error parse credential keys: ", zap.Error(err))
+	}
 
-	versionEventRepo := version_event_repository.NewKafkaMQ(cfg.KafkaServer, cfg.KafkaTopicVersionEvent)
-	return appRepo, settingRepo, pcrdTHRepo, pcrdJPRepo, versionRepo, historyRepo, versionEventRepo
+	return keyring
 }
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"os"
+	"time"
+)
+
+type shutdownHook struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+var (
+	shutdownHooks   []shutdownHook
+	shutdownTimeout = 30 * time.Second
+)
+
+// onShutdown registers fn to run when the process exits. Hooks run in
+// reverse order, so whatever was initialised last is closed first and the
+// tracer, initialised first, flushes the spans of everything else.
+func onShutdown(name string, fn func(ctx context.Context) error) {
+	shutdownHooks = append(shutdownHooks, shutdownHook{name: name, fn: fn})
+}
+
+// shutdown runs every hook within shutdownTimeout. A failing hook is logged
+// and does not stop the others.
+func shutdown() {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	for i := len(shutdownHooks) - 1; i >= 0; i-- {
+		hook := shutdownHooks[i]
+		if err := hook.fn(ctx); err != nil {
+			zap.L().Error("Error shutdown: ", zap.String("hook", hook.name), zap.Error(err))
+		}
+	}
+	shutdownHooks = nil
+}
+
+// exit shuts down and exits with code. Every exit after start-up goes
+// through here.
+func exit(code int) {
+	shutdown()
+	_ = zap.L().Sync()
+	os.Exit(code)
+}
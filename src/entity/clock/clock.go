@@ -0,0 +1,92 @@
+// Package clock tells the time and waits on it, so that code taking a Clock
+// can be tested on a Fake one instead of the system clock.
+package clock
+
+import (
+	"context"
+	"time"
+)
+
+type Clock interface {
+	Now() time.Time
+	Since(t time.Time) time.Duration
+	// Sleep waits for d, or returns ctx.Err() once ctx is done.
+	Sleep(ctx context.Context, d time.Duration) error
+	NewTimer(d time.Duration) Timer
+	NewTicker(d time.Duration) Ticker
+	// WithTimeout is context.WithTimeout with the deadline on this clock.
+	WithTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc)
+}
+
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+}
+
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+type real struct{}
+
+// Real is the system clock.
+func Real() Clock {
+	return real{}
+}
+
+func (real) Now() time.Time {
+	return time.Now()
+}
+
+func (real) Since(t time.Time) time.Duration {
+	return time.Since(t)
+}
+
+func (real) Sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+func (real) NewTimer(d time.Duration) Timer {
+	return realTimer{time.NewTimer(d)}
+}
+
+func (real) NewTicker(d time.Duration) Ticker {
+	return realTicker{time.NewTicker(d)}
+}
+
+func (real) WithTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, d)
+}
+
+type realTimer struct {
+	t *time.Timer
+}
+
+func (t realTimer) C() <-chan time.Time {
+	return t.t.C
+}
+
+func (t realTimer) Stop() bool {
+	return t.t.Stop()
+}
+
+type realTicker struct {
+	t *time.Ticker
+}
+
+func (t realTicker) C() <-chan time.Time {
+	return t.t.C
+}
+
+func (t realTicker) Stop() {
+	t.t.Stop()
+}
@@ -0,0 +1,241 @@
+package clock
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Fake is a clock that only moves when told to. Sleep moves it forward by
+// the time slept and returns at once, so code that sleeps runs instantly in
+// simulated time; timers, tickers and timeouts fire as it passes them.
+type Fake struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []*waiter
+}
+
+// waiter fires at at: it sends on ch, or calls fire. A ticker's waiter is
+// rescheduled every period.
+type waiter struct {
+	at      time.Time
+	period  time.Duration
+	ch      chan time.Time
+	fire    func()
+	stopped bool
+}
+
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now}
+}
+
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.now
+}
+
+func (f *Fake) Since(t time.Time) time.Duration {
+	return f.Now().Sub(t)
+}
+
+// Advance moves the clock forward by d, firing what falls due in order.
+func (f *Fake) Advance(d time.Duration) {
+	f.Set(f.Now().Add(d))
+}
+
+// Set moves the clock to t, firing what falls due in order. The clock never
+// goes back.
+func (f *Fake) Set(t time.Time) {
+	f.mu.Lock()
+	if t.After(f.now) {
+		f.now = t
+	}
+	now := f.now
+
+	var due []*waiter
+	kept := f.waiters[:0]
+	for _, w := range f.waiters {
+		switch {
+		case w.stopped:
+		case !w.at.After(now):
+			due = append(due, w)
+			if w.period > 0 {
+				kept = append(kept, w)
+			}
+		default:
+			kept = append(kept, w)
+		}
+	}
+	f.waiters = kept
+	sort.SliceStable(due, func(i, j int) bool { return due[i].at.Before(due[j].at) })
+
+	var fires []func()
+	for _, w := range due {
+		if w.fire != nil {
+			fires = append(fires, w.fire)
+			continue
+		}
+		// Like time.Ticker, drop ticks the receiver is not ready for.
+		select {
+		case w.ch <- w.at:
+		default:
+		}
+		if w.period > 0 {
+			for !w.at.After(now) {
+				w.at = w.at.Add(w.period)
+			}
+		}
+	}
+	f.mu.Unlock()
+
+	for _, fire := range fires {
+		fire()
+	}
+}
+
+// Waiters returns how many timers, tickers and timeouts are pending, so a
+// test can tell that another goroutine is waiting before advancing.
+func (f *Fake) Waiters() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return len(f.waiters)
+}
+
+func (f *Fake) Sleep(ctx context.Context, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	f.Advance(d)
+	return ctx.Err()
+}
+
+func (f *Fake) add(w *waiter) {
+	f.mu.Lock()
+	f.waiters = append(f.waiters, w)
+	f.mu.Unlock()
+	// Fire at once when already due.
+	f.Set(f.Now())
+}
+
+func (f *Fake) stop(w *waiter) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for i, pending := range f.waiters {
+		if pending == w {
+			f.waiters = append(f.waiters[:i], f.waiters[i+1:]...)
+			w.stopped = true
+			return true
+		}
+	}
+	return false
+}
+
+func (f *Fake) NewTimer(d time.Duration) Timer {
+	w := &waiter{at: f.Now().Add(d), ch: make(chan time.Time, 1)}
+	f.add(w)
+	return fakeTimer{f: f, w: w}
+}
+
+func (f *Fake) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("non-positive interval for clock.Fake.NewTicker")
+	}
+	w := &waiter{at: f.Now().Add(d), period: d, ch: make(chan time.Time, 1)}
+	f.add(w)
+	return fakeTicker{f: f, w: w}
+}
+
+func (f *Fake) WithTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	// A parent deadline may be on another clock; the parent cancels c
+	// through Done instead.
+	c := &timeoutContext{Context: ctx, deadline: f.Now().Add(d), done: make(chan struct{})}
+
+	w := &waiter{at: c.deadline, fire: func() { c.cancel(context.DeadlineExceeded) }}
+	stop := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			f.stop(w)
+			c.cancel(ctx.Err())
+		case <-stop:
+		case <-c.done:
+		}
+	}()
+	f.add(w)
+
+	var once sync.Once
+	return c, func() {
+		once.Do(func() {
+			f.stop(w)
+			close(stop)
+			c.cancel(context.Canceled)
+		})
+	}
+}
+
+type fakeTimer struct {
+	f *Fake
+	w *waiter
+}
+
+func (t fakeTimer) C() <-chan time.Time {
+	return t.w.ch
+}
+
+func (t fakeTimer) Stop() bool {
+	return t.f.stop(t.w)
+}
+
+type fakeTicker struct {
+	f *Fake
+	w *waiter
+}
+
+func (t fakeTicker) C() <-chan time.Time {
+	return t.w.ch
+}
+
+func (t fakeTicker) Stop() {
+	t.f.stop(t.w)
+}
+
+// timeoutContext is done when the fake clock reaches its deadline, or when
+// its parent is done.
+type timeoutContext struct {
+	context.Context
+	deadline time.Time
+	done     chan struct{}
+	mu       sync.Mutex
+	err      error
+}
+
+func (c *timeoutContext) Deadline() (time.Time, bool) {
+	return c.deadline, true
+}
+
+func (c *timeoutContext) Done() <-chan struct{} {
+	return c.done
+}
+
+func (c *timeoutContext) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.err
+}
+
+func (c *timeoutContext) cancel(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.err != nil {
+		return
+	}
+	c.err = err
+	close(c.done)
+}
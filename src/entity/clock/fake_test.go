@@ -0,0 +1,97 @@
+package clock_test
+
+import (
+	"context"
+	"errors"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/clock"
+	"testing"
+	"time"
+)
+
+var start = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestFakeSleep(t *testing.T) {
+	f := clock.NewFake(start)
+
+	if err := f.Sleep(context.Background(), time.Minute); err != nil {
+		t.Fatalf("Sleep: %v", err)
+	}
+	if got := f.Since(start); got != time.Minute {
+		t.Fatalf("Since: got %s, want the minute slept", got)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := f.Sleep(ctx, time.Minute); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Sleep on a cancelled context: got %v", err)
+	}
+	if got := f.Since(start); got != time.Minute {
+		t.Fatalf("Since: got %s, want no time slept once cancelled", got)
+	}
+}
+
+func TestFakeTimerAndTicker(t *testing.T) {
+	f := clock.NewFake(start)
+	timer := f.NewTimer(time.Second)
+	ticker := f.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	select {
+	case <-timer.C():
+		t.Fatalf("timer fired before its time")
+	default:
+	}
+
+	f.Advance(time.Second)
+	if got := <-timer.C(); !got.Equal(start.Add(time.Second)) {
+		t.Fatalf("timer: got %s", got)
+	}
+	if got := <-ticker.C(); !got.Equal(start.Add(time.Second)) {
+		t.Fatalf("ticker: got %s", got)
+	}
+	if timer.Stop() {
+		t.Fatalf("Stop: a fired timer is not pending")
+	}
+
+	f.Advance(time.Second)
+	if got := <-ticker.C(); !got.Equal(start.Add(2 * time.Second)) {
+		t.Fatalf("ticker: got %s, want the second tick", got)
+	}
+	if f.Waiters() != 1 {
+		t.Fatalf("Waiters: got %d, want the ticker", f.Waiters())
+	}
+}
+
+func TestFakeWithTimeout(t *testing.T) {
+	f := clock.NewFake(start)
+
+	ctx, cancel := f.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if deadline, ok := ctx.Deadline(); !ok || !deadline.Equal(start.Add(time.Minute)) {
+		t.Fatalf("Deadline: got %s, %t", deadline, ok)
+	}
+
+	// Sleeping within the deadline passes the time towards it.
+	if err := f.Sleep(ctx, 30*time.Second); err != nil {
+		t.Fatalf("Sleep: %v", err)
+	}
+	if err := f.Sleep(ctx, 30*time.Second); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Sleep past the deadline: got %v, want DeadlineExceeded", err)
+	}
+	<-ctx.Done()
+
+	parent, cancelParent := context.WithCancel(context.Background())
+	child, cancelChild := f.WithTimeout(parent, time.Minute)
+	defer cancelChild()
+	cancelParent()
+	<-child.Done()
+	if !errors.Is(child.Err(), context.Canceled) {
+		t.Fatalf("Err after the parent is cancelled: got %v", child.Err())
+	}
+
+	ctx, cancel = f.WithTimeout(context.Background(), time.Minute)
+	cancel()
+	if !errors.Is(ctx.Err(), context.Canceled) || f.Waiters() != 0 {
+		t.Fatalf("cancel: got %v with %d waiters, want Canceled and none", ctx.Err(), f.Waiters())
+	}
+}
@@ -0,0 +1,92 @@
+package cloudevent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrInvalidMode = errors.New("unknow cloudevent mode")
+)
+
+const (
+	SpecVersion = "1.0"
+
+	ContentTypeJSON       = "application/json"
+	ContentTypeStructured = "application/cloudevents+json"
+)
+
+// Mode is the CloudEvents content mode used when writing to a transport.
+type Mode string
+
+const (
+	ModeBinary     Mode = "binary"
+	ModeStructured Mode = "structured"
+)
+
+func ParseMode(s string) (d Mode, e error) {
+	dataTypes := map[Mode]struct{}{
+		ModeBinary:     {},
+		ModeStructured: {},
+	}
+
+	dat := Mode(s)
+	_, ok := dataTypes[dat]
+	if !ok {
+		return d, fmt.Errorf("cannot parse:[%s] as cloudevent mode: %w", s, ErrInvalidMode)
+	}
+	return dat, nil
+}
+
+// Event is a CloudEvents 1.0 event carrying JSON data.
+type Event struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Type            string          `json:"type"`
+	Source          string          `json:"source"`
+	Subject         string          `json:"subject,omitempty"`
+	Time            time.Time       `json:"time"`
+	DataSchema      string          `json:"dataschema,omitempty"`
+	DataContentType string          `json:"datacontenttype"`
+	Data            json.RawMessage `json:"data"`
+}
+
+func New(ID string, eventType string, source string, subject string, dataSchema string, t time.Time, data interface{}) (Event, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return Event{}, err
+	}
+
+	return Event{
+		SpecVersion:     SpecVersion,
+		ID:              ID,
+		Type:            eventType,
+		Source:          source,
+		Subject:         subject,
+		Time:            t.UTC(),
+		DataSchema:      dataSchema,
+		DataContentType: ContentTypeJSON,
+		Data:            raw,
+	}, nil
+}
+
+// Attributes returns the context attributes keyed by their CloudEvents
+// name, for transports that carry them out of band in binary mode.
+func (e Event) Attributes() map[string]string {
+	attributes := map[string]string{
+		"specversion": e.SpecVersion,
+		"id":          e.ID,
+		"type":        e.Type,
+		"source":      e.Source,
+		"time":        e.Time.Format(time.RFC3339Nano),
+	}
+	if len(e.Subject) > 0 {
+		attributes["subject"] = e.Subject
+	}
+	if len(e.DataSchema) > 0 {
+		attributes["dataschema"] = e.DataSchema
+	}
+	return attributes
+}
@@ -0,0 +1,212 @@
+// Package configuration layers an optional YAML file under environment
+// variables and renders the effective configuration with secrets redacted.
+//
+// A configuration is a struct whose leaf fields carry the `env` tags of
+// github.com/caarlos0/env and whose fields all carry a `yaml` tag naming
+// their key in the file. Nested structs become nested mappings. A leaf
+// tagged `secret:"true"` is redacted when rendered; `secret:"dsn"` only
+// redacts the password of a URL or key=value connection string.
+package configuration
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/caarlos0/env/v6"
+	"gopkg.in/yaml.v2"
+	"net/url"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+var ErrInvalidConfig = errors.New("invalid configuration")
+
+// Redacted replaces secrets in rendered configurations.
+const Redacted = "REDACTED"
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// Load parses cfg, a pointer to a configuration struct, from, lowest first:
+// the envDefault values, the YAML file at path when not empty, and environ.
+// Unknown keys in the file are errors, so a typo is not silently ignored.
+func Load(cfg interface{}, path string, environ map[string]string) error {
+	merged := map[string]string{}
+	if len(path) > 0 {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("read %s: %s: %w", path, err, ErrInvalidConfig)
+		}
+		values := map[interface{}]interface{}{}
+		if err := yaml.Unmarshal(data, &values); err != nil {
+			return fmt.Errorf("parse %s: %s: %w", path, err, ErrInvalidConfig)
+		}
+		var problems []string
+		flatten(reflect.TypeOf(cfg).Elem(), values, "", merged, &problems)
+		if len(problems) > 0 {
+			sort.Strings(problems)
+			return fmt.Errorf("%s: %s: %w", path, strings.Join(problems, "; "), ErrInvalidConfig)
+		}
+	}
+	for k, v := range environ {
+		merged[k] = v
+	}
+
+	if err := env.Parse(cfg, env.Options{Environment: merged}); err != nil {
+		return fmt.Errorf("%s: %w", err, ErrInvalidConfig)
+	}
+	return nil
+}
+
+// Environ returns the process environment as a map for Load.
+func Environ() map[string]string {
+	environ := map[string]string{}
+	for _, kv := range os.Environ() {
+		if i := strings.IndexByte(kv, '='); i > 0 {
+			environ[kv[:i]] = kv[i+1:]
+		}
+	}
+	return environ
+}
+
+// Flags registers on fs a flag for every env variable of cfg, a pointer to
+// a configuration struct, named after the variable in kebab case:
+// STORAGE_BACKEND becomes --storage-backend. Secrets, including DSNs, get no
+// flag: arguments show in ps and pod specs. Once fs is parsed, the returned
+// function gives the variables of the flags set on the command line, to
+// layer over the environment passed to Load.
+func Flags(fs *flag.FlagSet, cfg interface{}) func() map[string]string {
+	names := map[string]string{}
+	var register func(t reflect.Type, prefix string)
+	register = func(t reflect.Type, prefix string) {
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			key := prefix + f.Tag.Get("yaml")
+			if f.Type.Kind() == reflect.Struct {
+				register(f.Type, key+".")
+				continue
+			}
+			if len(f.Tag.Get("secret")) > 0 {
+				continue
+			}
+			name := f.Tag.Get("env")
+			flagName := strings.ReplaceAll(strings.ToLower(name), "_", "-")
+			names[flagName] = name
+			fs.String(flagName, "", fmt.Sprintf("overrides %s (%s)", name, key))
+		}
+	}
+	register(reflect.TypeOf(cfg).Elem(), "")
+
+	return func() map[string]string {
+		set := map[string]string{}
+		fs.Visit(func(f *flag.Flag) {
+			if name, ok := names[f.Name]; ok {
+				set[name] = f.Value.String()
+			}
+		})
+		return set
+	}
+}
+
+// flatten maps the YAML values onto the env variables of t, so that the
+// file and the environment go through the same parsing. Keys it cannot map
+// are added to problems.
+func flatten(t reflect.Type, values map[interface{}]interface{}, prefix string, out map[string]string, problems *[]string) {
+	known := map[string]struct{}{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		key := f.Tag.Get("yaml")
+		known[key] = struct{}{}
+		value, ok := values[key]
+		if !ok || value == nil {
+			continue
+		}
+
+		if f.Type.Kind() == reflect.Struct {
+			nested, ok := value.(map[interface{}]interface{})
+			if !ok {
+				*problems = append(*problems, fmt.Sprintf("%s%s: want a mapping", prefix, key))
+				continue
+			}
+			flatten(f.Type, nested, prefix+key+".", out, problems)
+			continue
+		}
+
+		name := f.Tag.Get("env")
+		switch v := value.(type) {
+		case []interface{}:
+			separator := f.Tag.Get("envSeparator")
+			if len(separator) <= 0 {
+				separator = ","
+			}
+			items := make([]string, len(v))
+			for j := range v {
+				items[j] = fmt.Sprint(v[j])
+			}
+			out[name] = strings.Join(items, separator)
+		case map[interface{}]interface{}:
+			*problems = append(*problems, fmt.Sprintf("%s%s: want a value, not a mapping", prefix, key))
+		default:
+			out[name] = fmt.Sprint(v)
+		}
+	}
+
+	for k := range values {
+		if _, ok := known[fmt.Sprint(k)]; !ok {
+			*problems = append(*problems, fmt.Sprintf("unknown key %s%v", prefix, k))
+		}
+	}
+}
+
+// Render returns cfg, a configuration struct, in the shape of its YAML
+// file with every secret redacted. Durations are written as "1m30s".
+func Render(cfg interface{}) yaml.MapSlice {
+	return render(reflect.Indirect(reflect.ValueOf(cfg)))
+}
+
+func render(v reflect.Value) yaml.MapSlice {
+	out := yaml.MapSlice{}
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Type().Field(i)
+		value := v.Field(i)
+
+		var rendered interface{}
+		switch {
+		case f.Type.Kind() == reflect.Struct:
+			rendered = render(value)
+		case f.Type == durationType:
+			rendered = time.Duration(value.Int()).String()
+		default:
+			rendered = value.Interface()
+		}
+
+		switch f.Tag.Get("secret") {
+		case "true":
+			if !value.IsZero() {
+				rendered = Redacted
+			}
+		case "dsn":
+			rendered = redactDSN(value.String())
+		}
+
+		out = append(out, yaml.MapItem{Key: f.Tag.Get("yaml"), Value: rendered})
+	}
+	return out
+}
+
+var dsnPassword = regexp.MustCompile(`(?i)(password\s*=\s*)('[^']*'|\S+)`)
+
+// redactDSN hides the password of a URL, such as a MongoDB URI, or of a
+// key=value connection string, such as a PostgreSQL DSN.
+func redactDSN(s string) string {
+	if u, err := url.Parse(s); err == nil && len(u.Scheme) > 0 && u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), Redacted)
+		}
+		return u.String()
+	}
+	return dsnPassword.ReplaceAllString(s, "${1}"+Redacted)
+}
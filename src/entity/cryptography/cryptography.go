@@ -1,8 +1,10 @@
 package cryptography
 
 import (
+	"crypto/hmac"
 	"crypto/md5"
 	"crypto/sha1"
+	"crypto/sha256"
 	"encoding/hex"
 )
 
@@ -15,3 +17,9 @@ func MakeSHA1(input string) string {
 	hash := sha1.Sum([]byte(input))
 	return hex.EncodeToString(hash[:])
 }
+
+func MakeHMACSHA256(key string, input string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(input))
+	return hex.EncodeToString(mac.Sum(nil))
+}
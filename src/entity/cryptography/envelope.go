@@ -0,0 +1,162 @@
+package cryptography
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+var (
+	ErrInvalidKey = errors.New("invalid encryption key")
+	ErrUnknownKey = errors.New("unknown encryption key")
+	ErrDecrypt    = errors.New("decryption failed")
+)
+
+const keySize = 32
+
+// Keyring holds the key encryption keys (KEK). New data is always sealed
+// with the current key, older keys are kept only so existing data can still
+// be opened until it is re-encrypted.
+type Keyring struct {
+	currentID string
+	keys      map[string][]byte
+}
+
+// Sealed is an envelope: Ciphertext is encrypted with a random data key,
+// and that data key is stored wrapped by the KEK identified by KeyID.
+type Sealed struct {
+	KeyID      string
+	DataKey    []byte
+	Ciphertext []byte
+}
+
+// ParseKeyring reads keys in the form "id:base64key", separated by commas or
+// newlines. The first key is the current one.
+func ParseKeyring(s string) (*Keyring, error) {
+	k := &Keyring{keys: map[string][]byte{}}
+
+	entries := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == '\n' || r == '\r'
+	})
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if len(entry) <= 0 {
+			continue
+		}
+
+		parts := strings.SplitN(entry, ":", 2)
+		if len(parts) != 2 || len(parts[0]) <= 0 {
+			return nil, fmt.Errorf("key must be in the form id:base64key: %w", ErrInvalidKey)
+		}
+
+		key, err := base64.StdEncoding.DecodeString(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("key %s is not base64: %w", parts[0], ErrInvalidKey)
+		}
+		if len(key) != keySize {
+			return nil, fmt.Errorf("key %s must be %d bytes: %w", parts[0], keySize, ErrInvalidKey)
+		}
+		if _, ok := k.keys[parts[0]]; ok {
+			return nil, fmt.Errorf("key %s is duplicated: %w", parts[0], ErrInvalidKey)
+		}
+
+		if len(k.currentID) <= 0 {
+			k.currentID = parts[0]
+		}
+		k.keys[parts[0]] = key
+	}
+
+	if len(k.currentID) <= 0 {
+		return nil, fmt.Errorf("no key given: %w", ErrInvalidKey)
+	}
+
+	return k, nil
+}
+
+func (k *Keyring) CurrentKeyID() string {
+	return k.currentID
+}
+
+// Seal encrypts plaintext under a fresh data key. additionalData is
+// authenticated but not stored, so the same value must be given to Open.
+func (k *Keyring) Seal(plaintext []byte, additionalData []byte) (Sealed, error) {
+	dataKey := make([]byte, keySize)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return Sealed{}, err
+	}
+
+	ciphertext, err := sealAESGCM(dataKey, plaintext, additionalData)
+	if err != nil {
+		return Sealed{}, err
+	}
+
+	wrapped, err := sealAESGCM(k.keys[k.currentID], dataKey, []byte(k.currentID))
+	if err != nil {
+		return Sealed{}, err
+	}
+
+	return Sealed{
+		KeyID:      k.currentID,
+		DataKey:    wrapped,
+		Ciphertext: ciphertext,
+	}, nil
+}
+
+func (k *Keyring) Open(s Sealed, additionalData []byte) ([]byte, error) {
+	kek, ok := k.keys[s.KeyID]
+	if !ok {
+		return nil, fmt.Errorf("key %s: %w", s.KeyID, ErrUnknownKey)
+	}
+
+	dataKey, err := openAESGCM(kek, s.DataKey, []byte(s.KeyID))
+	if err != nil {
+		return nil, fmt.Errorf("unwrap data key: %w", err)
+	}
+
+	return openAESGCM(dataKey, s.Ciphertext, additionalData)
+}
+
+func sealAESGCM(key []byte, plaintext []byte, additionalData []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	return gcm.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+func openAESGCM(key []byte, data []byte, additionalData []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < gcm.NonceSize() {
+		return nil, fmt.Errorf("ciphertext too short: %w", ErrDecrypt)
+	}
+
+	plaintext, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], additionalData)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", err, ErrDecrypt)
+	}
+
+	return plaintext, nil
+}
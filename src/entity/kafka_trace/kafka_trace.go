@@ -0,0 +1,62 @@
+// Package kafka_trace carries W3C trace context (traceparent, tracestate)
+// and baggage in Kafka message headers. Producers call Inject before writing
+// a message; consumers call Extract on a read message and start their spans
+// from the returned context to continue the producer's trace.
+package kafka_trace
+
+import (
+	"context"
+	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// HeaderCarrier adapts Kafka message headers to propagation.TextMapCarrier.
+type HeaderCarrier struct {
+	Headers *[]kafka.Header
+}
+
+var _ propagation.TextMapCarrier = HeaderCarrier{}
+
+func (c HeaderCarrier) Get(key string) string {
+	for _, h := range *c.Headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+// Set replaces the header if it already exists, so injecting twice does not
+// leave two conflicting traceparent headers.
+func (c HeaderCarrier) Set(key string, value string) {
+	for i := range *c.Headers {
+		if (*c.Headers)[i].Key == key {
+			(*c.Headers)[i].Value = []byte(value)
+			return
+		}
+	}
+	*c.Headers = append(*c.Headers, kafka.Header{Key: key, Value: []byte(value)})
+}
+
+func (c HeaderCarrier) Keys() []string {
+	keys := make([]string, len(*c.Headers))
+	for i, h := range *c.Headers {
+		keys[i] = h.Key
+	}
+	return keys
+}
+
+// Inject writes the trace context of ctx into the message headers using the
+// global propagator.
+func Inject(ctx context.Context, message *kafka.Message) {
+	otel.GetTextMapPropagator().Inject(ctx, HeaderCarrier{Headers: &message.Headers})
+}
+
+// Extract returns ctx with the trace context read from the message headers,
+// using the global propagator. Set it to propagation.TraceContext{} (or a
+// composite including it) before consuming.
+func Extract(ctx context.Context, message kafka.Message) context.Context {
+	headers := message.Headers
+	return otel.GetTextMapPropagator().Extract(ctx, HeaderCarrier{Headers: &headers})
+}
@@ -0,0 +1,41 @@
+package kafka_trace
+
+import (
+	"context"
+	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"testing"
+)
+
+func TestInjectExtract(t *testing.T) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	tp := sdktrace.NewTracerProvider()
+
+	ctx, span := tp.Tracer("test").Start(context.Background(), "produce")
+	defer span.End()
+
+	message := kafka.Message{Headers: []kafka.Header{{Key: "ce_id", Value: []byte("1")}}}
+	Inject(ctx, &message)
+	Inject(ctx, &message)
+
+	count := 0
+	for _, h := range message.Headers {
+		if h.Key == "traceparent" {
+			count++
+		}
+	}
+	if count != 1 {
+		t.Fatalf("got %d traceparent headers, want 1", count)
+	}
+
+	got := trace.SpanContextFromContext(Extract(context.Background(), message))
+	if got.TraceID() != span.SpanContext().TraceID() || got.SpanID() != span.SpanContext().SpanID() {
+		t.Fatalf("extracted %s/%s, want %s/%s", got.TraceID(), got.SpanID(), span.SpanContext().TraceID(), span.SpanContext().SpanID())
+	}
+	if !got.IsRemote() {
+		t.Fatalf("extracted span context is not remote")
+	}
+}
@@ -3,6 +3,7 @@ package platform
 import (
 	"errors"
 	"fmt"
+	"strings"
 )
 
 var (
@@ -15,6 +16,8 @@ const (
 	PlatformTypeNone    PlatformType = ""
 	PlatformTypeAndroid PlatformType = "android"
 	PlatformTypeIOS     PlatformType = "ios"
+	// PlatformTypeWindows is the PC build, distributed through DMM.
+	PlatformTypeWindows PlatformType = "windows"
 )
 
 func ParsePlatformType(s string) (d PlatformType, e error) {
@@ -22,6 +25,7 @@ func ParsePlatformType(s string) (d PlatformType, e error) {
 		PlatformTypeNone:    {},
 		PlatformTypeAndroid: {},
 		PlatformTypeIOS:     {},
+		PlatformTypeWindows: {},
 	}
 
 	dat := PlatformType(s)
@@ -31,3 +35,45 @@ func ParsePlatformType(s string) (d PlatformType, e error) {
 	}
 	return dat, nil
 }
+
+// Names converts platforms to their names, nil when there are none.
+func Names(platforms []PlatformType) []string {
+	if len(platforms) <= 0 {
+		return nil
+	}
+	names := make([]string, len(platforms))
+	for i, p := range platforms {
+		names[i] = string(p)
+	}
+	return names
+}
+
+// ParseList parses every name with ParsePlatformType, nil when there are
+// none.
+func ParseList(names []string) ([]PlatformType, error) {
+	if len(names) <= 0 {
+		return nil, nil
+	}
+	platforms := make([]PlatformType, len(names))
+	for i, name := range names {
+		p, err := ParsePlatformType(name)
+		if err != nil {
+			return nil, err
+		}
+		platforms[i] = p
+	}
+	return platforms, nil
+}
+
+// SplitList splits a comma-separated list of platforms or locales and trims
+// each item. A blank list is nil.
+func SplitList(s string) []string {
+	if len(strings.TrimSpace(s)) <= 0 {
+		return nil
+	}
+	items := strings.Split(s, ",")
+	for i := range items {
+		items[i] = strings.TrimSpace(items[i])
+	}
+	return items
+}
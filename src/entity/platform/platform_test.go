@@ -0,0 +1,40 @@
+package platform_test
+
+import (
+	"errors"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/platform"
+	"reflect"
+	"testing"
+)
+
+func TestList(t *testing.T) {
+	for s, want := range map[string][]string{
+		"":                nil,
+		"  ":              nil,
+		"android":         {"android"},
+		"android, ios ,":  {"android", "ios", ""},
+		"windows,android": {"windows", "android"},
+	} {
+		if got := platform.SplitList(s); !reflect.DeepEqual(got, want) {
+			t.Fatalf("SplitList(%q): got %q, want %q", s, got, want)
+		}
+	}
+
+	platforms, err := platform.ParseList([]string{"ios", "windows"})
+	if err != nil {
+		t.Fatalf("ParseList: %v", err)
+	}
+	if want := []platform.PlatformType{platform.PlatformTypeIOS, platform.PlatformTypeWindows}; !reflect.DeepEqual(platforms, want) {
+		t.Fatalf("ParseList: got %v, want %v", platforms, want)
+	}
+	if names := platform.Names(platforms); !reflect.DeepEqual(names, []string{"ios", "windows"}) {
+		t.Fatalf("Names: got %q", names)
+	}
+	if platform.Names(nil) != nil {
+		t.Fatalf("Names(nil): want nil")
+	}
+
+	if _, err := platform.ParseList([]string{"android", "switch"}); !errors.Is(err, platform.ErrInvalidPlatform) {
+		t.Fatalf("ParseList: got %v, want %v", err, platform.ErrInvalidPlatform)
+	}
+}
@@ -0,0 +1,68 @@
+// Package tracing parses the tracing configuration. Names follow the
+// OpenTelemetry environment variable specification where one exists.
+package tracing
+
+import (
+	"errors"
+	"fmt"
+	"go.opentelemetry.io/otel/sdk/trace"
+)
+
+var (
+	ErrInvalidExporter = errors.New("unknow trace exporter")
+	ErrInvalidSampler  = errors.New("unknow trace sampler")
+)
+
+type Exporter string
+
+const (
+	ExporterOTLPGRPC Exporter = "otlp-grpc"
+	ExporterOTLPHTTP Exporter = "otlp-http"
+	ExporterStdout   Exporter = "stdout"
+	ExporterNone     Exporter = "none"
+	// ExporterJaeger is deprecated upstream and kept for existing
+	// deployments until they move to OTLP.
+	ExporterJaeger Exporter = "jaeger"
+)
+
+func ParseExporter(s string) (d Exporter, e error) {
+	dataTypes := map[Exporter]struct{}{
+		ExporterOTLPGRPC: {},
+		ExporterOTLPHTTP: {},
+		ExporterStdout:   {},
+		ExporterNone:     {},
+		ExporterJaeger:   {},
+	}
+
+	dat := Exporter(s)
+	_, ok := dataTypes[dat]
+	if !ok {
+		return d, fmt.Errorf("cannot parse:[%s] as trace exporter: %w", s, ErrInvalidExporter)
+	}
+	return dat, nil
+}
+
+// ParseSampler returns the sampler named as in OTEL_TRACES_SAMPLER. ratio
+// is the OTEL_TRACES_SAMPLER_ARG of the traceidratio samplers, in [0, 1].
+func ParseSampler(name string, ratio float64) (trace.Sampler, error) {
+	if (name == "traceidratio" || name == "parentbased_traceidratio") && (ratio < 0 || ratio > 1) {
+		return nil, fmt.Errorf("sampler ratio %v not in [0, 1]: %w", ratio, ErrInvalidSampler)
+	}
+
+	switch name {
+	case "always_on":
+		return trace.AlwaysSample(), nil
+	case "always_off":
+		return trace.NeverSample(), nil
+	case "traceidratio":
+		return trace.TraceIDRatioBased(ratio), nil
+	case "parentbased_always_on":
+		return trace.ParentBased(trace.AlwaysSample()), nil
+	case "parentbased_always_off":
+		return trace.ParentBased(trace.NeverSample()), nil
+	case "parentbased_traceidratio":
+		return trace.ParentBased(trace.TraceIDRatioBased(ratio)), nil
+	default:
+		return nil, fmt.Errorf("cannot parse:[%s] as trace sampler: %w", name, ErrInvalidSampler)
+	}
+}
@@ -0,0 +1,40 @@
+package tracing
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseSampler(t *testing.T) {
+	for name, want := range map[string]string{
+		"always_on":                "AlwaysOnSampler",
+		"always_off":               "AlwaysOffSampler",
+		"traceidratio":             "TraceIDRatioBased{0.25}",
+		"parentbased_always_on":    "ParentBased{root:AlwaysOnSampler,remoteParentSampled:AlwaysOnSampler,remoteParentNotSampled:AlwaysOffSampler,localParentSampled:AlwaysOnSampler,localParentNotSampled:AlwaysOffSampler}",
+		"parentbased_traceidratio": "ParentBased{root:TraceIDRatioBased{0.25},remoteParentSampled:AlwaysOnSampler,remoteParentNotSampled:AlwaysOffSampler,localParentSampled:AlwaysOnSampler,localParentNotSampled:AlwaysOffSampler}",
+	} {
+		sampler, err := ParseSampler(name, 0.25)
+		if err != nil {
+			t.Fatalf("ParseSampler(%s): %v", name, err)
+		}
+		if got := sampler.Description(); got != want {
+			t.Errorf("ParseSampler(%s): got %s, want %s", name, got, want)
+		}
+	}
+
+	if _, err := ParseSampler("sometimes", 1); !errors.Is(err, ErrInvalidSampler) {
+		t.Errorf("ParseSampler(sometimes): got %v, want %v", err, ErrInvalidSampler)
+	}
+	if _, err := ParseSampler("traceidratio", 1.5); !errors.Is(err, ErrInvalidSampler) {
+		t.Errorf("ParseSampler(traceidratio, 1.5): got %v, want %v", err, ErrInvalidSampler)
+	}
+}
+
+func TestParseExporter(t *testing.T) {
+	if _, err := ParseExporter("otlp-http"); err != nil {
+		t.Fatalf("ParseExporter: %v", err)
+	}
+	if _, err := ParseExporter("zipkin"); !errors.Is(err, ErrInvalidExporter) {
+		t.Fatalf("ParseExporter(zipkin): got %v, want %v", err, ErrInvalidExporter)
+	}
+}
@@ -0,0 +1,60 @@
+// Package webhook_signature signs webhook requests and lets receivers verify
+// them. The signature is an HMAC-SHA256 over "<timestamp>.<body>", so a
+// captured request cannot be replayed once its timestamp is out of tolerance.
+package webhook_signature
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/cryptography"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrExpiredTimestamp = errors.New("webhook timestamp outside tolerance")
+)
+
+const (
+	HeaderSignature = "X-PCRD-Signature"
+	HeaderTimestamp = "X-PCRD-Timestamp"
+	HeaderEventID   = "X-PCRD-Event-ID"
+
+	signaturePrefix = "sha256="
+
+	// DefaultTolerance is how far a timestamp may be from the receiver's clock.
+	DefaultTolerance = 5 * time.Minute
+)
+
+// Sign returns the HeaderSignature value for body sent at timestamp.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	return signaturePrefix + cryptography.MakeHMACSHA256(secret, fmt.Sprintf("%d.%s", timestamp.Unix(), body))
+}
+
+// Verify checks the HeaderSignature and HeaderTimestamp values of a request
+// against body, rejecting timestamps further than tolerance from now.
+func Verify(secret string, signature string, timestamp string, body []byte, now time.Time, tolerance time.Duration) error {
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("timestamp %q: %w", timestamp, ErrInvalidSignature)
+	}
+
+	sent := time.Unix(unix, 0)
+	if now.Sub(sent) > tolerance || sent.Sub(now) > tolerance {
+		return fmt.Errorf("timestamp %s: %w", sent.UTC().Format(time.RFC3339), ErrExpiredTimestamp)
+	}
+
+	if !strings.HasPrefix(signature, signaturePrefix) {
+		return fmt.Errorf("missing %s prefix: %w", signaturePrefix, ErrInvalidSignature)
+	}
+
+	want := Sign(secret, sent, body)
+	if subtle.ConstantTimeCompare([]byte(signature), []byte(want)) != 1 {
+		return fmt.Errorf("%w", ErrInvalidSignature)
+	}
+
+	return nil
+}
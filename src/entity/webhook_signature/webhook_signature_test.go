@@ -0,0 +1,39 @@
+package webhook_signature
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestVerify(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	body := []byte(`{"id":"1"}`)
+	signature := Sign("secret", now, body)
+	timestamp := fmt.Sprintf("%d", now.Unix())
+
+	if err := Verify("secret", signature, timestamp, body, now.Add(time.Minute), DefaultTolerance); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+
+	for name, tc := range map[string]struct {
+		secret    string
+		signature string
+		timestamp string
+		body      []byte
+		now       time.Time
+		want      error
+	}{
+		"wrong secret":   {"other", signature, timestamp, body, now, ErrInvalidSignature},
+		"tampered body":  {"secret", signature, timestamp, []byte(`{"id":"2"}`), now, ErrInvalidSignature},
+		"moved stamp":    {"secret", signature, fmt.Sprintf("%d", now.Unix()+1), body, now, ErrInvalidSignature},
+		"replayed later": {"secret", signature, timestamp, body, now.Add(time.Hour), ErrExpiredTimestamp},
+		"bad stamp":      {"secret", signature, "yesterday", body, now, ErrInvalidSignature},
+	} {
+		err := Verify(tc.secret, tc.signature, tc.timestamp, tc.body, tc.now, DefaultTolerance)
+		if !errors.Is(err, tc.want) {
+			t.Fatalf("%s: got %v, want %v", name, err, tc.want)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+// Package pcrd_jp_cdn is a fake JP CDN for tests and local runs. It serves
+// the asset manifest of every version published so far on its clock, and
+// can be made slow, failing or misleading on purpose.
+package pcrd_jp_cdn
+
+import (
+	"fmt"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/clock"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Fault changes how the CDN answers manifest requests.
+type Fault struct {
+	// Version limits the fault to one version; zero applies it to all.
+	Version int64
+	// Latency delays the answer on the CDN's clock, or until the client
+	// gives up.
+	Latency time.Duration
+	// Status answers with this status, such as 503, instead.
+	Status int
+	// Bogus answers 200 with an error page instead of the manifest, as a
+	// misconfigured edge does.
+	Bogus bool
+	// Times is how many requests the fault applies to; zero is every one.
+	Times int
+}
+
+func (f Fault) matches(version int64) bool {
+	return f.Version == 0 || f.Version == version
+}
+
+// Request is a manifest request the CDN received and how it answered.
+type Request struct {
+	Version  int64
+	Locale   string
+	Platform string
+	Status   int
+	Bogus    bool
+	Time     time.Time
+}
+
+type CDN struct {
+	mu    sync.Mutex
+	clock clock.Clock
+	// published holds when each version comes out on every platform
+	// under "", and on one platform only under its path segment.
+	published map[string]map[int64]time.Time
+	faults    []Fault
+	requests  []Request
+}
+
+// New returns a CDN with nothing published, on clk; a nil clk is the system
+// clock. Tests pass a clock.Fake they move forward to publish over time.
+func New(clk clock.Clock) *CDN {
+	if clk == nil {
+		clk = clock.Real()
+	}
+	return &CDN{clock: clk, published: map[string]map[int64]time.Time{}}
+}
+
+// Publish makes version available from at on the CDN's clock, for every
+// locale and platform.
+func (c *CDN) Publish(version int64, at time.Time) {
+	c.PublishOn("", version, at)
+}
+
+// PublishOn makes version available from at on one platform only, named by
+// its path segment such as iOS, so that it can lag the others.
+func (c *CDN) PublishOn(platform string, version int64, at time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.published[platform] == nil {
+		c.published[platform] = map[int64]time.Time{}
+	}
+	c.published[platform][version] = at
+}
+
+// isPublished tells whether version is out on platform at t.
+func (c *CDN) isPublished(platform string, version int64, t time.Time) bool {
+	for _, p := range []string{"", platform} {
+		if at, ok := c.published[p][version]; ok && !t.Before(at) {
+			return true
+		}
+	}
+	return false
+}
+
+// Inject adds f; when several faults match a request, the first one added
+// applies.
+func (c *CDN) Inject(f Fault) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.faults = append(c.faults, f)
+}
+
+// Requests returns every manifest request received so far, oldest first.
+func (c *CDN) Requests() []Request {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]Request(nil), c.requests...)
+}
+
+// ServeHTTP answers /dl/Resources/{version}/{locale}/AssetBundles/
+// {platform}/manifest/manifest_assetmanifest; anything else is forbidden,
+// like the root of the real CDN.
+func (c *CDN) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	request, ok := parsePath(r.URL.Path)
+	if !ok || (r.Method != http.MethodGet && r.Method != http.MethodHead) {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	c.mu.Lock()
+	request.Time = c.clock.Now()
+	fault := c.takeFault(request.Version)
+	published := c.isPublished(request.Platform, request.Version, request.Time)
+	switch {
+	case fault.Status > 0:
+		request.Status = fault.Status
+	case fault.Bogus:
+		request.Status = http.StatusOK
+		request.Bogus = true
+	case published:
+		request.Status = http.StatusOK
+	default:
+		request.Status = http.StatusNotFound
+	}
+	c.requests = append(c.requests, request)
+	c.mu.Unlock()
+
+	if fault.Latency > 0 {
+		if err := c.clock.Sleep(r.Context(), fault.Latency); err != nil {
+			return
+		}
+	}
+
+	switch {
+	case request.Bogus:
+		w.Header().Set("Content-Type", "text/html")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, "<html><body><h1>Service Unavailable</h1></body></html>")
+	case request.Status == http.StatusOK:
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, manifest(request))
+	default:
+		w.WriteHeader(request.Status)
+	}
+}
+
+// takeFault returns the first fault matching version, counting it down.
+func (c *CDN) takeFault(version int64) Fault {
+	for i, f := range c.faults {
+		if !f.matches(version) {
+			continue
+		}
+		if f.Times > 0 {
+			c.faults[i].Times--
+			if c.faults[i].Times == 0 {
+				c.faults = append(c.faults[:i], c.faults[i+1:]...)
+			}
+		}
+		return f
+	}
+	return Fault{}
+}
+
+func parsePath(path string) (Request, bool) {
+	parts := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	if len(parts) != 8 || parts[0] != "dl" || parts[1] != "Resources" || parts[4] != "AssetBundles" ||
+		parts[6] != "manifest" || parts[7] != "manifest_assetmanifest" {
+		return Request{}, false
+	}
+	version, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return Request{}, false
+	}
+	return Request{Version: version, Locale: parts[3], Platform: parts[5]}, true
+}
+
+// manifest is a small manifest in the format of the real one: one asset
+// bundle per line, with its hash, category and size.
+func manifest(r Request) string {
+	var b strings.Builder
+	for _, name := range []string{"masterdata", "sound", "movie"} {
+		fmt.Fprintf(&b, "manifest/%s_assetmanifest,%08x%024d,%s,%d,\n", name, r.Version, 0, name, 1024+len(name))
+	}
+	return b.String()
+}
@@ -0,0 +1,144 @@
+package pcrd_jp_cdn_test
+
+import (
+	"fmt"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/clock"
+	"github.com/SpeedxPz/pcrd-version-updater/src/fake/pcrd_jp_cdn"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func get(t *testing.T, client *http.Client, url string, version int64) (int, string, error) {
+	return getOn(t, client, url, "Android", version)
+}
+
+func getOn(t *testing.T, client *http.Client, url string, platform string, version int64) (int, string, error) {
+	res, err := client.Get(fmt.Sprintf("%s/dl/Resources/%d/Jpn/AssetBundles/%s/manifest/manifest_assetmanifest", url, version, platform))
+	if err != nil {
+		return 0, "", err
+	}
+	defer res.Body.Close()
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	return res.StatusCode, string(body), nil
+}
+
+func TestPublishOverTime(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	clk := clock.NewFake(start)
+	cdn := pcrd_jp_cdn.New(clk)
+	cdn.Publish(10000010, start)
+	cdn.Publish(10000020, start.Add(time.Hour))
+	server := httptest.NewServer(cdn)
+	defer server.Close()
+
+	if status, body, _ := get(t, server.Client(), server.URL, 10000010); status != http.StatusOK || !strings.Contains(body, "masterdata") {
+		t.Fatalf("published version: got %d %q, want the manifest", status, body)
+	}
+	if status, _, _ := get(t, server.Client(), server.URL, 10000020); status != http.StatusNotFound {
+		t.Fatalf("version published later: got %d, want 404", status)
+	}
+	clk.Set(start.Add(time.Hour))
+	if status, _, _ := get(t, server.Client(), server.URL, 10000020); status != http.StatusOK {
+		t.Fatalf("version once published: got %d, want 200", status)
+	}
+
+	res, err := server.Client().Get(server.URL)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusForbidden {
+		t.Fatalf("root: got %d, want 403", res.StatusCode)
+	}
+
+	requests := cdn.Requests()
+	if len(requests) != 3 || requests[0].Locale != "Jpn" || requests[0].Platform != "Android" {
+		t.Fatalf("got requests %+v, want the 3 manifest requests", requests)
+	}
+}
+
+func TestPublishOn(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	clk := clock.NewFake(start)
+	cdn := pcrd_jp_cdn.New(clk)
+	cdn.PublishOn("Android", 10000010, start)
+	cdn.PublishOn("iOS", 10000010, start.Add(time.Hour))
+	server := httptest.NewServer(cdn)
+	defer server.Close()
+
+	for _, c := range []struct {
+		platform string
+		want     int
+	}{{"Android", http.StatusOK}, {"iOS", http.StatusNotFound}, {"Windows", http.StatusNotFound}} {
+		if status, _, _ := getOn(t, server.Client(), server.URL, c.platform, 10000010); status != c.want {
+			t.Fatalf("%s: got %d, want %d", c.platform, status, c.want)
+		}
+	}
+
+	clk.Set(start.Add(time.Hour))
+	if status, _, _ := getOn(t, server.Client(), server.URL, "iOS", 10000010); status != http.StatusOK {
+		t.Fatalf("iOS after its publication: got %d, want 200", status)
+	}
+}
+
+func TestFaults(t *testing.T) {
+	cdn := pcrd_jp_cdn.New(nil)
+	cdn.Publish(10000010, time.Time{})
+	cdn.Publish(10000020, time.Time{})
+	cdn.Inject(pcrd_jp_cdn.Fault{Version: 10000010, Status: http.StatusServiceUnavailable, Times: 1})
+	cdn.Inject(pcrd_jp_cdn.Fault{Version: 10000030, Bogus: true})
+	server := httptest.NewServer(cdn)
+	defer server.Close()
+
+	for _, tc := range []struct {
+		version int64
+		status  int
+		page    bool
+	}{
+		{10000010, http.StatusServiceUnavailable, false},
+		{10000010, http.StatusOK, false},
+		{10000030, http.StatusOK, true},
+		{10000030, http.StatusOK, true},
+	} {
+		status, body, err := get(t, server.Client(), server.URL, tc.version)
+		if err != nil {
+			t.Fatalf("Get(%d): %v", tc.version, err)
+		}
+		if status != tc.status || strings.Contains(body, "<html>") != tc.page {
+			t.Fatalf("Get(%d): got %d %q, want %d", tc.version, status, body, tc.status)
+		}
+	}
+
+	cdn.Inject(pcrd_jp_cdn.Fault{Version: 10000020, Latency: time.Second})
+	client := &http.Client{Timeout: 50 * time.Millisecond}
+	if _, _, err := get(t, client, server.URL, 10000020); err == nil {
+		t.Fatalf("Get with latency: want the client to time out")
+	}
+}
+
+// TestLatencyOnClock checks that latency is spent on the CDN's clock: a
+// clock.Fake sleeps it at once and is an hour later afterwards.
+func TestLatencyOnClock(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	clk := clock.NewFake(start)
+	cdn := pcrd_jp_cdn.New(clk)
+	cdn.Publish(10000010, start)
+	cdn.Inject(pcrd_jp_cdn.Fault{Latency: time.Hour})
+	server := httptest.NewServer(cdn)
+	defer server.Close()
+
+	client := &http.Client{Timeout: 5 * time.Second}
+	if status, _, err := get(t, client, server.URL, 10000010); err != nil || status != http.StatusOK {
+		t.Fatalf("Get with latency: got %d, %v, want 200 without waiting", status, err)
+	}
+	if got := clk.Now(); !got.Equal(start.Add(time.Hour)) {
+		t.Fatalf("clock: got %v, want it moved by the latency", got)
+	}
+}
@@ -0,0 +1,275 @@
+// Package pcrd_th_server is a fake TH game server for tests and local runs.
+// It serves check/game_start, checks the PARAM, SID and headers of each
+// request the way the game client computes them, and answers with scripted
+// data_headers.
+package pcrd_th_server
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/credential"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/cryptography"
+	"github.com/vmihailenco/msgpack/v5"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrInvalidResponse = errors.New("invalid scripted response")
+
+// Result codes of data_headers. ResultOK is what the game server answers a
+// valid call with; the others are the fake's own.
+const (
+	ResultOK             int64 = 1
+	ResultInvalidRequest int64 = 3
+	ResultMaintenance    int64 = 101
+)
+
+const gameStartPath = "/check/game_start"
+
+// requiredHeaders are sent by the game client on every call.
+var requiredHeaders = []string{
+	"APP-VER", "RES-VER", "UDID", "SHORT-UDID", "PARAM", "SID",
+	"DEVICE", "PLATFORM", "BATTLE-LOGIC-VERSION",
+}
+
+// Response is the data_headers answered to a valid call.
+type Response struct {
+	ResultCode     int64
+	RequiredResVer string
+}
+
+// Version answers resVersion as the required resource version.
+func Version(resVersion string) Response {
+	return Response{ResultCode: ResultOK, RequiredResVer: resVersion}
+}
+
+// Maintenance answers like a server under maintenance: no version.
+func Maintenance() Response {
+	return Response{ResultCode: ResultMaintenance}
+}
+
+// ParseResponse reads a scripted response: a resource version,
+// "maintenance", or "code:<n>" for a result code without a version.
+func ParseResponse(s string) (Response, error) {
+	switch {
+	case s == "maintenance":
+		return Maintenance(), nil
+	case strings.HasPrefix(s, "code:"):
+		code, err := strconv.ParseInt(strings.TrimPrefix(s, "code:"), 10, 64)
+		if err != nil {
+			return Response{}, fmt.Errorf("cannot parse:[%s] as response: %w", s, ErrInvalidResponse)
+		}
+		return Response{ResultCode: code}, nil
+	case len(s) > 0:
+		return Version(s), nil
+	}
+	return Response{}, fmt.Errorf("cannot parse:[%s] as response: %w", s, ErrInvalidResponse)
+}
+
+// Request is a call the server received. Rejected says why it was refused,
+// and is empty for a valid call.
+type Request struct {
+	Path       string
+	AppVersion string
+	ResVersion string
+	UDID       string
+	Rejected   string
+	Response   Response
+}
+
+// checkGameStartParam mirrors the client's request; its field order is the
+// order msgpack encodes, which PARAM is computed from.
+type checkGameStartParam struct {
+	AppType      int64  `msgpack:"app_type" json:"app_type"`
+	CampaignData string `msgpack:"campaign_data" json:"campaign_data"`
+	CampaignSign string `msgpack:"campaign_sign" json:"campaign_sign"`
+	CampaignUser int64  `msgpack:"campaign_user" json:"campaign_user"`
+	ViewerID     string `msgpack:"viewer_id" json:"viewer_id"`
+}
+
+type dataHeaders struct {
+	ResultCode     int64  `json:"result_code"`
+	RequiredResVer string `json:"required_res_ver,omitempty"`
+	ShortUdid      int64  `json:"short_udid"`
+	ViewerID       int64  `json:"viewer_id"`
+	SID            string `json:"sid"`
+	ServerTime     int64  `json:"servertime"`
+}
+
+type gameStartData struct {
+	NowViewerID  int64  `json:"now_viewer_id"`
+	NowName      string `json:"now_name"`
+	NowTeamLevel int64  `json:"now_team_level"`
+	NowTutorial  bool   `json:"now_tutorial"`
+	BundleVer    string `json:"bundle_ver"`
+	ResourceFix  bool   `json:"resource_fix"`
+	BundleFix    bool   `json:"bundle_fix"`
+}
+
+type Server struct {
+	now      func() time.Time
+	salt     string
+	mu       sync.Mutex
+	accounts map[string]credential.Credential
+	script   []Response
+	next     int
+	requests []Request
+}
+
+// New returns a server that accepts the calls of accounts, signed with
+// salt, and tells the time of now in its answers; a nil now is the system
+// clock. Until scripted, it answers every call with maintenance.
+func New(now func() time.Time, salt string, accounts ...credential.Credential) *Server {
+	if now == nil {
+		now = time.Now
+	}
+	s := &Server{now: now, salt: salt, accounts: map[string]credential.Credential{}}
+	for _, a := range accounts {
+		s.accounts[a.Udid] = a
+	}
+	return s
+}
+
+// Script sets the responses to valid calls, served in order; the last one
+// is repeated.
+func (s *Server) Script(responses ...Response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.script = responses
+	s.next = 0
+}
+
+// Requests returns every call received so far, oldest first.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Request(nil), s.requests...)
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodHead {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if r.URL.Path != gameStartPath {
+		http.NotFound(w, r)
+		return
+	}
+
+	request := Request{
+		Path:       r.URL.Path,
+		AppVersion: r.Header.Get("APP-VER"),
+		ResVersion: r.Header.Get("RES-VER"),
+		UDID:       r.Header.Get("UDID"),
+	}
+	account, err := s.validate(r)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	headers := dataHeaders{ServerTime: s.now().Unix()}
+	if err != nil {
+		request.Rejected = err.Error()
+		request.Response = Response{ResultCode: ResultInvalidRequest}
+		s.requests = append(s.requests, request)
+		headers.ResultCode = ResultInvalidRequest
+		writeJSON(w, http.StatusBadRequest, headers, nil)
+		return
+	}
+
+	request.Response = s.nextResponse()
+	s.requests = append(s.requests, request)
+	headers.ResultCode = request.Response.ResultCode
+	headers.RequiredResVer = request.Response.RequiredResVer
+	headers.ShortUdid = int64(account.ShortUdid)
+	headers.ViewerID = int64(account.ViewerID)
+	headers.SID = r.Header.Get("SID")
+
+	var data interface{}
+	if request.Response.ResultCode == ResultOK {
+		data = gameStartData{NowViewerID: int64(account.ViewerID), NowName: "fake", NowTeamLevel: 1}
+	}
+	writeJSON(w, http.StatusOK, headers, data)
+}
+
+func (s *Server) nextResponse() Response {
+	if len(s.script) <= 0 {
+		return Maintenance()
+	}
+	response := s.script[s.next]
+	if s.next < len(s.script)-1 {
+		s.next++
+	}
+	return response
+}
+
+// validate checks a call the way the game server does and returns the
+// account it was made for.
+func (s *Server) validate(r *http.Request) (credential.Credential, error) {
+	if r.Method != http.MethodPost {
+		return credential.Credential{}, fmt.Errorf("method %s, want POST", r.Method)
+	}
+	if r.URL.Query().Get("format") != "json" {
+		return credential.Credential{}, fmt.Errorf("format %q, only json is served", r.URL.Query().Get("format"))
+	}
+	for _, name := range requiredHeaders {
+		if _, ok := r.Header[http.CanonicalHeaderKey(name)]; !ok {
+			return credential.Credential{}, fmt.Errorf("missing header %s", name)
+		}
+	}
+
+	account, ok := s.accounts[r.Header.Get("UDID")]
+	if !ok {
+		return credential.Credential{}, fmt.Errorf("unknown udid %q", r.Header.Get("UDID"))
+	}
+	if r.Header.Get("SHORT-UDID") != strconv.Itoa(int(account.ShortUdid)) {
+		return credential.Credential{}, fmt.Errorf("short udid %q does not match", r.Header.Get("SHORT-UDID"))
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return credential.Credential{}, fmt.Errorf("read body: %s", err)
+	}
+	var param checkGameStartParam
+	if err := json.Unmarshal(body, &param); err != nil {
+		return credential.Credential{}, fmt.Errorf("parse body: %s", err)
+	}
+	if param.ViewerID != strconv.Itoa(int(account.ViewerID)) {
+		return credential.Credential{}, fmt.Errorf("viewer id %q does not match", param.ViewerID)
+	}
+
+	packed, err := msgpack.Marshal(&param)
+	if err != nil {
+		return credential.Credential{}, fmt.Errorf("pack param: %s", err)
+	}
+	encoded := base64.StdEncoding.EncodeToString(packed)
+	// The client hashes the viewer ID as Go's %s prints an int32.
+	viewerID := fmt.Sprintf("%%!s(int32=%d)", account.ViewerID)
+	if cryptography.MakeSHA1(fmt.Sprintf("%s%s%s%s", account.Udid, gameStartPath, encoded, viewerID)) != r.Header.Get("PARAM") {
+		return credential.Credential{}, errors.New("PARAM does not match the body")
+	}
+
+	sid := cryptography.MakeMD5(fmt.Sprintf("%s%s%s", viewerID, account.Udid, s.salt))
+	if len(account.SessionID) > 0 {
+		sid = cryptography.MakeMD5(fmt.Sprintf("%s%s", account.SessionID, s.salt))
+	}
+	if r.Header.Get("SID") != sid {
+		return credential.Credential{}, errors.New("SID does not match")
+	}
+
+	return account, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, headers dataHeaders, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{"data_headers": headers, "data": data})
+}
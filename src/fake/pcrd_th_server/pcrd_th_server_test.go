@@ -0,0 +1,76 @@
+package pcrd_th_server_test
+
+import (
+	"encoding/json"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/credential"
+	"github.com/SpeedxPz/pcrd-version-updater/src/fake/pcrd_th_server"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	salt = "salt"
+	body = `{"app_type":0,"campaign_data":"","campaign_sign":"69fc9ddde974cc75a0756abb16b2ef35","campaign_user":157428,"viewer_id":"456"}`
+)
+
+var account = credential.Credential{Udid: "udid", ShortUdid: 123, ViewerID: 456}
+
+// gameStart sends check/game_start for account, signed with param and sid
+// instead of hashes the client would compute.
+func gameStart(t *testing.T, server *httptest.Server, param string, sid string) map[string]interface{} {
+	req, err := http.NewRequest(http.MethodPost, server.URL+"/check/game_start?format=json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("NewRequest: %v", err)
+	}
+	for k, v := range map[string]string{
+		"APP-VER": "3.1.0", "RES-VER": "00150000", "UDID": "udid", "SHORT-UDID": "123",
+		"PARAM": param, "SID": sid,
+		"DEVICE": "2", "PLATFORM": "2", "BATTLE-LOGIC-VERSION": "4",
+	} {
+		req.Header.Set(k, v)
+	}
+	res, err := server.Client().Do(req)
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	defer res.Body.Close()
+
+	var o struct {
+		DataHeaders map[string]interface{} `json:"data_headers"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&o); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	return o.DataHeaders
+}
+
+// TestValidateKnownHashes checks the server against PARAM and SID worked
+// out by hand with sha1sum and md5sum for account, body and salt, with the
+// viewer ID as the client hashes it, "%!s(int32=456)". The ones hashing it
+// in decimal are refused.
+func TestValidateKnownHashes(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	fake := pcrd_th_server.New(func() time.Time { return now }, salt, account)
+	fake.Script(pcrd_th_server.Version("00150010"))
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	headers := gameStart(t, server, "4582298058a1bdb6eef210473c06f22612a34ea1", "a401a82eb2688bc5a35d0745eb935f6d")
+	if headers["required_res_ver"] != "00150010" || headers["servertime"] != float64(now.Unix()) {
+		t.Fatalf("got %v, want the version at %d", headers, now.Unix())
+	}
+
+	for name, hashes := range map[string][2]string{
+		"PARAM": {"1b341fecdf48953a7fd70553dab438ccb913e326", "a401a82eb2688bc5a35d0745eb935f6d"},
+		"SID":   {"4582298058a1bdb6eef210473c06f22612a34ea1", "4c1471a3060430190ab8a147af9fee01"},
+	} {
+		gameStart(t, server, hashes[0], hashes[1])
+		requests := fake.Requests()
+		if last := requests[len(requests)-1]; !strings.Contains(last.Rejected, name) {
+			t.Fatalf("got %+v, want the decimal %s hash refused", last, name)
+		}
+	}
+}
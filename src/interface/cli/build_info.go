@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
+	"io"
+	"runtime"
+	"runtime/debug"
+)
+
+type BuildInfo struct {
+	Name      string `json:"name"`
+	Version   string `json:"version"`
+	GoVersion string `json:"goVersion"`
+	Revision  string `json:"revision,omitempty"`
+	BuildTime string `json:"buildTime,omitempty"`
+	Modified  bool   `json:"modified,omitempty"`
+}
+
+// ReadBuildInfo describes the running binary. version is APP_VERSION; when
+// empty, the module version the binary was built from is used.
+func ReadBuildInfo(name string, version string) BuildInfo {
+	info := BuildInfo{Name: name, Version: version, GoVersion: runtime.Version()}
+	build, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	if len(info.Version) <= 0 {
+		info.Version = build.Main.Version
+	}
+	for _, s := range build.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			info.Revision = s.Value
+		case "vcs.time":
+			info.BuildTime = s.Value
+		case "vcs.modified":
+			info.Modified = s.Value == "true"
+		}
+	}
+	return info
+}
+
+// Version runs `version [--format json|text]`.
+func Version(out io.Writer, args []string, info BuildInfo) error {
+	fs := flag.NewFlagSet("version", flag.ContinueOnError)
+	formatName := fs.String("format", string(ReportFormatJSON), "output format (json, text)")
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("version: %s: %w", err, use_case.ErrInvalidRequestParam)
+	}
+	format, err := ParseReportFormat(*formatName)
+	if err != nil {
+		return err
+	}
+
+	if format == ReportFormatJSON {
+		return writeJSON(out, info)
+	}
+	_, err = fmt.Fprintf(out, "%s %s (%s", info.Name, info.Version, info.GoVersion)
+	if err == nil && len(info.Revision) > 0 {
+		_, err = fmt.Fprintf(out, ", %s", info.Revision)
+	}
+	if err == nil {
+		_, err = fmt.Fprintln(out, ")")
+	}
+	return err
+}
@@ -0,0 +1,90 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
+	"io"
+	"time"
+)
+
+type checkOutput struct {
+	ID           string         `json:"id"`
+	SettingID    string         `json:"settingId"`
+	ServerCode   string         `json:"serverCode,omitempty"`
+	Outcome      string         `json:"outcome"`
+	FailureClass string         `json:"failureClass,omitempty"`
+	Failure      string         `json:"failure,omitempty"`
+	Before       *versionOutput `json:"before"`
+	After        *versionOutput `json:"after"`
+	Probes       probesOutput   `json:"probes"`
+	TraceID      string         `json:"traceId,omitempty"`
+	StartedAt    time.Time      `json:"startedAt"`
+	FinishedAt   time.Time      `json:"finishedAt"`
+	ExpiresAt    *time.Time     `json:"expiresAt,omitempty"`
+}
+
+func newCheckOutput(c use_case.CheckRecord) checkOutput {
+	output := checkOutput{
+		ID:           c.ID,
+		SettingID:    c.SettingID,
+		ServerCode:   string(c.ServerCode),
+		Outcome:      string(c.Outcome),
+		FailureClass: string(c.FailureClass),
+		Failure:      c.Failure,
+		Before:       newVersionOutput(c.Before),
+		After:        newVersionOutput(c.After),
+		Probes:       newProbesOutput(c.Probes),
+		TraceID:      c.TraceID,
+		StartedAt:    c.StartedAt,
+		FinishedAt:   c.FinishedAt,
+	}
+	if !c.ExpiresAt.IsZero() {
+		output.ExpiresAt = &c.ExpiresAt
+	}
+	return output
+}
+
+// Checks runs `checks list [--setting id] [--outcome o] [--from t] [--to t]
+// [--limit n]`, listing recorded checks newest first.
+func Checks(ctx context.Context, u *use_case.UseCase, out io.Writer, args []string) error {
+	if len(args) <= 0 || args[0] != "list" {
+		return fmt.Errorf("checks: missing action (list): %w", use_case.ErrInvalidRequestParam)
+	}
+
+	fs := flag.NewFlagSet("checks list", flag.ContinueOnError)
+	var q use_case.CheckQuery
+	var outcome, from, to string
+	fs.StringVar(&q.SettingID, "setting", "", "only checks of this setting ID")
+	fs.StringVar(&outcome, "outcome", "", "only checks with this outcome (unchanged, created, updated, failed)")
+	fs.StringVar(&from, "from", "", "only checks started at or after this RFC 3339 time")
+	fs.StringVar(&to, "to", "", "only checks started before this RFC 3339 time")
+	fs.IntVar(&q.Limit, "limit", 100, "most checks to list")
+	if err := fs.Parse(args[1:]); err != nil {
+		return fmt.Errorf("checks list: %s: %w", err, use_case.ErrInvalidRequestParam)
+	}
+
+	var err error
+	if len(outcome) > 0 {
+		if q.Outcome, err = use_case.ParseRunOutcome(outcome); err != nil {
+			return err
+		}
+	}
+	if q.From, err = parseTime("from", from); err != nil {
+		return err
+	}
+	if q.To, err = parseTime("to", to); err != nil {
+		return err
+	}
+
+	results, err := u.ListChecks(ctx, q)
+	if err != nil {
+		return err
+	}
+	outputs := make([]checkOutput, len(results))
+	for i := range results {
+		outputs[i] = newCheckOutput(results[i])
+	}
+	return writeJSON(out, outputs)
+}
@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
+)
+
+// Process exit codes. The failure codes follow sysexits.h so that a
+// scheduler can retry transient failures and alert on the others.
+const (
+	ExitUnchanged     = 0
+	ExitFailure       = 1
+	ExitUpdated       = 10
+	ExitTransient     = 75 // EX_TEMPFAIL
+	ExitConfiguration = 78 // EX_CONFIG
+)
+
+// ExitCode maps the outcome of a command to the process exit code. outcome is
+// only set by a check; other commands exit with ExitUnchanged on success.
+// Failures exit by their use_case.ClassifyFailure class, so the exit code
+// agrees with the class in events, reports and the check log.
+func ExitCode(outcome use_case.RunOutcome, err error) int {
+	if err == nil {
+		if outcome == use_case.RunCreated || outcome == use_case.RunUpdated {
+			return ExitUpdated
+		}
+		return ExitUnchanged
+	}
+
+	switch use_case.ClassifyFailure(err) {
+	case use_case.FailureNotAvailable, use_case.FailureApplication,
+		use_case.FailureRemote, use_case.FailureStorage, use_case.FailurePublish,
+		use_case.FailureTimeout, use_case.FailureDependency:
+		return ExitTransient
+	case use_case.FailureConfiguration:
+		return ExitConfiguration
+	default:
+		return ExitFailure
+	}
+}
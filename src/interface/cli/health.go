@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
+	"io"
+	"time"
+)
+
+type dependencyHealthOutput struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	Error     string  `json:"error,omitempty"`
+	LatencyMs float64 `json:"latencyMs"`
+}
+
+type healthOutput struct {
+	Status       string                   `json:"status"`
+	Dependencies []dependencyHealthOutput `json:"dependencies"`
+	CheckedAt    time.Time                `json:"checkedAt"`
+}
+
+func newHealthOutput(r use_case.HealthReport) healthOutput {
+	output := healthOutput{
+		Status:       string(r.Status),
+		Dependencies: make([]dependencyHealthOutput, len(r.Dependencies)),
+		CheckedAt:    r.CheckedAt,
+	}
+	for i, d := range r.Dependencies {
+		output.Dependencies[i] = dependencyHealthOutput{
+			Name:      d.Name,
+			Status:    string(d.Status),
+			Error:     d.Error,
+			LatencyMs: milliseconds(d.Latency),
+		}
+	}
+	return output
+}
+
+// Health runs `health live` or `health ready` (the default) and prints the
+// report. A dependency that is down makes it return ErrDependencyUnavailable.
+func Health(ctx context.Context, u *use_case.UseCase, out io.Writer, args []string) error {
+	action := "ready"
+	if len(args) > 0 {
+		action = args[0]
+	}
+
+	switch action {
+	case "live":
+		return writeJSON(out, newHealthOutput(u.Liveness(ctx)))
+	case "ready":
+		report, err := u.Readiness(ctx)
+		if werr := writeJSON(out, newHealthOutput(report)); werr != nil {
+			return werr
+		}
+		return err
+	default:
+		return fmt.Errorf("health: unknown action %s: %w", action, use_case.ErrInvalidRequestParam)
+	}
+}
@@ -0,0 +1,37 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
+	"io"
+)
+
+// SchemaMigrator brings a storage schema up to date and returns the schema
+// versions before and after.
+type SchemaMigrator func(ctx context.Context) (from int, to int, err error)
+
+type migrateOutput struct {
+	Backend string `json:"backend"`
+	From    int    `json:"from"`
+	To      int    `json:"to"`
+}
+
+// Migrate runs `migrate`. Backends with no schema to migrate pass a nil
+// migrate; their buckets and collections are created when first used.
+func Migrate(ctx context.Context, out io.Writer, args []string, backend string, migrate SchemaMigrator) error {
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("migrate: %s: %w", err, use_case.ErrInvalidRequestParam)
+	}
+
+	output := migrateOutput{Backend: backend}
+	if migrate != nil {
+		var err error
+		if output.From, output.To, err = migrate(ctx); err != nil {
+			return err
+		}
+	}
+	return writeJSON(out, output)
+}
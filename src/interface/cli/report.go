@@ -0,0 +1,163 @@
+package cli
+
+import (
+	"fmt"
+	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
+	"io"
+	"strings"
+	"time"
+)
+
+// ErrInvalidReportFormat is a configuration failure to ExitCode.
+var ErrInvalidReportFormat = fmt.Errorf("unknow report format: %w", use_case.ErrInvalidRequestParam)
+
+type ReportFormat string
+
+const (
+	ReportFormatJSON ReportFormat = "json"
+	ReportFormatText ReportFormat = "text"
+)
+
+func ParseReportFormat(s string) (d ReportFormat, e error) {
+	dataTypes := map[ReportFormat]struct{}{
+		ReportFormatJSON: {},
+		ReportFormatText: {},
+	}
+
+	dat := ReportFormat(s)
+	_, ok := dataTypes[dat]
+	if !ok {
+		return d, fmt.Errorf("cannot parse:[%s] as report format: %w", s, ErrInvalidReportFormat)
+	}
+	return dat, nil
+}
+
+type versionOutput struct {
+	AppVersion string `json:"appVersion"`
+	ResVersion string `json:"resVersion"`
+}
+
+func newVersionOutput(v *use_case.PcrdVersion) *versionOutput {
+	if v == nil {
+		return nil
+	}
+	return &versionOutput{AppVersion: v.AppVersion, ResVersion: v.ResVersion}
+}
+
+type failureOutput struct {
+	Class   string `json:"class"`
+	Message string `json:"message"`
+}
+
+type stepOutput struct {
+	Step       string  `json:"step"`
+	DurationMs float64 `json:"durationMs"`
+}
+
+type probesOutput struct {
+	Sent   int `json:"sent"`
+	Hits   int `json:"hits"`
+	Errors int `json:"errors"`
+}
+
+type platformVersionOutput struct {
+	Platform   string `json:"platform"`
+	ResVersion string `json:"resVersion"`
+}
+
+func newProbesOutput(p use_case.ProbeCounts) probesOutput {
+	return probesOutput{Sent: p.Sent, Hits: p.Hits, Errors: p.Errors}
+}
+
+type reportOutput struct {
+	ID         string         `json:"id"`
+	ServerCode string         `json:"serverCode,omitempty"`
+	Outcome    string         `json:"outcome"`
+	Before     *versionOutput `json:"before"`
+	After      *versionOutput `json:"after"`
+	Failure    *failureOutput `json:"failure,omitempty"`
+	StartedAt  time.Time      `json:"startedAt"`
+	FinishedAt time.Time      `json:"finishedAt"`
+	DurationMs float64        `json:"durationMs"`
+	Steps      []stepOutput   `json:"steps"`
+	Probes     probesOutput   `json:"probes"`
+	// Platforms is only set for JP checks.
+	Platforms         []platformVersionOutput `json:"platforms,omitempty"`
+	PlatformsDisagree bool                    `json:"platformsDisagree,omitempty"`
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+func newReportOutput(r use_case.RunReport) reportOutput {
+	output := reportOutput{
+		ID:         r.ID,
+		ServerCode: string(r.ServerCode),
+		Outcome:    string(r.Outcome),
+		Before:     newVersionOutput(r.Before),
+		After:      newVersionOutput(r.After),
+		StartedAt:  r.StartedAt,
+		FinishedAt: r.FinishedAt,
+		DurationMs: milliseconds(r.Duration()),
+		Steps:      make([]stepOutput, len(r.Steps)),
+		Probes:     newProbesOutput(r.Probes),
+
+		PlatformsDisagree: r.PlatformsDisagree,
+	}
+	for _, p := range r.Platforms {
+		output.Platforms = append(output.Platforms, platformVersionOutput{Platform: string(p.Platform), ResVersion: p.ResVersion})
+	}
+	if r.Failure != nil {
+		output.Failure = &failureOutput{Class: string(r.Failure.Class), Message: r.Failure.Message}
+	}
+	for i, step := range r.Steps {
+		output.Steps[i] = stepOutput{Step: step.Step, DurationMs: milliseconds(step.Duration)}
+	}
+	return output
+}
+
+// WriteReport writes the report of one check to out in format.
+func WriteReport(out io.Writer, format ReportFormat, r use_case.RunReport) error {
+	if format == ReportFormatJSON {
+		return writeJSON(out, newReportOutput(r))
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s", r.ID)
+	if len(r.ServerCode) > 0 {
+		fmt.Fprintf(&b, " (%s)", r.ServerCode)
+	}
+	fmt.Fprintf(&b, ": %s in %s\n", r.Outcome, r.Duration().Round(time.Millisecond))
+	for _, v := range []struct {
+		name    string
+		version *use_case.PcrdVersion
+	}{{"before", r.Before}, {"after", r.After}} {
+		if v.version != nil {
+			fmt.Fprintf(&b, "  %-7s app %s, resource %s\n", v.name+":", v.version.AppVersion, v.version.ResVersion)
+		}
+	}
+	if r.Failure != nil {
+		fmt.Fprintf(&b, "  failure: [%s] %s\n", r.Failure.Class, r.Failure.Message)
+	}
+	steps := make([]string, len(r.Steps))
+	for i, step := range r.Steps {
+		steps[i] = fmt.Sprintf("%s %s", step.Step, step.Duration.Round(time.Millisecond))
+	}
+	fmt.Fprintf(&b, "  steps:  %s\n", strings.Join(steps, ", "))
+	fmt.Fprintf(&b, "  probes: %d sent, %d hits, %d errors\n", r.Probes.Sent, r.Probes.Hits, r.Probes.Errors)
+	if len(r.Platforms) > 1 {
+		platforms := make([]string, len(r.Platforms))
+		for i, p := range r.Platforms {
+			platforms[i] = fmt.Sprintf("%s %s", p.Platform, p.ResVersion)
+		}
+		fmt.Fprintf(&b, "  platforms: %s", strings.Join(platforms, ", "))
+		if r.PlatformsDisagree {
+			fmt.Fprintf(&b, " (disagree)")
+		}
+		fmt.Fprintf(&b, "\n")
+	}
+
+	_, err := io.WriteString(out, b.String())
+	return err
+}
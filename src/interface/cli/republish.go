@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
+	"io"
+	"time"
+)
+
+type versionEventOutput struct {
+	Type       string                `json:"type"`
+	ID         string                `json:"id"`
+	ServerCode string                `json:"serverCode,omitempty"`
+	Before     *use_case.PcrdVersion `json:"before"`
+	After      *use_case.PcrdVersion `json:"after"`
+	Time       time.Time             `json:"time"`
+}
+
+func newVersionEventOutput(e use_case.VersionEvent) versionEventOutput {
+	return versionEventOutput{
+		Type:       string(e.Type),
+		ID:         e.Setting.ID,
+		ServerCode: string(e.Setting.ServerCode),
+		Before:     e.Before,
+		After:      e.After,
+		Time:       e.Time,
+	}
+}
+
+// Republish runs `republish snapshot [flags]`, publishing every current
+// version to snapshot, and `republish history --from ... [--to ...] [flags]`,
+// replaying histories to history.
+func Republish(ctx context.Context, u *use_case.UseCase, snapshot use_case.VersionEventRepository, history use_case.VersionEventRepository, out io.Writer, args []string) error {
+	if len(args) <= 0 {
+		return fmt.Errorf("republish: missing action (snapshot, history): %w", use_case.ErrInvalidRequestParam)
+	}
+
+	fs := flag.NewFlagSet("republish "+args[0], flag.ContinueOnError)
+	var opts use_case.RepublishOptions
+	var from, to string
+	fs.Float64Var(&opts.Rate, "rate", 0, "events per second, 0 for unlimited")
+	fs.BoolVar(&opts.DryRun, "dry-run", false, "list the events without publishing")
+	if args[0] == "history" {
+		fs.StringVar(&from, "from", "", "replay entries created at or after this RFC 3339 time")
+		fs.StringVar(&to, "to", "", "replay entries created before this RFC 3339 time (default: now)")
+	}
+	if err := fs.Parse(args[1:]); err != nil {
+		return fmt.Errorf("republish %s: %s: %w", args[0], err, use_case.ErrInvalidRequestParam)
+	}
+
+	var events []use_case.VersionEvent
+	var err error
+	switch args[0] {
+	case "snapshot":
+		events, err = u.RepublishVersions(ctx, snapshot, opts)
+	case "history":
+		fromTime, parseErr := parseTime("from", from)
+		if parseErr != nil {
+			return parseErr
+		}
+		toTime, parseErr := parseTime("to", to)
+		if parseErr != nil {
+			return parseErr
+		}
+		events, err = u.ReplayHistories(ctx, history, fromTime, toTime, opts)
+	default:
+		return fmt.Errorf("republish: unknown action %s: %w", args[0], use_case.ErrInvalidRequestParam)
+	}
+
+	outputs := make([]versionEventOutput, len(events))
+	for i := range events {
+		outputs[i] = newVersionEventOutput(events[i])
+	}
+	if werr := writeJSON(out, map[string]interface{}{"dryRun": opts.DryRun, "events": outputs}); werr != nil {
+		return werr
+	}
+	return err
+}
+
+func parseTime(name string, value string) (time.Time, error) {
+	if len(value) <= 0 {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("--%s: %s: %w", name, err, use_case.ErrInvalidRequestParam)
+	}
+	return t, nil
+}
@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
+	"go.uber.org/zap"
+	"io"
+	"os"
+)
+
+// ReportOptions are where a check writes its report unless its flags say
+// otherwise, from REPORT_FORMAT and REPORT_FILE.
+type ReportOptions struct {
+	Format string
+	File   string
+}
+
+type runFunc func(ctx context.Context, ID string) (use_case.RunReport, error)
+
+// Check runs `check [id] [--format json|text] [--output file]`: one check of
+// id, or of defaultID when it is omitted, and writes its report.
+func Check(ctx context.Context, u *use_case.UseCase, out io.Writer, args []string, defaultID string, opts ReportOptions) (use_case.RunOutcome, error) {
+	return runCheck(ctx, "check", u.UpdateResourceVersion, out, args, defaultID, opts)
+}
+
+// Plan runs `plan [id] [flags]` like Check, but stores and publishes
+// nothing: the report has the outcome a check would have now.
+func Plan(ctx context.Context, u *use_case.UseCase, out io.Writer, args []string, defaultID string, opts ReportOptions) (use_case.RunOutcome, error) {
+	return runCheck(ctx, "plan", u.PlanResourceVersion, out, args, defaultID, opts)
+}
+
+func runCheck(ctx context.Context, name string, run runFunc, out io.Writer, args []string, defaultID string, opts ReportOptions) (use_case.RunOutcome, error) {
+	ID, rest := splitID(args)
+	if len(ID) <= 0 {
+		ID = defaultID
+	}
+
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.StringVar(&opts.Format, "format", opts.Format, "report format (json, text)")
+	fs.StringVar(&opts.File, "output", opts.File, "write the report to this file instead of stdout")
+	if err := fs.Parse(rest); err != nil {
+		return "", fmt.Errorf("%s: %s: %w", name, err, use_case.ErrInvalidRequestParam)
+	}
+	format, err := ParseReportFormat(opts.Format)
+	if err != nil {
+		return "", err
+	}
+
+	report, err := run(ctx, ID)
+	if err != nil && ctx.Err() != nil {
+		// Whatever failed, it failed because the run was cut short.
+		err = fmt.Errorf("%s: %w", err, ctx.Err())
+	}
+
+	if len(opts.File) > 0 {
+		file, ferr := os.Create(opts.File)
+		if ferr != nil {
+			zap.L().Error("Error create report file: ", zap.Error(ferr))
+		} else {
+			defer file.Close()
+			out = file
+		}
+	}
+	if werr := WriteReport(out, format, report); werr != nil {
+		zap.L().Error("Error write report: ", zap.Error(werr))
+	}
+
+	return report.Outcome, err
+}
@@ -0,0 +1,172 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/platform"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/setting"
+	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
+	"io"
+	"strings"
+)
+
+// settingOutput never carries the udid, only whether one is stored, since
+// command output ends up in job logs.
+type settingOutput struct {
+	ID                string           `json:"id"`
+	ServerCode        string           `json:"serverCode"`
+	Credential        credentialOutput `json:"credential"`
+	GuessStartVersion string           `json:"guessStartVersion,omitempty"`
+	Platforms         []string         `json:"platforms,omitempty"`
+	Locales           []string         `json:"locales,omitempty"`
+}
+
+type credentialOutput struct {
+	HasUDID   bool  `json:"hasUdid"`
+	ShortUDID int32 `json:"shortUdid,omitempty"`
+	ViewerID  int32 `json:"viewerId,omitempty"`
+}
+
+func newSettingOutput(s use_case.PCRDSetting) settingOutput {
+	return settingOutput{
+		ID:         s.Setting.ID,
+		ServerCode: string(s.Setting.ServerCode),
+		Credential: credentialOutput{
+			HasUDID:   len(s.Credential.Udid) > 0,
+			ShortUDID: s.Credential.ShortUdid,
+			ViewerID:  s.Credential.ViewerID,
+		},
+		GuessStartVersion: s.GuessStartVersion,
+		Platforms:         platform.Names(s.Platforms),
+		Locales:           s.Locales,
+	}
+}
+
+type settingFlags struct {
+	fs                *flag.FlagSet
+	serverCode        string
+	udid              string
+	shortUdid         int
+	viewerID          int
+	guessStartVersion string
+	platforms         string
+	locales           string
+}
+
+func newSettingFlags(name string) *settingFlags {
+	f := &settingFlags{fs: flag.NewFlagSet(name, flag.ContinueOnError)}
+	f.fs.StringVar(&f.serverCode, "server", "", "server code (th, jp)")
+	f.fs.StringVar(&f.udid, "udid", "", "credential udid (th)")
+	f.fs.IntVar(&f.shortUdid, "short-udid", 0, "credential short udid (th)")
+	f.fs.IntVar(&f.viewerID, "viewer-id", 0, "credential viewer id (th)")
+	f.fs.StringVar(&f.guessStartVersion, "guess-start", "", "resource version to start guessing from (jp)")
+	f.fs.StringVar(&f.platforms, "platforms", "", "comma-separated platforms to probe, the primary one first: android, ios, windows (jp, default android)")
+	f.fs.StringVar(&f.locales, "locales", "", "comma-separated manifest locales to probe (jp, default Jpn)")
+	return f
+}
+
+// apply copies only the flags given on the command line onto s, so update
+// can change a single field without restating the others.
+func (f *settingFlags) apply(s *use_case.PCRDSetting) {
+	f.fs.Visit(func(fl *flag.Flag) {
+		switch fl.Name {
+		case "server":
+			s.Setting.ServerCode = setting.ServerCode(f.serverCode)
+		case "udid":
+			s.Credential.Udid = f.udid
+		case "short-udid":
+			s.Credential.ShortUdid = int32(f.shortUdid)
+		case "viewer-id":
+			s.Credential.ViewerID = int32(f.viewerID)
+		case "guess-start":
+			s.GuessStartVersion = f.guessStartVersion
+		case "platforms":
+			// An empty flag is nil, which puts the default back.
+			s.Platforms = nil
+			for _, name := range platform.SplitList(f.platforms) {
+				s.Platforms = append(s.Platforms, platform.PlatformType(name))
+			}
+		case "locales":
+			s.Locales = platform.SplitList(f.locales)
+		}
+	})
+}
+
+// Settings runs `settings <list|get|create|update|delete|re-encrypt> [id] [flags]`.
+func Settings(ctx context.Context, u *use_case.UseCase, out io.Writer, args []string) error {
+	if len(args) <= 0 {
+		return fmt.Errorf("settings: missing action (list, get, create, update, delete, re-encrypt): %w", use_case.ErrInvalidRequestParam)
+	}
+
+	action := args[0]
+	ID, rest := splitID(args[1:])
+
+	switch action {
+	case "list":
+		results, err := u.ListSettings(ctx)
+		if err != nil {
+			return err
+		}
+		outputs := make([]settingOutput, len(results))
+		for i := range results {
+			outputs[i] = newSettingOutput(results[i])
+		}
+		return writeJSON(out, outputs)
+	case "get":
+		result, err := u.GetSetting(ctx, ID)
+		if err != nil {
+			return err
+		}
+		return writeJSON(out, newSettingOutput(result))
+	case "create":
+		f := newSettingFlags("settings create")
+		if err := f.fs.Parse(rest); err != nil {
+			return fmt.Errorf("settings create: %s: %w", err, use_case.ErrInvalidRequestParam)
+		}
+		s := use_case.PCRDSetting{Setting: setting.Setting{ID: ID}}
+		f.apply(&s)
+		if err := u.CreateSetting(ctx, s); err != nil {
+			return err
+		}
+		return writeJSON(out, newSettingOutput(s))
+	case "update":
+		f := newSettingFlags("settings update")
+		if err := f.fs.Parse(rest); err != nil {
+			return fmt.Errorf("settings update: %s: %w", err, use_case.ErrInvalidRequestParam)
+		}
+		s, err := u.GetSetting(ctx, ID)
+		if err != nil {
+			return err
+		}
+		f.apply(&s)
+		if err := u.UpdateSetting(ctx, s); err != nil {
+			return err
+		}
+		return writeJSON(out, newSettingOutput(s))
+	case "delete":
+		return u.DeleteSetting(ctx, ID)
+	case "re-encrypt":
+		count, err := u.ReEncryptSettings(ctx)
+		if err != nil {
+			return err
+		}
+		return writeJSON(out, map[string]int{"reEncrypted": count})
+	default:
+		return fmt.Errorf("settings: unknown action %s: %w", action, use_case.ErrInvalidRequestParam)
+	}
+}
+
+func splitID(args []string) (string, []string) {
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		return args[0], args[1:]
+	}
+	return "", args
+}
+
+func writeJSON(out io.Writer, v interface{}) error {
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,26 @@
+package cli
+
+// Usage lists the commands; the global flags follow it in -help.
+const Usage = `Usage: pcrd-version-updater [flags] [command] [arguments]
+
+Commands:
+  check [id]                    check a setting, TARGET_APPID by default (the default command)
+  plan [id]                     check a setting without storing or publishing anything
+  versions list|get <id>        show the current versions
+  history <id>                  show the versions a setting went through
+  settings <action> [id]        list, get, create, update, delete or re-encrypt settings
+  checks list                   list the recorded checks
+  health [live|ready]           check every dependency
+  republish snapshot|history    publish versions or histories again
+  webhook redeliver             redeliver the failed webhook deliveries
+  config check                  print the effective configuration and validate it
+  migrate                       bring the SQL schema up to date
+  serve                         run the admin API
+  version                       print the build information
+
+Run a command with -help for its flags. Every configuration variable can
+also be set with a flag before the command, e.g. --storage-backend bolt
+for STORAGE_BACKEND.
+
+Flags:
+`
@@ -0,0 +1,145 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
+	"io"
+	"text/tabwriter"
+	"time"
+)
+
+type gameVersionOutput struct {
+	ID         string `json:"id"`
+	ServerCode string `json:"serverCode"`
+	AppVersion string `json:"appVersion"`
+	ResVersion string `json:"resVersion"`
+}
+
+func newGameVersionOutput(v use_case.GameVersion) gameVersionOutput {
+	return gameVersionOutput{
+		ID:         v.Setting.ID,
+		ServerCode: string(v.Setting.ServerCode),
+		AppVersion: v.AppVersion,
+		ResVersion: v.ResVersion,
+	}
+}
+
+type historyOutput struct {
+	ID         string    `json:"id"`
+	ServerCode string    `json:"serverCode"`
+	AppVersion string    `json:"appVersion"`
+	ResVersion string    `json:"resVersion"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+func newHistoryOutput(h use_case.VersionHistory) historyOutput {
+	return historyOutput{
+		ID:         h.Version.Setting.ID,
+		ServerCode: string(h.Version.Setting.ServerCode),
+		AppVersion: h.Version.AppVersion,
+		ResVersion: h.Version.ResVersion,
+		CreatedAt:  h.CreatedAt,
+	}
+}
+
+// Versions runs `versions list [--format json|text]` and
+// `versions get <id> [--format json|text]`.
+func Versions(ctx context.Context, u *use_case.UseCase, out io.Writer, args []string) error {
+	if len(args) <= 0 {
+		return fmt.Errorf("versions: missing action (list, get): %w", use_case.ErrInvalidRequestParam)
+	}
+
+	action := args[0]
+	ID, rest := splitID(args[1:])
+	fs := flag.NewFlagSet("versions "+action, flag.ContinueOnError)
+	formatName := fs.String("format", string(ReportFormatJSON), "output format (json, text)")
+	if err := fs.Parse(rest); err != nil {
+		return fmt.Errorf("versions %s: %s: %w", action, err, use_case.ErrInvalidRequestParam)
+	}
+	format, err := ParseReportFormat(*formatName)
+	if err != nil {
+		return err
+	}
+
+	var versions []use_case.GameVersion
+	switch action {
+	case "list":
+		if versions, err = u.ListVersions(ctx); err != nil {
+			return err
+		}
+	case "get":
+		version, err := u.GetVersion(ctx, ID)
+		if err != nil {
+			return err
+		}
+		if format == ReportFormatJSON {
+			return writeJSON(out, newGameVersionOutput(version))
+		}
+		versions = []use_case.GameVersion{version}
+	default:
+		return fmt.Errorf("versions: unknown action %s: %w", action, use_case.ErrInvalidRequestParam)
+	}
+
+	if format == ReportFormatJSON {
+		outputs := make([]gameVersionOutput, len(versions))
+		for i := range versions {
+			outputs[i] = newGameVersionOutput(versions[i])
+		}
+		return writeJSON(out, outputs)
+	}
+
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tSERVER\tAPP\tRESOURCE")
+	for _, v := range versions {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", v.Setting.ID, v.Setting.ServerCode, v.AppVersion, v.ResVersion)
+	}
+	return w.Flush()
+}
+
+// History runs `history <id> [--from t] [--to t] [--format json|text]`,
+// listing the versions a setting went through, oldest first.
+func History(ctx context.Context, u *use_case.UseCase, out io.Writer, args []string) error {
+	ID, rest := splitID(args)
+
+	fs := flag.NewFlagSet("history", flag.ContinueOnError)
+	from := fs.String("from", "", "only entries created at or after this RFC 3339 time")
+	to := fs.String("to", "", "only entries created before this RFC 3339 time")
+	formatName := fs.String("format", string(ReportFormatJSON), "output format (json, text)")
+	if err := fs.Parse(rest); err != nil {
+		return fmt.Errorf("history: %s: %w", err, use_case.ErrInvalidRequestParam)
+	}
+	format, err := ParseReportFormat(*formatName)
+	if err != nil {
+		return err
+	}
+	fromTime, err := parseTime("from", *from)
+	if err != nil {
+		return err
+	}
+	toTime, err := parseTime("to", *to)
+	if err != nil {
+		return err
+	}
+
+	histories, err := u.ListHistory(ctx, ID, fromTime, toTime)
+	if err != nil {
+		return err
+	}
+
+	if format == ReportFormatJSON {
+		outputs := make([]historyOutput, len(histories))
+		for i := range histories {
+			outputs[i] = newHistoryOutput(histories[i])
+		}
+		return writeJSON(out, outputs)
+	}
+
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "CREATED\tAPP\tRESOURCE")
+	for _, h := range histories {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", h.CreatedAt.Format(time.RFC3339), h.Version.AppVersion, h.Version.ResVersion)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,31 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
+	"io"
+)
+
+// Redeliverer is the webhook publisher's view used by the webhook command.
+type Redeliverer interface {
+	Redeliver(ctx context.Context) (int, error)
+}
+
+// Webhook runs `webhook redeliver`.
+func Webhook(ctx context.Context, r Redeliverer, out io.Writer, args []string) error {
+	if len(args) <= 0 {
+		return fmt.Errorf("webhook: missing action (redeliver): %w", use_case.ErrInvalidRequestParam)
+	}
+
+	switch args[0] {
+	case "redeliver":
+		delivered, err := r.Redeliver(ctx)
+		if werr := writeJSON(out, map[string]int{"redelivered": delivered}); werr != nil {
+			return werr
+		}
+		return err
+	default:
+		return fmt.Errorf("webhook: unknown action %s: %w", args[0], use_case.ErrInvalidRequestParam)
+	}
+}
@@ -0,0 +1,122 @@
+package fiber_server
+
+import (
+	"fmt"
+	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
+	"github.com/gofiber/fiber/v2"
+	"strconv"
+	"time"
+)
+
+type versionResponse struct {
+	AppVersion string `json:"appVersion"`
+	ResVersion string `json:"resVersion"`
+}
+
+func newVersionResponse(v *use_case.PcrdVersion) *versionResponse {
+	if v == nil {
+		return nil
+	}
+	return &versionResponse{AppVersion: v.AppVersion, ResVersion: v.ResVersion}
+}
+
+type probesResponse struct {
+	Sent   int `json:"sent"`
+	Hits   int `json:"hits"`
+	Errors int `json:"errors"`
+}
+
+type checkResponse struct {
+	ID           string           `json:"id"`
+	SettingID    string           `json:"settingId"`
+	ServerCode   string           `json:"serverCode,omitempty"`
+	Outcome      string           `json:"outcome"`
+	FailureClass string           `json:"failureClass,omitempty"`
+	Failure      string           `json:"failure,omitempty"`
+	Before       *versionResponse `json:"before"`
+	After        *versionResponse `json:"after"`
+	Probes       probesResponse   `json:"probes"`
+	TraceID      string           `json:"traceId,omitempty"`
+	StartedAt    time.Time        `json:"startedAt"`
+	FinishedAt   time.Time        `json:"finishedAt"`
+	ExpiresAt    *time.Time       `json:"expiresAt,omitempty"`
+}
+
+func newCheckResponse(c use_case.CheckRecord) checkResponse {
+	response := checkResponse{
+		ID:           c.ID,
+		SettingID:    c.SettingID,
+		ServerCode:   string(c.ServerCode),
+		Outcome:      string(c.Outcome),
+		FailureClass: string(c.FailureClass),
+		Failure:      c.Failure,
+		Before:       newVersionResponse(c.Before),
+		After:        newVersionResponse(c.After),
+		Probes:       probesResponse{Sent: c.Probes.Sent, Hits: c.Probes.Hits, Errors: c.Probes.Errors},
+		TraceID:      c.TraceID,
+		StartedAt:    c.StartedAt,
+		FinishedAt:   c.FinishedAt,
+	}
+	if !c.ExpiresAt.IsZero() {
+		response.ExpiresAt = &c.ExpiresAt
+	}
+	return response
+}
+
+func queryTime(c *fiber.Ctx, name string) (time.Time, error) {
+	value := c.Query(name)
+	if len(value) <= 0 {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s: %s: %w", name, err, use_case.ErrInvalidRequestParam)
+	}
+	return t, nil
+}
+
+// @Summary List checks
+// @Description Recorded checks, newest first, whatever their outcome.
+// @Tags checks
+// @Security AdminToken
+// @Produce json
+// @Param settingId query string false "Setting ID"
+// @Param outcome query string false "unchanged, created, updated or failed"
+// @Param from query string false "Started at or after, RFC 3339"
+// @Param to query string false "Started before, RFC 3339"
+// @Param limit query int false "Most checks to return" default(100)
+// @Success 200 {array} checkResponse
+// @Failure 400 {object} errorResponse
+// @Router /admin/checks [get]
+func (s *server) listChecks(c *fiber.Ctx) error {
+	q := use_case.CheckQuery{SettingID: c.Query("settingId"), Limit: 100}
+
+	var err error
+	if limit := c.Query("limit"); len(limit) > 0 {
+		if q.Limit, err = strconv.Atoi(limit); err != nil {
+			return fmt.Errorf("limit: %s: %w", err, use_case.ErrInvalidRequestParam)
+		}
+	}
+	if outcome := c.Query("outcome"); len(outcome) > 0 {
+		if q.Outcome, err = use_case.ParseRunOutcome(outcome); err != nil {
+			return err
+		}
+	}
+	if q.From, err = queryTime(c, "from"); err != nil {
+		return err
+	}
+	if q.To, err = queryTime(c, "to"); err != nil {
+		return err
+	}
+
+	results, err := s.useCase.ListChecks(c.UserContext(), q)
+	if err != nil {
+		return err
+	}
+
+	resp := make([]checkResponse, len(results))
+	for i := range results {
+		resp[i] = newCheckResponse(results[i])
+	}
+	return c.JSON(resp)
+}
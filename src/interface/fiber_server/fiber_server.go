@@ -0,0 +1,85 @@
+package fiber_server
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
+	"github.com/gofiber/fiber/v2"
+	"strings"
+)
+
+type server struct {
+	app        *fiber.App
+	useCase    *use_case.UseCase
+	adminToken string
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// @title PCRD Version Updater Admin API
+// @version 1.0
+// @BasePath /
+// @securityDefinitions.apikey AdminToken
+// @in header
+// @name Authorization
+func New(u *use_case.UseCase, adminToken string) *fiber.App {
+	s := &server{
+		app: fiber.New(fiber.Config{
+			DisableStartupMessage: true,
+			ErrorHandler:          errorHandler,
+		}),
+		useCase:    u,
+		adminToken: adminToken,
+	}
+
+	s.app.Get("/health/live", s.liveness)
+	s.app.Get("/health/ready", s.readiness)
+
+	admin := s.app.Group("/admin", s.requireAdmin)
+	admin.Get("/settings", s.listSettings)
+	admin.Get("/settings/:id", s.getSetting)
+	admin.Post("/settings", s.createSetting)
+	admin.Put("/settings/:id", s.updateSetting)
+	admin.Delete("/settings/:id", s.deleteSetting)
+	admin.Get("/checks", s.listChecks)
+
+	return s.app
+}
+
+// requireAdmin rejects every admin request when no token is configured, so
+// an unconfigured deployment never exposes credentials.
+func (s *server) requireAdmin(c *fiber.Ctx) error {
+	token := strings.TrimPrefix(c.Get(fiber.HeaderAuthorization), "Bearer ")
+	if len(s.adminToken) <= 0 || subtle.ConstantTimeCompare([]byte(token), []byte(s.adminToken)) != 1 {
+		return fmt.Errorf("admin token: %w", use_case.ErrPermissionDenied)
+	}
+	return c.Next()
+}
+
+func errorHandler(c *fiber.Ctx, err error) error {
+	status := fiber.StatusInternalServerError
+
+	var fiberErr *fiber.Error
+	switch {
+	case errors.As(err, &fiberErr):
+		status = fiberErr.Code
+	case errors.Is(err, use_case.ErrPermissionDenied):
+		status = fiber.StatusForbidden
+	case errors.Is(err, use_case.ErrSettingNotExists),
+		errors.Is(err, use_case.ErrVersionNotFound):
+		status = fiber.StatusNotFound
+	case errors.Is(err, use_case.ErrDependencyUnavailable):
+		status = fiber.StatusServiceUnavailable
+	case errors.Is(err, use_case.ErrSettingAlreadyExists):
+		status = fiber.StatusConflict
+	case errors.Is(err, use_case.ErrInvalidSetting),
+		errors.Is(err, use_case.ErrInvalidRequestParam),
+		errors.Is(err, use_case.ErrMissingAppID):
+		status = fiber.StatusBadRequest
+	}
+
+	return c.Status(status).JSON(errorResponse{Error: err.Error()})
+}
@@ -0,0 +1,62 @@
+package fiber_server
+
+import (
+	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
+	"github.com/gofiber/fiber/v2"
+	"time"
+)
+
+type dependencyHealthResponse struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	Error     string  `json:"error,omitempty"`
+	LatencyMs float64 `json:"latencyMs"`
+}
+
+type healthResponse struct {
+	Status       string                     `json:"status"`
+	Dependencies []dependencyHealthResponse `json:"dependencies"`
+	CheckedAt    time.Time                  `json:"checkedAt"`
+}
+
+func newHealthResponse(r use_case.HealthReport) healthResponse {
+	response := healthResponse{
+		Status:       string(r.Status),
+		Dependencies: make([]dependencyHealthResponse, len(r.Dependencies)),
+		CheckedAt:    r.CheckedAt,
+	}
+	for i, d := range r.Dependencies {
+		response.Dependencies[i] = dependencyHealthResponse{
+			Name:      d.Name,
+			Status:    string(d.Status),
+			Error:     d.Error,
+			LatencyMs: float64(d.Latency.Microseconds()) / 1000,
+		}
+	}
+	return response
+}
+
+// @Summary Liveness
+// @Description Whether the process is alive. Checks no dependency.
+// @Tags health
+// @Produce json
+// @Success 200 {object} healthResponse
+// @Router /health/live [get]
+func (s *server) liveness(c *fiber.Ctx) error {
+	return c.JSON(newHealthResponse(s.useCase.Liveness(c.UserContext())))
+}
+
+// @Summary Readiness
+// @Description Checks every dependency; 503 names the ones that are down.
+// @Tags health
+// @Produce json
+// @Success 200 {object} healthResponse
+// @Failure 503 {object} healthResponse
+// @Router /health/ready [get]
+func (s *server) readiness(c *fiber.Ctx) error {
+	report, err := s.useCase.Readiness(c.UserContext())
+	if err != nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(newHealthResponse(report))
+	}
+	return c.JSON(newHealthResponse(report))
+}
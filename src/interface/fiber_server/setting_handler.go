@@ -0,0 +1,184 @@
+package fiber_server
+
+import (
+	"fmt"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/credential"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/platform"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/setting"
+	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
+	"github.com/gofiber/fiber/v2"
+)
+
+type settingRequest struct {
+	ID                string            `json:"id"`
+	ServerCode        string            `json:"serverCode"`
+	Credential        credentialRequest `json:"credential"`
+	GuessStartVersion string            `json:"guessStartVersion"`
+	Platforms         []string          `json:"platforms"`
+	Locales           []string          `json:"locales"`
+}
+
+type credentialRequest struct {
+	UDID      string `json:"udid"`
+	ShortUDID int32  `json:"shortUdid"`
+	ViewerID  int32  `json:"viewerId"`
+}
+
+func (r settingRequest) toUseCasePCRDSetting() use_case.PCRDSetting {
+	return use_case.PCRDSetting{
+		Setting: setting.Setting{
+			ID:         r.ID,
+			ServerCode: setting.ServerCode(r.ServerCode),
+		},
+		Credential: credential.Credential{
+			Udid:      r.Credential.UDID,
+			ShortUdid: r.Credential.ShortUDID,
+			ViewerID:  r.Credential.ViewerID,
+		},
+		GuessStartVersion: r.GuessStartVersion,
+		Platforms:         platformTypes(r.Platforms),
+		Locales:           r.Locales,
+	}
+}
+
+func platformTypes(names []string) []platform.PlatformType {
+	if len(names) <= 0 {
+		return nil
+	}
+	platforms := make([]platform.PlatformType, len(names))
+	for i, name := range names {
+		platforms[i] = platform.PlatformType(name)
+	}
+	return platforms
+}
+
+// settingResponse never carries the udid, only whether one is stored.
+type settingResponse struct {
+	ID                string             `json:"id"`
+	ServerCode        string             `json:"serverCode"`
+	Credential        credentialResponse `json:"credential"`
+	GuessStartVersion string             `json:"guessStartVersion,omitempty"`
+	Platforms         []string           `json:"platforms,omitempty"`
+	Locales           []string           `json:"locales,omitempty"`
+}
+
+type credentialResponse struct {
+	HasUDID   bool  `json:"hasUdid"`
+	ShortUDID int32 `json:"shortUdid,omitempty"`
+	ViewerID  int32 `json:"viewerId,omitempty"`
+}
+
+func newSettingResponse(s use_case.PCRDSetting) settingResponse {
+	return settingResponse{
+		ID:         s.Setting.ID,
+		ServerCode: string(s.Setting.ServerCode),
+		Credential: credentialResponse{
+			HasUDID:   len(s.Credential.Udid) > 0,
+			ShortUDID: s.Credential.ShortUdid,
+			ViewerID:  s.Credential.ViewerID,
+		},
+		GuessStartVersion: s.GuessStartVersion,
+		Platforms:         platform.Names(s.Platforms),
+		Locales:           s.Locales,
+	}
+}
+
+// @Summary List settings
+// @Tags settings
+// @Security AdminToken
+// @Produce json
+// @Success 200 {array} settingResponse
+// @Router /admin/settings [get]
+func (s *server) listSettings(c *fiber.Ctx) error {
+	results, err := s.useCase.ListSettings(c.UserContext())
+	if err != nil {
+		return err
+	}
+
+	resp := make([]settingResponse, len(results))
+	for i := range results {
+		resp[i] = newSettingResponse(results[i])
+	}
+	return c.JSON(resp)
+}
+
+// @Summary Get a setting
+// @Tags settings
+// @Security AdminToken
+// @Produce json
+// @Param id path string true "Setting ID"
+// @Success 200 {object} settingResponse
+// @Failure 404 {object} errorResponse
+// @Router /admin/settings/{id} [get]
+func (s *server) getSetting(c *fiber.Ctx) error {
+	result, err := s.useCase.GetSetting(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(newSettingResponse(result))
+}
+
+// @Summary Create a setting
+// @Tags settings
+// @Security AdminToken
+// @Accept json
+// @Produce json
+// @Param setting body settingRequest true "Setting"
+// @Success 201 {object} settingResponse
+// @Failure 400 {object} errorResponse
+// @Failure 409 {object} errorResponse
+// @Router /admin/settings [post]
+func (s *server) createSetting(c *fiber.Ctx) error {
+	var req settingRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fmt.Errorf("%s: %w", err, use_case.ErrInvalidRequestParam)
+	}
+
+	result := req.toUseCasePCRDSetting()
+	err := s.useCase.CreateSetting(c.UserContext(), result)
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusCreated).JSON(newSettingResponse(result))
+}
+
+// @Summary Replace a setting
+// @Tags settings
+// @Security AdminToken
+// @Accept json
+// @Produce json
+// @Param id path string true "Setting ID"
+// @Param setting body settingRequest true "Setting"
+// @Success 200 {object} settingResponse
+// @Failure 400 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Router /admin/settings/{id} [put]
+func (s *server) updateSetting(c *fiber.Ctx) error {
+	var req settingRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fmt.Errorf("%s: %w", err, use_case.ErrInvalidRequestParam)
+	}
+	req.ID = c.Params("id")
+
+	result := req.toUseCasePCRDSetting()
+	err := s.useCase.UpdateSetting(c.UserContext(), result)
+	if err != nil {
+		return err
+	}
+	return c.JSON(newSettingResponse(result))
+}
+
+// @Summary Delete a setting
+// @Tags settings
+// @Security AdminToken
+// @Param id path string true "Setting ID"
+// @Success 204
+// @Failure 404 {object} errorResponse
+// @Router /admin/settings/{id} [delete]
+func (s *server) deleteSetting(c *fiber.Ctx) error {
+	err := s.useCase.DeleteSetting(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return err
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
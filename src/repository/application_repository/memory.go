@@ -0,0 +1,39 @@
+package application_repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/application"
+	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
+)
+
+type memory struct {
+	apps map[string]application.Application
+}
+
+// GetAndroidAppByID looks the app up by bundle ID, matching the REST query.
+func (m memory) GetAndroidAppByID(ctx context.Context, appID string) (application.Application, error) {
+	if len(appID) <= 0 {
+		return application.Application{}, fmt.Errorf("%w", use_case.ErrMissingAppID)
+	}
+
+	result, ok := m.apps[appID]
+	if !ok {
+		return application.Application{}, fmt.Errorf("%s: %w", appID, use_case.ErrApplicationNotFound)
+	}
+
+	return result, nil
+}
+
+func (m memory) HealthCheck(ctx context.Context) error {
+	return nil
+}
+
+func NewMemory(apps ...application.Application) use_case.ApplicationRepository {
+	m := memory{apps: map[string]application.Application{}}
+	for _, app := range apps {
+		m.apps[app.BundleID] = app
+	}
+
+	return m
+}
@@ -6,7 +6,9 @@ import (
 	"fmt"
 	"github.com/SpeedxPz/pcrd-version-updater/src/entity/application"
 	"github.com/SpeedxPz/pcrd-version-updater/src/entity/logger"
+	"github.com/SpeedxPz/pcrd-version-updater/src/repository/cassette"
 	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"go.opentelemetry.io/otel/codes"
 	"go.uber.org/zap"
 	"io/ioutil"
@@ -119,19 +121,55 @@ func (r rest) GetAndroidAppByID(ctx context.Context, appID string) (application.
 	return result[0], nil
 }
 
+// HealthCheck calls the status endpoint of the application service.
 func (r rest) HealthCheck(ctx context.Context) error {
+	ctx, span := tracer.Start(ctx, "application_repository.HealthCheck")
+	defer span.End()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/status", r.baseURL), nil)
+	if err != nil {
+		span.SetStatus(codes.Error, fmt.Sprintf("create request failed: %s", err))
+		return fmt.Errorf("create request failed: %s: %w", err, use_case.ErrRetrivingApplication)
+	}
+
+	res, err := r.client.Do(req)
+	if err != nil {
+		span.SetStatus(codes.Error, fmt.Sprintf("execute request failed: %s", err))
+		return fmt.Errorf("execute request failed: %s: %w", err, use_case.ErrRetrivingApplication)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		span.SetStatus(codes.Error, fmt.Sprintf("status endpoint answered %d", res.StatusCode))
+		return fmt.Errorf("status endpoint answered %d: %w", res.StatusCode, use_case.ErrRetrivingApplication)
+	}
+
 	return nil
 }
 
+// CassetteOptions records and replays the calls to the application
+// service. The bundle ID in the query is all that varies.
+func CassetteOptions() cassette.Options {
+	return cassette.Options{}
+}
+
 func NewRest(baseURL string) use_case.ApplicationRepository {
+	return NewRestWithTransport(baseURL, &http.Transport{
+		MaxIdleConns:        10,
+		IdleConnTimeout:     30 * time.Second,
+		DisableCompression:  true,
+		MaxIdleConnsPerHost: 10,
+	})
+}
+
+// NewRestWithTransport is NewRest sending its requests through transport,
+// such as a cassette.Recorder.
+func NewRestWithTransport(baseURL string, transport http.RoundTripper) use_case.ApplicationRepository {
 	c := &http.Client{
 		Timeout: 10 * time.Second,
-		Transport: &http.Transport{
-			MaxIdleConns:        10,
-			IdleConnTimeout:     30 * time.Second,
-			DisableCompression:  true,
-			MaxIdleConnsPerHost: 10,
-		},
+		// otelhttp starts a client span per request and injects its
+		// context into the request headers.
+		Transport: otelhttp.NewTransport(transport),
 	}
 
 	r := &rest{
@@ -0,0 +1,66 @@
+package application_repository_test
+
+import (
+	"context"
+	"errors"
+	"github.com/SpeedxPz/pcrd-version-updater/src/repository/application_repository"
+	"github.com/SpeedxPz/pcrd-version-updater/src/repository/cassette"
+	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+// service answers like the application service, for recording.
+func service(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case r.URL.Path == "/status":
+		w.Write([]byte(`{"status":"ok"}`))
+	case r.URL.Query().Get("bundle_id") == "th.app":
+		w.Write([]byte(`{"results":[{"app_id":"1","bundle_id":"th.app","name":"Princess Connect! Re:Dive","version":"3.1.0","author":"i3play","icon":"","platform":"android","update_datetime":"2026-10-01T00:00:00Z"}],"total":1}`))
+	default:
+		w.Write([]byte(`{"results":[],"total":0}`))
+	}
+}
+
+// TestRestCassette replays testdata/get_android_app.yaml, or records it
+// against service with CASSETTE_MODE=record.
+func TestRestCassette(t *testing.T) {
+	mode := cassette.ModeFromEnv()
+	baseURL := "http://application.cassette"
+	if mode == cassette.ModeRecord {
+		server := httptest.NewServer(http.HandlerFunc(service))
+		defer server.Close()
+		baseURL = server.URL
+	}
+
+	recorder, err := cassette.New(filepath.Join("testdata", "get_android_app.yaml"), mode, application_repository.CassetteOptions())
+	if err != nil {
+		t.Fatalf("cassette.New: %v", err)
+	}
+	repo := application_repository.NewRestWithTransport(baseURL, recorder)
+
+	ctx := context.Background()
+	app, err := repo.GetAndroidAppByID(ctx, "th.app")
+	if err != nil {
+		t.Fatalf("GetAndroidAppByID: %v", err)
+	}
+	if app.BundleID != "th.app" || app.Version != "3.1.0" {
+		t.Fatalf("GetAndroidAppByID: got %+v", app)
+	}
+	if _, err := repo.GetAndroidAppByID(ctx, "missing.app"); !errors.Is(err, use_case.ErrApplicationNotFound) {
+		t.Fatalf("GetAndroidAppByID(missing.app): got %v, want ErrApplicationNotFound", err)
+	}
+	if err := repo.HealthCheck(ctx); err != nil {
+		t.Fatalf("HealthCheck: %v", err)
+	}
+
+	if err := recorder.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if err := recorder.Done(); err != nil {
+		t.Fatalf("Done: %v", err)
+	}
+}
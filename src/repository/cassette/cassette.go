@@ -0,0 +1,384 @@
+// Package cassette records HTTP exchanges to a file and replays them, so
+// the REST repositories can be tested without network access.
+//
+// A Recorder is an http.RoundTripper. When recording, it forwards each
+// request and keeps the exchange; Save writes the cassette. When replaying,
+// the n-th request must match the n-th recorded one on method, path and
+// query, the selected headers and the body, or the round trip fails.
+// Scrubbers run on both sides before matching, so a secret redacted from
+// the cassette still matches the live request.
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrMismatch    = errors.New("request does not match the cassette")
+	ErrUnknownMode = errors.New("unknown cassette mode")
+)
+
+// Redacted replaces scrubbed values.
+const Redacted = "REDACTED"
+
+type Mode string
+
+const (
+	ModeReplay Mode = "replay"
+	ModeRecord Mode = "record"
+)
+
+func ParseMode(s string) (d Mode, e error) {
+	dataTypes := map[Mode]struct{}{
+		ModeReplay: {},
+		ModeRecord: {},
+	}
+
+	dat := Mode(s)
+	_, ok := dataTypes[dat]
+	if !ok {
+		return d, fmt.Errorf("cannot parse:[%s] as cassette mode: %w", s, ErrUnknownMode)
+	}
+	return dat, nil
+}
+
+// ModeFromEnv is ModeRecord when CASSETTE_MODE=record, else ModeReplay.
+func ModeFromEnv() Mode {
+	if Mode(os.Getenv("CASSETTE_MODE")) == ModeRecord {
+		return ModeRecord
+	}
+	return ModeReplay
+}
+
+type Request struct {
+	Method  string            `yaml:"method"`
+	URL     string            `yaml:"url"`
+	Headers map[string]string `yaml:"headers,omitempty"`
+	Body    string            `yaml:"body,omitempty"`
+}
+
+type Response struct {
+	Status  int               `yaml:"status"`
+	Headers map[string]string `yaml:"headers,omitempty"`
+	Body    string            `yaml:"body,omitempty"`
+}
+
+type Interaction struct {
+	Request  Request  `yaml:"request"`
+	Response Response `yaml:"response"`
+}
+
+type cassetteFile struct {
+	Interactions []Interaction `yaml:"interactions"`
+}
+
+// Scrubber rewrites an interaction in place. On live requests being
+// matched, only the request is set.
+type Scrubber func(i *Interaction)
+
+// ScrubHeaders redacts the named request and response headers.
+func ScrubHeaders(names ...string) Scrubber {
+	return func(i *Interaction) {
+		for _, name := range names {
+			name = http.CanonicalHeaderKey(name)
+			for _, headers := range []map[string]string{i.Request.Headers, i.Response.Headers} {
+				if _, ok := headers[name]; ok {
+					headers[name] = Redacted
+				}
+			}
+		}
+	}
+}
+
+// ScrubString replaces secret with placeholder everywhere: URL, headers
+// and bodies. Empty secrets are ignored.
+func ScrubString(secret string, placeholder string) Scrubber {
+	return func(i *Interaction) {
+		if len(secret) <= 0 {
+			return
+		}
+		replace := func(s string) string { return strings.ReplaceAll(s, secret, placeholder) }
+		i.Request.URL = replace(i.Request.URL)
+		i.Request.Body = replace(i.Request.Body)
+		i.Response.Body = replace(i.Response.Body)
+		for _, headers := range []map[string]string{i.Request.Headers, i.Response.Headers} {
+			for k, v := range headers {
+				headers[k] = replace(v)
+			}
+		}
+	}
+}
+
+// ScrubJSONFields redacts the values of the named fields, at any depth, in
+// request and response bodies that are JSON objects. Numbers become 0 and
+// booleans false, so the client still decodes them.
+func ScrubJSONFields(names ...string) Scrubber {
+	set := map[string]struct{}{}
+	for _, name := range names {
+		set[name] = struct{}{}
+	}
+	scrub := func(body string) string {
+		var v map[string]interface{}
+		if err := json.Unmarshal([]byte(body), &v); err != nil {
+			return body
+		}
+		redactFields(v, set)
+		data, err := json.Marshal(v)
+		if err != nil {
+			return body
+		}
+		return string(data)
+	}
+	return func(i *Interaction) {
+		i.Request.Body = scrub(i.Request.Body)
+		i.Response.Body = scrub(i.Response.Body)
+	}
+}
+
+func redactedValue(v interface{}) interface{} {
+	switch v.(type) {
+	case float64:
+		return 0
+	case bool:
+		return false
+	}
+	return Redacted
+}
+
+func redactFields(v interface{}, names map[string]struct{}) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, value := range v {
+			if _, ok := names[k]; ok {
+				v[k] = redactedValue(value)
+				continue
+			}
+			redactFields(value, names)
+		}
+	case []interface{}:
+		for _, value := range v {
+			redactFields(value, names)
+		}
+	}
+}
+
+type Options struct {
+	// MatchHeaders are the request headers kept in the cassette and
+	// compared on replay; other request headers are neither.
+	MatchHeaders []string
+	Scrubbers    []Scrubber
+	// Transport sends the requests when recording; nil is
+	// http.DefaultTransport.
+	Transport http.RoundTripper
+}
+
+type Recorder struct {
+	path         string
+	mode         Mode
+	opts         Options
+	mu           sync.Mutex
+	interactions []Interaction
+	next         int
+}
+
+// New returns a recorder on the cassette at path. Replaying reads it now;
+// recording starts an empty one, written by Save.
+func New(path string, mode Mode, opts Options) (*Recorder, error) {
+	if _, err := ParseMode(string(mode)); err != nil {
+		return nil, err
+	}
+	if opts.Transport == nil {
+		opts.Transport = http.DefaultTransport
+	}
+
+	r := &Recorder{path: path, mode: mode, opts: opts}
+	if mode == ModeRecord {
+		return r, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read cassette: %w", err)
+	}
+	var f cassetteFile
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("parse cassette %s: %w", path, err)
+	}
+	r.interactions = f.Interactions
+	return r, nil
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	live, err := r.request(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.mode == ModeRecord {
+		return r.record(req, live)
+	}
+	return r.replay(req, live)
+}
+
+func (r *Recorder) record(req *http.Request, live Request) (*http.Response, error) {
+	res, err := r.opts.Transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	i := Interaction{
+		Request:  live,
+		Response: Response{Status: res.StatusCode, Headers: map[string]string{}, Body: string(body)},
+	}
+	for name := range res.Header {
+		// Scrubbing can change the body's length; replay sets it.
+		if name != "Content-Length" {
+			i.Response.Headers[name] = res.Header.Get(name)
+		}
+	}
+	r.scrub(&i)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.interactions = append(r.interactions, i)
+	return res, nil
+}
+
+func (r *Recorder) replay(req *http.Request, live Request) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.next >= len(r.interactions) {
+		return nil, fmt.Errorf("%s %s: no interaction left in %s: %w", live.Method, live.URL, r.path, ErrMismatch)
+	}
+	recorded := r.interactions[r.next]
+	if diff := diffRequest(recorded.Request, live); len(diff) > 0 {
+		return nil, fmt.Errorf("interaction %d of %s: %s: %w", r.next, r.path, diff, ErrMismatch)
+	}
+	r.next++
+
+	res := &http.Response{
+		Status:        fmt.Sprintf("%d %s", recorded.Response.Status, http.StatusText(recorded.Response.Status)),
+		StatusCode:    recorded.Response.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{},
+		Body:          ioutil.NopCloser(strings.NewReader(recorded.Response.Body)),
+		ContentLength: int64(len(recorded.Response.Body)),
+		Request:       req,
+	}
+	for k, v := range recorded.Response.Headers {
+		res.Header.Set(k, v)
+	}
+	return res, nil
+}
+
+// request reads the parts of req the cassette keeps, scrubbed, and leaves
+// req's body readable.
+func (r *Recorder) request(req *http.Request) (Request, error) {
+	live := Request{Method: req.Method, URL: req.URL.RequestURI(), Headers: map[string]string{}}
+	if req.Body != nil {
+		body, err := ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return Request{}, err
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+		live.Body = string(body)
+	}
+	for _, name := range r.opts.MatchHeaders {
+		if values, ok := req.Header[http.CanonicalHeaderKey(name)]; ok {
+			live.Headers[http.CanonicalHeaderKey(name)] = strings.Join(values, ",")
+		}
+	}
+
+	i := Interaction{Request: live}
+	r.scrub(&i)
+	return i.Request, nil
+}
+
+func (r *Recorder) scrub(i *Interaction) {
+	for _, scrub := range r.opts.Scrubbers {
+		scrub(i)
+	}
+}
+
+func diffRequest(recorded Request, live Request) string {
+	var diffs []string
+	if recorded.Method != live.Method {
+		diffs = append(diffs, fmt.Sprintf("method %s, recorded %s", live.Method, recorded.Method))
+	}
+	if recorded.URL != live.URL {
+		diffs = append(diffs, fmt.Sprintf("url %s, recorded %s", live.URL, recorded.URL))
+	}
+	names := map[string]struct{}{}
+	for k := range recorded.Headers {
+		names[k] = struct{}{}
+	}
+	for k := range live.Headers {
+		names[k] = struct{}{}
+	}
+	sorted := make([]string, 0, len(names))
+	for k := range names {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+	for _, k := range sorted {
+		if recorded.Headers[k] != live.Headers[k] {
+			diffs = append(diffs, fmt.Sprintf("header %s %q, recorded %q", k, live.Headers[k], recorded.Headers[k]))
+		}
+	}
+	if recorded.Body != live.Body {
+		diffs = append(diffs, fmt.Sprintf("body %q, recorded %q", live.Body, recorded.Body))
+	}
+	return strings.Join(diffs, "; ")
+}
+
+// Save writes the recorded interactions to the cassette, creating its
+// directory. It does nothing when replaying.
+func (r *Recorder) Save() error {
+	if r.mode != ModeRecord {
+		return nil
+	}
+
+	r.mu.Lock()
+	data, err := yaml.Marshal(cassetteFile{Interactions: r.interactions})
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(r.path, data, 0644)
+}
+
+// Done reports the recorded interactions that were not replayed, so a test
+// also fails when the client makes fewer requests than it used to.
+func (r *Recorder) Done() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.mode == ModeReplay && r.next < len(r.interactions) {
+		return fmt.Errorf("%d of %d interactions of %s not replayed: %w", len(r.interactions)-r.next, len(r.interactions), r.path, ErrMismatch)
+	}
+	return nil
+}
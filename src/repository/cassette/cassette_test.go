@@ -0,0 +1,129 @@
+package cassette_test
+
+import (
+	"errors"
+	"github.com/SpeedxPz/pcrd-version-updater/src/repository/cassette"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var options = cassette.Options{
+	MatchHeaders: []string{"Version", "Token"},
+	Scrubbers: []cassette.Scrubber{
+		cassette.ScrubHeaders("Token"),
+		cassette.ScrubJSONFields("secret"),
+	},
+}
+
+func send(t *testing.T, client *http.Client, url string, version string, token string, body string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodPost, url+"/call?format=json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("NewRequest: %v", err)
+	}
+	req.Header.Set("Version", version)
+	req.Header.Set("Token", token)
+	req.Header.Set("Ignored", token)
+	return client.Do(req)
+}
+
+func record(t *testing.T) string {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"result":"ok","secret":"s3cr3t"}`))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "cassette.yaml")
+	recorder, err := cassette.New(path, cassette.ModeRecord, options)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	res, err := send(t, &http.Client{Transport: recorder}, server.URL, "1", "real-token", `{"id":1,"secret":"real"}`)
+	if err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	body, _ := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if !strings.Contains(string(body), "s3cr3t") {
+		t.Fatalf("record: got %q, want the live response", body)
+	}
+	if err := recorder.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	return path
+}
+
+func TestRecordScrubs(t *testing.T) {
+	data, err := os.ReadFile(record(t))
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	for _, secret := range []string{"real-token", "real", "s3cr3t"} {
+		if strings.Contains(string(data), secret) {
+			t.Fatalf("cassette keeps %q:\n%s", secret, data)
+		}
+	}
+	if strings.Contains(string(data), "Ignored") {
+		t.Fatalf("cassette keeps a header it does not match:\n%s", data)
+	}
+}
+
+func TestReplay(t *testing.T) {
+	path := record(t)
+
+	recorder, err := cassette.New(path, cassette.ModeReplay, options)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	client := &http.Client{Transport: recorder}
+	if err := recorder.Done(); !errors.Is(err, cassette.ErrMismatch) {
+		t.Fatalf("Done before replay: got %v, want ErrMismatch", err)
+	}
+
+	// Another token and secret match, since both are scrubbed.
+	res, err := send(t, client, "http://replay", "1", "other-token", `{"id":1,"secret":"other"}`)
+	if err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	body, _ := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK || !strings.Contains(string(body), `"result":"ok"`) {
+		t.Fatalf("replay: got %d %q, want the recorded response", res.StatusCode, body)
+	}
+	if err := recorder.Done(); err != nil {
+		t.Fatalf("Done: %v", err)
+	}
+
+	if _, err := send(t, client, "http://replay", "1", "token", `{"id":1}`); !errors.Is(err, cassette.ErrMismatch) {
+		t.Fatalf("request past the cassette: got %v, want ErrMismatch", err)
+	}
+}
+
+func TestReplayMismatch(t *testing.T) {
+	path := record(t)
+
+	for name, tc := range map[string]struct {
+		version string
+		body    string
+		diff    string
+	}{
+		"header": {version: "2", body: `{"id":1,"secret":"real"}`, diff: "header Version"},
+		"body":   {version: "1", body: `{"id":2,"secret":"real"}`, diff: "body"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			recorder, err := cassette.New(path, cassette.ModeReplay, options)
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+			_, err = send(t, &http.Client{Transport: recorder}, "http://replay", tc.version, "token", tc.body)
+			if !errors.Is(err, cassette.ErrMismatch) || !strings.Contains(err.Error(), tc.diff) {
+				t.Fatalf("got %v, want a mismatch on %s", err, tc.diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,188 @@
+package check_repository
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/logger"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/setting"
+	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
+	"go.etcd.io/bbolt"
+	"go.opentelemetry.io/otel/codes"
+	"go.uber.org/zap"
+	"time"
+)
+
+var boltBucket = []byte("checks")
+
+type bolt struct {
+	db *bbolt.DB
+}
+
+type boltCheck struct {
+	ID           string                `json:"id"`
+	SettingID    string                `json:"settingId"`
+	ServerCode   string                `json:"serverCode"`
+	Outcome      use_case.RunOutcome   `json:"outcome"`
+	FailureClass use_case.FailureClass `json:"failureClass"`
+	Failure      string                `json:"failure"`
+	Before       *use_case.PcrdVersion `json:"before"`
+	After        *use_case.PcrdVersion `json:"after"`
+	Probes       use_case.ProbeCounts  `json:"probes"`
+	TraceID      string                `json:"traceId"`
+	StartedAt    time.Time             `json:"startedAt"`
+	FinishedAt   time.Time             `json:"finishedAt"`
+	ExpiresAt    time.Time             `json:"expiresAt"`
+}
+
+// boltKey orders checks by start time, so listing newest first is a
+// reverse cursor walk.
+func boltKey(c use_case.CheckRecord) []byte {
+	key := make([]byte, 8, 8+len(c.ID))
+	binary.BigEndian.PutUint64(key, uint64(c.StartedAt.UnixNano()))
+	return append(key, c.ID...)
+}
+
+func (b bolt) CreateCheck(ctx context.Context, c use_case.CheckRecord) error {
+	ctx, span := tracer.Start(ctx, "check_repository.CreateCheck")
+	defer span.End()
+
+	err := b.db.Update(func(tx *bbolt.Tx) error {
+		data, err := json.Marshal(boltCheck{
+			ID:           c.ID,
+			SettingID:    c.SettingID,
+			ServerCode:   string(c.ServerCode),
+			Outcome:      c.Outcome,
+			FailureClass: c.FailureClass,
+			Failure:      c.Failure,
+			Before:       c.Before,
+			After:        c.After,
+			Probes:       c.Probes,
+			TraceID:      c.TraceID,
+			StartedAt:    c.StartedAt,
+			FinishedAt:   c.FinishedAt,
+			ExpiresAt:    c.ExpiresAt,
+		})
+		if err != nil {
+			return err
+		}
+		return tx.Bucket(boltBucket).Put(boltKey(c), data)
+	})
+	if err != nil {
+		zap.L().Error("error while saving", logger.WithTraceId(ctx), zap.Any("ID", c.ID), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+		return fmt.Errorf("%w", use_case.ErrSavingCheck)
+	}
+
+	return nil
+}
+
+func (b bolt) ListChecks(ctx context.Context, q use_case.CheckQuery) ([]use_case.CheckRecord, error) {
+	ctx, span := tracer.Start(ctx, "check_repository.ListChecks")
+	defer span.End()
+
+	results := []use_case.CheckRecord{}
+	err := b.db.View(func(tx *bbolt.Tx) error {
+		cursor := tx.Bucket(boltBucket).Cursor()
+		for k, v := cursor.Last(); k != nil; k, v = cursor.Prev() {
+			var o boltCheck
+			if err := json.Unmarshal(v, &o); err != nil {
+				return err
+			}
+			c := o.toUseCaseCheckRecord()
+			if !q.From.IsZero() && c.StartedAt.Before(q.From) {
+				break
+			}
+			if !matches(q, c) {
+				continue
+			}
+			results = append(results, c)
+			if q.Limit > 0 && len(results) >= q.Limit {
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		zap.L().Error("error while reading retrieving", logger.WithTraceId(ctx), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("error while retrieving: %s", err))
+		return nil, fmt.Errorf("error while retrieving: %w", use_case.ErrRetrivingCheck)
+	}
+
+	return results, nil
+}
+
+func (b bolt) DeleteExpiredChecks(ctx context.Context, now time.Time) (int, error) {
+	ctx, span := tracer.Start(ctx, "check_repository.DeleteExpiredChecks")
+	defer span.End()
+
+	var deleted int
+	err := b.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(boltBucket)
+		var keys [][]byte
+		err := bucket.ForEach(func(k, v []byte) error {
+			var o boltCheck
+			if err := json.Unmarshal(v, &o); err != nil {
+				return err
+			}
+			if expired(o.toUseCaseCheckRecord(), now) {
+				keys = append(keys, k)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, k := range keys {
+			if err := bucket.Delete(k); err != nil {
+				return err
+			}
+		}
+		deleted = len(keys)
+		return nil
+	})
+	if err != nil {
+		zap.L().Error("error while deleting", logger.WithTraceId(ctx), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+		return 0, fmt.Errorf("%w", use_case.ErrSavingCheck)
+	}
+
+	return deleted, nil
+}
+
+func (o boltCheck) toUseCaseCheckRecord() use_case.CheckRecord {
+	return use_case.CheckRecord{
+		ID:           o.ID,
+		SettingID:    o.SettingID,
+		ServerCode:   setting.ServerCode(o.ServerCode),
+		Outcome:      o.Outcome,
+		FailureClass: o.FailureClass,
+		Failure:      o.Failure,
+		Before:       o.Before,
+		After:        o.After,
+		Probes:       o.Probes,
+		TraceID:      o.TraceID,
+		StartedAt:    o.StartedAt,
+		FinishedAt:   o.FinishedAt,
+		ExpiresAt:    o.ExpiresAt,
+	}
+}
+
+func (b bolt) HealthCheck(ctx context.Context) error {
+	return b.db.View(func(tx *bbolt.Tx) error {
+		return nil
+	})
+}
+
+func NewBolt(db *bbolt.DB) (use_case.CheckRepository, error) {
+	err := db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(boltBucket)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &bolt{db: db}, nil
+}
@@ -0,0 +1,28 @@
+package check_repository
+
+import (
+	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
+	"go.opentelemetry.io/otel"
+	"time"
+)
+
+var tracer = otel.Tracer("check_repository")
+
+// matches applies q to c for the backends that filter in Go.
+func matches(q use_case.CheckQuery, c use_case.CheckRecord) bool {
+	switch {
+	case len(q.SettingID) > 0 && c.SettingID != q.SettingID:
+		return false
+	case len(q.Outcome) > 0 && c.Outcome != q.Outcome:
+		return false
+	case !q.From.IsZero() && c.StartedAt.Before(q.From):
+		return false
+	case !q.To.IsZero() && !c.StartedAt.Before(q.To):
+		return false
+	}
+	return true
+}
+
+func expired(c use_case.CheckRecord, now time.Time) bool {
+	return !c.ExpiresAt.IsZero() && !c.ExpiresAt.After(now)
+}
@@ -0,0 +1,76 @@
+package check_repository_test
+
+import (
+	"context"
+	"fmt"
+	"github.com/SpeedxPz/pcrd-version-updater/src/repository/check_repository"
+	"github.com/SpeedxPz/pcrd-version-updater/src/repository/repository_contract"
+	"github.com/SpeedxPz/pcrd-version-updater/src/repository/sql_schema"
+	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
+	"go.mongodb.org/mongo-driver/bson"
+	"testing"
+)
+
+func TestMemory(t *testing.T) {
+	repository_contract.CheckRepository(t, func(t *testing.T) use_case.CheckRepository {
+		return check_repository.NewMemory()
+	})
+}
+
+func TestBolt(t *testing.T) {
+	repository_contract.CheckRepository(t, func(t *testing.T) use_case.CheckRepository {
+		repo, err := check_repository.NewBolt(repository_contract.NewBoltDB(t))
+		if err != nil {
+			t.Fatalf("NewBolt: %v", err)
+		}
+		return repo
+	})
+}
+
+func TestMongoDb(t *testing.T) {
+	repository_contract.CheckRepository(t, func(t *testing.T) use_case.CheckRepository {
+		repo, err := check_repository.NewMongoDb(context.Background(), repository_contract.NewMongoDatabase(t))
+		if err != nil {
+			t.Fatalf("NewMongoDb: %v", err)
+		}
+		return repo
+	})
+}
+
+func TestMongoDbTTLIndex(t *testing.T) {
+	ctx := context.Background()
+	db := repository_contract.NewMongoDatabase(t)
+	if _, err := check_repository.NewMongoDb(ctx, db); err != nil {
+		t.Fatalf("NewMongoDb: %v", err)
+	}
+
+	cursor, err := db.Collection("checks").Indexes().List(ctx)
+	if err != nil {
+		t.Fatalf("Indexes: %v", err)
+	}
+	var indexes []bson.M
+	if err := cursor.All(ctx, &indexes); err != nil {
+		t.Fatalf("Indexes: %v", err)
+	}
+	for _, index := range indexes {
+		if keys, ok := index["key"].(bson.M); ok && len(keys) == 1 && keys["expiresAt"] != nil {
+			if ttl, ok := index["expireAfterSeconds"]; !ok || fmt.Sprint(ttl) != "0" {
+				t.Fatalf("expiresAt index: got expireAfterSeconds %v, want 0", ttl)
+			}
+			return
+		}
+	}
+	t.Fatalf("got indexes %v, want a TTL index on expiresAt", indexes)
+}
+
+func TestSQLite(t *testing.T) {
+	repository_contract.CheckRepository(t, func(t *testing.T) use_case.CheckRepository {
+		return check_repository.NewSQL(repository_contract.NewSQLiteDB(t), sql_schema.DialectSQLite)
+	})
+}
+
+func TestPostgres(t *testing.T) {
+	repository_contract.CheckRepository(t, func(t *testing.T) use_case.CheckRepository {
+		return check_repository.NewSQL(repository_contract.NewPostgresDB(t), sql_schema.DialectPostgres)
+	})
+}
@@ -0,0 +1,62 @@
+package check_repository
+
+import (
+	"context"
+	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
+	"sort"
+	"sync"
+	"time"
+)
+
+type memory struct {
+	mu     sync.RWMutex
+	checks []use_case.CheckRecord
+}
+
+func (m *memory) CreateCheck(ctx context.Context, c use_case.CheckRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.checks = append(m.checks, c)
+	return nil
+}
+
+func (m *memory) ListChecks(ctx context.Context, q use_case.CheckQuery) ([]use_case.CheckRecord, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	results := []use_case.CheckRecord{}
+	for _, c := range m.checks {
+		if matches(q, c) {
+			results = append(results, c)
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool { return results[i].StartedAt.After(results[j].StartedAt) })
+	if q.Limit > 0 && len(results) > q.Limit {
+		results = results[:q.Limit]
+	}
+	return results, nil
+}
+
+func (m *memory) DeleteExpiredChecks(ctx context.Context, now time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	kept := m.checks[:0]
+	for _, c := range m.checks {
+		if !expired(c, now) {
+			kept = append(kept, c)
+		}
+	}
+	deleted := len(m.checks) - len(kept)
+	m.checks = kept
+	return deleted, nil
+}
+
+func (m *memory) HealthCheck(ctx context.Context) error {
+	return nil
+}
+
+func NewMemory() use_case.CheckRepository {
+	return &memory{}
+}
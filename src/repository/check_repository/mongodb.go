@@ -0,0 +1,206 @@
+package check_repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/logger"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/setting"
+	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.opentelemetry.io/otel/codes"
+	"go.uber.org/zap"
+	"time"
+)
+
+type mongoDB struct {
+	col *mongo.Collection
+}
+
+type mongoDBVersion struct {
+	AppVersion string `bson:"appVersion"`
+	ResVersion string `bson:"resVersion"`
+}
+
+type mongoDBProbes struct {
+	Sent   int `bson:"sent"`
+	Hits   int `bson:"hits"`
+	Errors int `bson:"errors"`
+}
+
+type mongoDBCheck struct {
+	ID           string          `bson:"id"`
+	SettingID    string          `bson:"settingId"`
+	ServerCode   string          `bson:"serverCode"`
+	Outcome      string          `bson:"outcome"`
+	FailureClass string          `bson:"failureClass"`
+	Failure      string          `bson:"failure"`
+	Before       *mongoDBVersion `bson:"before"`
+	After        *mongoDBVersion `bson:"after"`
+	Probes       mongoDBProbes   `bson:"probes"`
+	TraceID      string          `bson:"traceId"`
+	StartedAt    time.Time       `bson:"startedAt"`
+	FinishedAt   time.Time       `bson:"finishedAt"`
+	// Left out when the check never expires, so the TTL index on it skips
+	// the document.
+	ExpiresAt *time.Time `bson:"expiresAt,omitempty"`
+}
+
+func newMongoDBVersion(v *use_case.PcrdVersion) *mongoDBVersion {
+	if v == nil {
+		return nil
+	}
+	return &mongoDBVersion{AppVersion: v.AppVersion, ResVersion: v.ResVersion}
+}
+
+func (v *mongoDBVersion) toUseCasePcrdVersion() *use_case.PcrdVersion {
+	if v == nil {
+		return nil
+	}
+	return &use_case.PcrdVersion{AppVersion: v.AppVersion, ResVersion: v.ResVersion}
+}
+
+func (m mongoDBCheck) toUseCaseCheckRecord() use_case.CheckRecord {
+	c := use_case.CheckRecord{
+		ID:           m.ID,
+		SettingID:    m.SettingID,
+		ServerCode:   setting.ServerCode(m.ServerCode),
+		Outcome:      use_case.RunOutcome(m.Outcome),
+		FailureClass: use_case.FailureClass(m.FailureClass),
+		Failure:      m.Failure,
+		Before:       m.Before.toUseCasePcrdVersion(),
+		After:        m.After.toUseCasePcrdVersion(),
+		Probes:       use_case.ProbeCounts{Sent: m.Probes.Sent, Hits: m.Probes.Hits, Errors: m.Probes.Errors},
+		TraceID:      m.TraceID,
+		StartedAt:    m.StartedAt,
+		FinishedAt:   m.FinishedAt,
+	}
+	if m.ExpiresAt != nil {
+		c.ExpiresAt = *m.ExpiresAt
+	}
+	return c
+}
+
+func (m mongoDB) CreateCheck(ctx context.Context, c use_case.CheckRecord) error {
+	ctx, span := tracer.Start(ctx, "check_repository.CreateCheck")
+	defer span.End()
+
+	doc := mongoDBCheck{
+		ID:           c.ID,
+		SettingID:    c.SettingID,
+		ServerCode:   string(c.ServerCode),
+		Outcome:      string(c.Outcome),
+		FailureClass: string(c.FailureClass),
+		Failure:      c.Failure,
+		Before:       newMongoDBVersion(c.Before),
+		After:        newMongoDBVersion(c.After),
+		Probes:       mongoDBProbes{Sent: c.Probes.Sent, Hits: c.Probes.Hits, Errors: c.Probes.Errors},
+		TraceID:      c.TraceID,
+		StartedAt:    c.StartedAt,
+		FinishedAt:   c.FinishedAt,
+	}
+	if !c.ExpiresAt.IsZero() {
+		doc.ExpiresAt = &c.ExpiresAt
+	}
+
+	_, err := m.col.InsertOne(ctx, doc)
+	if err != nil {
+		zap.L().Error("error while saving", logger.WithTraceId(ctx), zap.Any("ID", c.ID), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+		return fmt.Errorf("%w", use_case.ErrSavingCheck)
+	}
+
+	return nil
+}
+
+func (m mongoDB) ListChecks(ctx context.Context, q use_case.CheckQuery) ([]use_case.CheckRecord, error) {
+	ctx, span := tracer.Start(ctx, "check_repository.ListChecks")
+	defer span.End()
+
+	filter := bson.M{}
+	if len(q.SettingID) > 0 {
+		filter["settingId"] = q.SettingID
+	}
+	if len(q.Outcome) > 0 {
+		filter["outcome"] = string(q.Outcome)
+	}
+	startedAt := bson.M{}
+	if !q.From.IsZero() {
+		startedAt["$gte"] = q.From
+	}
+	if !q.To.IsZero() {
+		startedAt["$lt"] = q.To
+	}
+	if len(startedAt) > 0 {
+		filter["startedAt"] = startedAt
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "startedAt", Value: -1}})
+	if q.Limit > 0 {
+		opts.SetLimit(int64(q.Limit))
+	}
+
+	cursor, err := m.col.Find(ctx, filter, opts)
+	if err != nil {
+		zap.L().Error("error while reading retrieving", logger.WithTraceId(ctx), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("error while retrieving: %s", err))
+		return nil, fmt.Errorf("error while retrieving: %w", use_case.ErrRetrivingCheck)
+	}
+	defer cursor.Close(ctx)
+
+	results := []use_case.CheckRecord{}
+	for cursor.Next(ctx) {
+		var o mongoDBCheck
+		if err := cursor.Decode(&o); err != nil {
+			zap.L().Error("error while reading retrieving", logger.WithTraceId(ctx), zap.Any("error", err))
+			span.SetStatus(codes.Error, fmt.Sprintf("error while retrieving: %s", err))
+			return nil, fmt.Errorf("error while retrieving: %w", use_case.ErrRetrivingCheck)
+		}
+		results = append(results, o.toUseCaseCheckRecord())
+	}
+	if err := cursor.Err(); err != nil {
+		zap.L().Error("error while reading retrieving", logger.WithTraceId(ctx), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("error while retrieving: %s", err))
+		return nil, fmt.Errorf("error while retrieving: %w", use_case.ErrRetrivingCheck)
+	}
+
+	return results, nil
+}
+
+// DeleteExpiredChecks is needed even with the TTL index, whose background
+// task only runs every minute or so; the index also serves its filter.
+func (m mongoDB) DeleteExpiredChecks(ctx context.Context, now time.Time) (int, error) {
+	ctx, span := tracer.Start(ctx, "check_repository.DeleteExpiredChecks")
+	defer span.End()
+
+	result, err := m.col.DeleteMany(ctx, bson.M{"expiresAt": bson.M{"$lte": now}})
+	if err != nil {
+		zap.L().Error("error while deleting", logger.WithTraceId(ctx), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+		return 0, fmt.Errorf("%w", use_case.ErrSavingCheck)
+	}
+
+	return int(result.DeletedCount), nil
+}
+
+func (m mongoDB) HealthCheck(ctx context.Context) error {
+	return m.col.Database().Client().Ping(ctx, readpref.Primary())
+}
+
+// NewMongoDb creates the TTL index on expiresAt, which lets the server
+// delete expired checks and keeps DeleteExpiredChecks off a collection scan.
+func NewMongoDb(ctx context.Context, db *mongo.Database) (use_case.CheckRepository, error) {
+	m := &mongoDB{col: db.Collection("checks")}
+
+	_, err := m.col.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
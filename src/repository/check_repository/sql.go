@@ -0,0 +1,162 @@
+package check_repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/logger"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/setting"
+	"github.com/SpeedxPz/pcrd-version-updater/src/repository/sql_schema"
+	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
+	"go.opentelemetry.io/otel/codes"
+	"go.uber.org/zap"
+	"time"
+)
+
+type sqlDB struct {
+	db      *sql.DB
+	dialect sql_schema.Dialect
+}
+
+// sqlVersion maps a nullable version onto two nullable columns.
+func sqlVersion(v *use_case.PcrdVersion) (sql.NullString, sql.NullString) {
+	if v == nil {
+		return sql.NullString{}, sql.NullString{}
+	}
+	return sql.NullString{String: v.AppVersion, Valid: true}, sql.NullString{String: v.ResVersion, Valid: true}
+}
+
+func sqlTime(t time.Time) sql.NullTime {
+	if t.IsZero() {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: t.UTC(), Valid: true}
+}
+
+func (s sqlDB) CreateCheck(ctx context.Context, c use_case.CheckRecord) error {
+	ctx, span := tracer.Start(ctx, "check_repository.CreateCheck")
+	defer span.End()
+
+	beforeApp, beforeRes := sqlVersion(c.Before)
+	afterApp, afterRes := sqlVersion(c.After)
+	_, err := s.db.ExecContext(ctx, s.dialect.Rebind(
+		`INSERT INTO checks (id, setting_id, server_code, outcome, failure_class, failure,
+			before_app_version, before_res_version, after_app_version, after_res_version,
+			probes_sent, probes_hits, probes_errors, trace_id, started_at, finished_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+	), c.ID, c.SettingID, string(c.ServerCode), string(c.Outcome), string(c.FailureClass), c.Failure,
+		beforeApp, beforeRes, afterApp, afterRes,
+		c.Probes.Sent, c.Probes.Hits, c.Probes.Errors, c.TraceID, c.StartedAt.UTC(), c.FinishedAt.UTC(), sqlTime(c.ExpiresAt))
+	if err != nil {
+		zap.L().Error("error while saving", logger.WithTraceId(ctx), zap.Any("ID", c.ID), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+		return fmt.Errorf("%w", use_case.ErrSavingCheck)
+	}
+
+	return nil
+}
+
+func (s sqlDB) ListChecks(ctx context.Context, q use_case.CheckQuery) ([]use_case.CheckRecord, error) {
+	ctx, span := tracer.Start(ctx, "check_repository.ListChecks")
+	defer span.End()
+
+	query := `SELECT id, setting_id, server_code, outcome, failure_class, failure,
+		before_app_version, before_res_version, after_app_version, after_res_version,
+		probes_sent, probes_hits, probes_errors, trace_id, started_at, finished_at, expires_at
+		FROM checks WHERE 1 = 1`
+	args := []interface{}{}
+	if len(q.SettingID) > 0 {
+		query += ` AND setting_id = ?`
+		args = append(args, q.SettingID)
+	}
+	if len(q.Outcome) > 0 {
+		query += ` AND outcome = ?`
+		args = append(args, string(q.Outcome))
+	}
+	if !q.From.IsZero() {
+		query += ` AND started_at >= ?`
+		args = append(args, q.From.UTC())
+	}
+	if !q.To.IsZero() {
+		query += ` AND started_at < ?`
+		args = append(args, q.To.UTC())
+	}
+	query += ` ORDER BY started_at DESC`
+	if q.Limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, q.Limit)
+	}
+
+	rows, err := s.db.QueryContext(ctx, s.dialect.Rebind(query), args...)
+	if err != nil {
+		zap.L().Error("error while reading retrieving", logger.WithTraceId(ctx), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("error while retrieving: %s", err))
+		return nil, fmt.Errorf("error while retrieving: %w", use_case.ErrRetrivingCheck)
+	}
+	defer rows.Close()
+
+	results := []use_case.CheckRecord{}
+	for rows.Next() {
+		var c use_case.CheckRecord
+		var serverCode, outcome, failureClass string
+		var beforeApp, beforeRes, afterApp, afterRes sql.NullString
+		var expiresAt sql.NullTime
+		err := rows.Scan(&c.ID, &c.SettingID, &serverCode, &outcome, &failureClass, &c.Failure,
+			&beforeApp, &beforeRes, &afterApp, &afterRes,
+			&c.Probes.Sent, &c.Probes.Hits, &c.Probes.Errors, &c.TraceID, &c.StartedAt, &c.FinishedAt, &expiresAt)
+		if err != nil {
+			zap.L().Error("error while reading retrieving", logger.WithTraceId(ctx), zap.Any("error", err))
+			span.SetStatus(codes.Error, fmt.Sprintf("error while retrieving: %s", err))
+			return nil, fmt.Errorf("error while retrieving: %w", use_case.ErrRetrivingCheck)
+		}
+
+		c.ServerCode = setting.ServerCode(serverCode)
+		c.Outcome = use_case.RunOutcome(outcome)
+		c.FailureClass = use_case.FailureClass(failureClass)
+		if beforeApp.Valid {
+			c.Before = &use_case.PcrdVersion{AppVersion: beforeApp.String, ResVersion: beforeRes.String}
+		}
+		if afterApp.Valid {
+			c.After = &use_case.PcrdVersion{AppVersion: afterApp.String, ResVersion: afterRes.String}
+		}
+		if expiresAt.Valid {
+			c.ExpiresAt = expiresAt.Time
+		}
+		results = append(results, c)
+	}
+	if err := rows.Err(); err != nil {
+		zap.L().Error("error while reading retrieving", logger.WithTraceId(ctx), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("error while retrieving: %s", err))
+		return nil, fmt.Errorf("error while retrieving: %w", use_case.ErrRetrivingCheck)
+	}
+
+	return results, nil
+}
+
+func (s sqlDB) DeleteExpiredChecks(ctx context.Context, now time.Time) (int, error) {
+	ctx, span := tracer.Start(ctx, "check_repository.DeleteExpiredChecks")
+	defer span.End()
+
+	result, err := s.db.ExecContext(ctx, s.dialect.Rebind(`DELETE FROM checks WHERE expires_at <= ?`), now.UTC())
+	if err != nil {
+		zap.L().Error("error while deleting", logger.WithTraceId(ctx), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+		return 0, fmt.Errorf("%w", use_case.ErrSavingCheck)
+	}
+
+	// The count is only logged, and not every driver reports it.
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, nil
+	}
+	return int(deleted), nil
+}
+
+func (s sqlDB) HealthCheck(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+// NewSQL expects the schema to be migrated with sql_schema.Migrate.
+func NewSQL(db *sql.DB, dialect sql_schema.Dialect) use_case.CheckRepository {
+	return &sqlDB{db: db, dialect: dialect}
+}
@@ -0,0 +1,94 @@
+package check_state_repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/logger"
+	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
+	"go.etcd.io/bbolt"
+	"go.opentelemetry.io/otel/codes"
+	"go.uber.org/zap"
+	"time"
+)
+
+var boltBucket = []byte("check_states")
+
+type bolt struct {
+	db *bbolt.DB
+}
+
+type boltCheckState struct {
+	ID                  string                `json:"id"`
+	ConsecutiveFailures int                   `json:"consecutiveFailures"`
+	LastFailureClass    use_case.FailureClass `json:"lastFailureClass"`
+	LastCheckedAt       time.Time             `json:"lastCheckedAt"`
+	LastSuccessAt       time.Time             `json:"lastSuccessAt"`
+	LastChangedAt       time.Time             `json:"lastChangedAt"`
+	Alerts              []use_case.AlertType  `json:"alerts"`
+}
+
+func (b bolt) GetCheckState(ctx context.Context, ID string) (use_case.CheckState, error) {
+	ctx, span := tracer.Start(ctx, "check_state_repository.GetCheckState")
+	defer span.End()
+
+	var o boltCheckState
+	var found bool
+	err := b.db.View(func(tx *bbolt.Tx) error {
+		data := tx.Bucket(boltBucket).Get([]byte(ID))
+		if data == nil {
+			return nil
+		}
+		found = true
+		return json.Unmarshal(data, &o)
+	})
+	if err != nil {
+		zap.L().Error("error while reading retrieving", logger.WithTraceId(ctx), zap.Any("ID", ID), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("error while retrieving: %s", err))
+		return use_case.CheckState{}, fmt.Errorf("error while retrieving: %w", use_case.ErrRetrivingCheckState)
+	}
+
+	if !found {
+		return use_case.CheckState{}, fmt.Errorf("%s: %w", ID, use_case.ErrCheckStateNotFound)
+	}
+
+	return use_case.CheckState(o), nil
+}
+
+func (b bolt) SaveCheckState(ctx context.Context, s use_case.CheckState) error {
+	ctx, span := tracer.Start(ctx, "check_state_repository.SaveCheckState")
+	defer span.End()
+
+	err := b.db.Update(func(tx *bbolt.Tx) error {
+		data, err := json.Marshal(boltCheckState(s))
+		if err != nil {
+			return err
+		}
+		return tx.Bucket(boltBucket).Put([]byte(s.ID), data)
+	})
+	if err != nil {
+		zap.L().Error("error while saving", logger.WithTraceId(ctx), zap.Any("ID", s.ID), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+		return fmt.Errorf("%w", use_case.ErrSavingCheckState)
+	}
+
+	return nil
+}
+
+func (b bolt) HealthCheck(ctx context.Context) error {
+	return b.db.View(func(tx *bbolt.Tx) error {
+		return nil
+	})
+}
+
+func NewBolt(db *bbolt.DB) (use_case.CheckStateRepository, error) {
+	err := db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(boltBucket)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &bolt{db: db}, nil
+}
@@ -0,0 +1,5 @@
+package check_state_repository
+
+import "go.opentelemetry.io/otel"
+
+var tracer = otel.Tracer("check_state_repository")
@@ -0,0 +1,43 @@
+package check_state_repository_test
+
+import (
+	"github.com/SpeedxPz/pcrd-version-updater/src/repository/check_state_repository"
+	"github.com/SpeedxPz/pcrd-version-updater/src/repository/repository_contract"
+	"github.com/SpeedxPz/pcrd-version-updater/src/repository/sql_schema"
+	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
+	"testing"
+)
+
+func TestMemory(t *testing.T) {
+	repository_contract.CheckStateRepository(t, func(t *testing.T) use_case.CheckStateRepository {
+		return check_state_repository.NewMemory()
+	})
+}
+
+func TestBolt(t *testing.T) {
+	repository_contract.CheckStateRepository(t, func(t *testing.T) use_case.CheckStateRepository {
+		repo, err := check_state_repository.NewBolt(repository_contract.NewBoltDB(t))
+		if err != nil {
+			t.Fatalf("NewBolt: %v", err)
+		}
+		return repo
+	})
+}
+
+func TestMongoDb(t *testing.T) {
+	repository_contract.CheckStateRepository(t, func(t *testing.T) use_case.CheckStateRepository {
+		return check_state_repository.NewMongoDb(repository_contract.NewMongoDatabase(t))
+	})
+}
+
+func TestSQLite(t *testing.T) {
+	repository_contract.CheckStateRepository(t, func(t *testing.T) use_case.CheckStateRepository {
+		return check_state_repository.NewSQL(repository_contract.NewSQLiteDB(t), sql_schema.DialectSQLite)
+	})
+}
+
+func TestPostgres(t *testing.T) {
+	repository_contract.CheckStateRepository(t, func(t *testing.T) use_case.CheckStateRepository {
+		return check_state_repository.NewSQL(repository_contract.NewPostgresDB(t), sql_schema.DialectPostgres)
+	})
+}
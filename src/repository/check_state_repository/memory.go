@@ -0,0 +1,42 @@
+package check_state_repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
+	"sync"
+)
+
+type memory struct {
+	mu     sync.RWMutex
+	states map[string]use_case.CheckState
+}
+
+func (m *memory) GetCheckState(ctx context.Context, ID string) (use_case.CheckState, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	s, ok := m.states[ID]
+	if !ok {
+		return use_case.CheckState{}, fmt.Errorf("%s: %w", ID, use_case.ErrCheckStateNotFound)
+	}
+	s.Alerts = append([]use_case.AlertType(nil), s.Alerts...)
+	return s, nil
+}
+
+func (m *memory) SaveCheckState(ctx context.Context, s use_case.CheckState) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s.Alerts = append([]use_case.AlertType(nil), s.Alerts...)
+	m.states[s.ID] = s
+	return nil
+}
+
+func (m *memory) HealthCheck(ctx context.Context) error {
+	return nil
+}
+
+func NewMemory() use_case.CheckStateRepository {
+	return &memory{states: map[string]use_case.CheckState{}}
+}
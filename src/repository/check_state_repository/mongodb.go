@@ -0,0 +1,100 @@
+package check_state_repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/logger"
+	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.opentelemetry.io/otel/codes"
+	"go.uber.org/zap"
+	"time"
+)
+
+type mongoDB struct {
+	col *mongo.Collection
+}
+
+type mongoDBCheckState struct {
+	ID                  string    `bson:"id"`
+	ConsecutiveFailures int       `bson:"consecutiveFailures"`
+	LastFailureClass    string    `bson:"lastFailureClass"`
+	LastCheckedAt       time.Time `bson:"lastCheckedAt"`
+	LastSuccessAt       time.Time `bson:"lastSuccessAt"`
+	LastChangedAt       time.Time `bson:"lastChangedAt"`
+	Alerts              []string  `bson:"alerts"`
+}
+
+func (m mongoDBCheckState) toUseCaseCheckState() use_case.CheckState {
+	s := use_case.CheckState{
+		ID:                  m.ID,
+		ConsecutiveFailures: m.ConsecutiveFailures,
+		LastFailureClass:    use_case.FailureClass(m.LastFailureClass),
+		LastCheckedAt:       m.LastCheckedAt,
+		LastSuccessAt:       m.LastSuccessAt,
+		LastChangedAt:       m.LastChangedAt,
+	}
+	for _, a := range m.Alerts {
+		s.Alerts = append(s.Alerts, use_case.AlertType(a))
+	}
+	return s
+}
+
+func (m mongoDB) GetCheckState(ctx context.Context, ID string) (use_case.CheckState, error) {
+	ctx, span := tracer.Start(ctx, "check_state_repository.GetCheckState")
+	defer span.End()
+
+	var o mongoDBCheckState
+	err := m.col.FindOne(ctx, bson.M{"id": ID}).Decode(&o)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return use_case.CheckState{}, fmt.Errorf("%s: %w", ID, use_case.ErrCheckStateNotFound)
+	}
+	if err != nil {
+		zap.L().Error("error while reading retrieving", logger.WithTraceId(ctx), zap.Any("ID", ID), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("error while retrieving: %s", err))
+		return use_case.CheckState{}, fmt.Errorf("error while retrieving: %w", use_case.ErrRetrivingCheckState)
+	}
+
+	return o.toUseCaseCheckState(), nil
+}
+
+func (m mongoDB) SaveCheckState(ctx context.Context, s use_case.CheckState) error {
+	ctx, span := tracer.Start(ctx, "check_state_repository.SaveCheckState")
+	defer span.End()
+
+	doc := mongoDBCheckState{
+		ID:                  s.ID,
+		ConsecutiveFailures: s.ConsecutiveFailures,
+		LastFailureClass:    string(s.LastFailureClass),
+		LastCheckedAt:       s.LastCheckedAt,
+		LastSuccessAt:       s.LastSuccessAt,
+		LastChangedAt:       s.LastChangedAt,
+		Alerts:              []string{},
+	}
+	for _, a := range s.Alerts {
+		doc.Alerts = append(doc.Alerts, string(a))
+	}
+
+	_, err := m.col.ReplaceOne(ctx, bson.M{"id": s.ID}, doc, options.Replace().SetUpsert(true))
+	if err != nil {
+		zap.L().Error("error while saving", logger.WithTraceId(ctx), zap.Any("ID", s.ID), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+		return fmt.Errorf("%w", use_case.ErrSavingCheckState)
+	}
+
+	return nil
+}
+
+func (m mongoDB) HealthCheck(ctx context.Context) error {
+	return m.col.Database().Client().Ping(ctx, readpref.Primary())
+}
+
+func NewMongoDb(db *mongo.Database) use_case.CheckStateRepository {
+	m := &mongoDB{col: db.Collection("check_states")}
+
+	return m
+}
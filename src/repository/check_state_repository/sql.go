@@ -0,0 +1,89 @@
+package check_state_repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/logger"
+	"github.com/SpeedxPz/pcrd-version-updater/src/repository/sql_schema"
+	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
+	"go.opentelemetry.io/otel/codes"
+	"go.uber.org/zap"
+	"strings"
+	"time"
+)
+
+type sqlDB struct {
+	db      *sql.DB
+	dialect sql_schema.Dialect
+}
+
+func (s sqlDB) GetCheckState(ctx context.Context, ID string) (use_case.CheckState, error) {
+	ctx, span := tracer.Start(ctx, "check_state_repository.GetCheckState")
+	defer span.End()
+
+	var o use_case.CheckState
+	var lastFailureClass, alerts string
+	var lastSuccessAt sql.NullTime
+	err := s.db.QueryRowContext(ctx, s.dialect.Rebind(
+		`SELECT id, consecutive_failures, last_failure_class, last_checked_at, last_success_at, last_changed_at, alerts FROM check_states WHERE id = ?`,
+	), ID).Scan(&o.ID, &o.ConsecutiveFailures, &lastFailureClass, &o.LastCheckedAt, &lastSuccessAt, &o.LastChangedAt, &alerts)
+	if errors.Is(err, sql.ErrNoRows) {
+		return use_case.CheckState{}, fmt.Errorf("%s: %w", ID, use_case.ErrCheckStateNotFound)
+	}
+	if err != nil {
+		zap.L().Error("error while reading retrieving", logger.WithTraceId(ctx), zap.Any("ID", ID), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("error while retrieving: %s", err))
+		return use_case.CheckState{}, fmt.Errorf("error while retrieving: %w", use_case.ErrRetrivingCheckState)
+	}
+
+	o.LastFailureClass = use_case.FailureClass(lastFailureClass)
+	if lastSuccessAt.Valid {
+		o.LastSuccessAt = lastSuccessAt.Time
+	}
+	if len(alerts) > 0 {
+		for _, a := range strings.Split(alerts, ",") {
+			o.Alerts = append(o.Alerts, use_case.AlertType(a))
+		}
+	}
+
+	return o, nil
+}
+
+func (s sqlDB) SaveCheckState(ctx context.Context, c use_case.CheckState) error {
+	ctx, span := tracer.Start(ctx, "check_state_repository.SaveCheckState")
+	defer span.End()
+
+	alerts := make([]string, len(c.Alerts))
+	for i, a := range c.Alerts {
+		alerts[i] = string(a)
+	}
+	var lastSuccessAt *time.Time
+	if !c.LastSuccessAt.IsZero() {
+		t := c.LastSuccessAt.UTC()
+		lastSuccessAt = &t
+	}
+
+	_, err := s.db.ExecContext(ctx, s.dialect.Rebind(
+		`INSERT INTO check_states (id, consecutive_failures, last_failure_class, last_checked_at, last_success_at, last_changed_at, alerts) VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET consecutive_failures = excluded.consecutive_failures, last_failure_class = excluded.last_failure_class,
+			last_checked_at = excluded.last_checked_at, last_success_at = excluded.last_success_at, last_changed_at = excluded.last_changed_at, alerts = excluded.alerts`,
+	), c.ID, c.ConsecutiveFailures, string(c.LastFailureClass), c.LastCheckedAt.UTC(), lastSuccessAt, c.LastChangedAt.UTC(), strings.Join(alerts, ","))
+	if err != nil {
+		zap.L().Error("error while saving", logger.WithTraceId(ctx), zap.Any("ID", c.ID), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+		return fmt.Errorf("%w", use_case.ErrSavingCheckState)
+	}
+
+	return nil
+}
+
+func (s sqlDB) HealthCheck(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+// NewSQL expects the schema to be migrated with sql_schema.Migrate.
+func NewSQL(db *sql.DB, dialect sql_schema.Dialect) use_case.CheckStateRepository {
+	return &sqlDB{db: db, dialect: dialect}
+}
@@ -0,0 +1,120 @@
+package delivery_repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/logger"
+	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
+	"go.etcd.io/bbolt"
+	"go.opentelemetry.io/otel/codes"
+	"go.uber.org/zap"
+	"time"
+)
+
+var boltBucket = []byte("webhook_deliveries")
+
+type bolt struct {
+	db *bbolt.DB
+}
+
+type boltDelivery struct {
+	ID            string    `json:"id"`
+	URL           string    `json:"url"`
+	EventID       string    `json:"eventId"`
+	Body          []byte    `json:"body"`
+	Attempts      int       `json:"attempts"`
+	LastError     string    `json:"lastError"`
+	FirstFailedAt time.Time `json:"firstFailedAt"`
+	LastAttemptAt time.Time `json:"lastAttemptAt"`
+}
+
+func (b bolt) SaveFailedDelivery(ctx context.Context, d use_case.FailedDelivery) error {
+	ctx, span := tracer.Start(ctx, "delivery_repository.SaveFailedDelivery")
+	defer span.End()
+
+	err := b.db.Update(func(tx *bbolt.Tx) error {
+		data, err := json.Marshal(boltDelivery(d))
+		if err != nil {
+			return err
+		}
+		return tx.Bucket(boltBucket).Put([]byte(d.ID), data)
+	})
+	if err != nil {
+		zap.L().Error("error while saving", logger.WithTraceId(ctx), zap.Any("ID", d.ID), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+		return fmt.Errorf("%w", use_case.ErrSavingDelivery)
+	}
+
+	return nil
+}
+
+func (b bolt) ListFailedDeliveries(ctx context.Context) ([]use_case.FailedDelivery, error) {
+	ctx, span := tracer.Start(ctx, "delivery_repository.ListFailedDeliveries")
+	defer span.End()
+
+	results := []use_case.FailedDelivery{}
+	err := b.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(boltBucket).ForEach(func(k, v []byte) error {
+			var o boltDelivery
+			if err := json.Unmarshal(v, &o); err != nil {
+				return err
+			}
+			results = append(results, use_case.FailedDelivery(o))
+			return nil
+		})
+	})
+	if err != nil {
+		zap.L().Error("error while reading retrieving", logger.WithTraceId(ctx), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("error while retrieving: %s", err))
+		return nil, fmt.Errorf("error while retrieving: %w", use_case.ErrRetrivingDelivery)
+	}
+	sortFailedDeliveries(results)
+
+	return results, nil
+}
+
+func (b bolt) DeleteFailedDelivery(ctx context.Context, ID string) error {
+	ctx, span := tracer.Start(ctx, "delivery_repository.DeleteFailedDelivery")
+	defer span.End()
+
+	var exists bool
+	err := b.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(boltBucket)
+		exists = bucket.Get([]byte(ID)) != nil
+		if !exists {
+			return nil
+		}
+		return bucket.Delete([]byte(ID))
+	})
+	if err != nil {
+		zap.L().Error("error while deleting", logger.WithTraceId(ctx), zap.Any("ID", ID), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+		return fmt.Errorf("%w", use_case.ErrSavingDelivery)
+	}
+
+	if !exists {
+		span.SetStatus(codes.Error, fmt.Sprintf("%s: %s", ID, use_case.ErrDeliveryNotFound))
+		return fmt.Errorf("delete delivery %s: %w", ID, use_case.ErrDeliveryNotFound)
+	}
+
+	return nil
+}
+
+func (b bolt) HealthCheck(ctx context.Context) error {
+	return b.db.View(func(tx *bbolt.Tx) error {
+		return nil
+	})
+}
+
+func NewBolt(db *bbolt.DB) (use_case.DeliveryRepository, error) {
+	err := db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(boltBucket)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &bolt{db: db}, nil
+}
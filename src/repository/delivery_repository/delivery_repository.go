@@ -0,0 +1,5 @@
+package delivery_repository
+
+import "go.opentelemetry.io/otel"
+
+var tracer = otel.Tracer("delivery_repository")
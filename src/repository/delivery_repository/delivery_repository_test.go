@@ -0,0 +1,43 @@
+package delivery_repository_test
+
+import (
+	"github.com/SpeedxPz/pcrd-version-updater/src/repository/delivery_repository"
+	"github.com/SpeedxPz/pcrd-version-updater/src/repository/repository_contract"
+	"github.com/SpeedxPz/pcrd-version-updater/src/repository/sql_schema"
+	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
+	"testing"
+)
+
+func TestMemory(t *testing.T) {
+	repository_contract.DeliveryRepository(t, func(t *testing.T) use_case.DeliveryRepository {
+		return delivery_repository.NewMemory()
+	})
+}
+
+func TestBolt(t *testing.T) {
+	repository_contract.DeliveryRepository(t, func(t *testing.T) use_case.DeliveryRepository {
+		repo, err := delivery_repository.NewBolt(repository_contract.NewBoltDB(t))
+		if err != nil {
+			t.Fatalf("NewBolt: %v", err)
+		}
+		return repo
+	})
+}
+
+func TestMongoDb(t *testing.T) {
+	repository_contract.DeliveryRepository(t, func(t *testing.T) use_case.DeliveryRepository {
+		return delivery_repository.NewMongoDb(repository_contract.NewMongoDatabase(t))
+	})
+}
+
+func TestSQLite(t *testing.T) {
+	repository_contract.DeliveryRepository(t, func(t *testing.T) use_case.DeliveryRepository {
+		return delivery_repository.NewSQL(repository_contract.NewSQLiteDB(t), sql_schema.DialectSQLite)
+	})
+}
+
+func TestPostgres(t *testing.T) {
+	repository_contract.DeliveryRepository(t, func(t *testing.T) use_case.DeliveryRepository {
+		return delivery_repository.NewSQL(repository_contract.NewPostgresDB(t), sql_schema.DialectPostgres)
+	})
+}
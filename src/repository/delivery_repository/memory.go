@@ -0,0 +1,66 @@
+package delivery_repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
+	"sort"
+	"sync"
+)
+
+type memory struct {
+	mu         sync.RWMutex
+	deliveries map[string]use_case.FailedDelivery
+}
+
+func (m *memory) SaveFailedDelivery(ctx context.Context, d use_case.FailedDelivery) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.deliveries[d.ID] = d
+	return nil
+}
+
+func (m *memory) ListFailedDeliveries(ctx context.Context) ([]use_case.FailedDelivery, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	results := make([]use_case.FailedDelivery, 0, len(m.deliveries))
+	for _, d := range m.deliveries {
+		results = append(results, d)
+	}
+	sortFailedDeliveries(results)
+
+	return results, nil
+}
+
+func (m *memory) DeleteFailedDelivery(ctx context.Context, ID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.deliveries[ID]; !ok {
+		return fmt.Errorf("delete delivery %s: %w", ID, use_case.ErrDeliveryNotFound)
+	}
+
+	delete(m.deliveries, ID)
+	return nil
+}
+
+func (m *memory) HealthCheck(ctx context.Context) error {
+	return nil
+}
+
+func NewMemory() use_case.DeliveryRepository {
+	return &memory{deliveries: map[string]use_case.FailedDelivery{}}
+}
+
+// sortFailedDeliveries orders deliveries oldest failure first, the order
+// they are redelivered in.
+func sortFailedDeliveries(deliveries []use_case.FailedDelivery) {
+	sort.Slice(deliveries, func(i, j int) bool {
+		if !deliveries[i].FirstFailedAt.Equal(deliveries[j].FirstFailedAt) {
+			return deliveries[i].FirstFailedAt.Before(deliveries[j].FirstFailedAt)
+		}
+		return deliveries[i].ID < deliveries[j].ID
+	})
+}
@@ -0,0 +1,130 @@
+package delivery_repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/logger"
+	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.opentelemetry.io/otel/codes"
+	"go.uber.org/zap"
+	"time"
+)
+
+type mongoDB struct {
+	col *mongo.Collection
+}
+
+type mongoDBDelivery struct {
+	ID            string    `bson:"id"`
+	URL           string    `bson:"url"`
+	EventID       string    `bson:"eventId"`
+	Body          []byte    `bson:"body"`
+	Attempts      int       `bson:"attempts"`
+	LastError     string    `bson:"lastError"`
+	FirstFailedAt time.Time `bson:"firstFailedAt"`
+	LastAttemptAt time.Time `bson:"lastAttemptAt"`
+}
+
+func (m mongoDBDelivery) toUseCaseFailedDelivery() use_case.FailedDelivery {
+	return use_case.FailedDelivery{
+		ID:            m.ID,
+		URL:           m.URL,
+		EventID:       m.EventID,
+		Body:          m.Body,
+		Attempts:      m.Attempts,
+		LastError:     m.LastError,
+		FirstFailedAt: m.FirstFailedAt,
+		LastAttemptAt: m.LastAttemptAt,
+	}
+}
+
+func (m mongoDB) SaveFailedDelivery(ctx context.Context, d use_case.FailedDelivery) error {
+	ctx, span := tracer.Start(ctx, "delivery_repository.SaveFailedDelivery")
+	defer span.End()
+
+	doc := mongoDBDelivery{
+		ID:            d.ID,
+		URL:           d.URL,
+		EventID:       d.EventID,
+		Body:          d.Body,
+		Attempts:      d.Attempts,
+		LastError:     d.LastError,
+		FirstFailedAt: d.FirstFailedAt,
+		LastAttemptAt: d.LastAttemptAt,
+	}
+
+	_, err := m.col.ReplaceOne(ctx, bson.M{"id": d.ID}, doc, options.Replace().SetUpsert(true))
+	if err != nil {
+		zap.L().Error("error while saving", logger.WithTraceId(ctx), zap.Any("ID", d.ID), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+		return fmt.Errorf("%w", use_case.ErrSavingDelivery)
+	}
+
+	return nil
+}
+
+func (m mongoDB) ListFailedDeliveries(ctx context.Context) ([]use_case.FailedDelivery, error) {
+	ctx, span := tracer.Start(ctx, "delivery_repository.ListFailedDeliveries")
+	defer span.End()
+
+	cur, err := m.col.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "firstFailedAt", Value: 1}, {Key: "id", Value: 1}}))
+	if err != nil {
+		zap.L().Error("error while reading retrieving", logger.WithTraceId(ctx), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("error while retrieving: %s", err))
+		return nil, fmt.Errorf("error while retrieving: %w", use_case.ErrRetrivingDelivery)
+	}
+	defer cur.Close(ctx)
+
+	results := []use_case.FailedDelivery{}
+	for cur.Next(ctx) {
+		var o mongoDBDelivery
+		err := cur.Decode(&o)
+		if err != nil {
+			zap.L().Error("error while reading retrieving", logger.WithTraceId(ctx), zap.Any("error", err))
+			span.SetStatus(codes.Error, fmt.Sprintf("error while retrieving: %s", err))
+			return nil, fmt.Errorf("error while retrieving: %w", use_case.ErrRetrivingDelivery)
+		}
+		results = append(results, o.toUseCaseFailedDelivery())
+	}
+
+	if err := cur.Err(); err != nil {
+		zap.L().Error("error while reading retrieving", logger.WithTraceId(ctx), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("error while retrieving: %s", err))
+		return nil, fmt.Errorf("error while retrieving: %w", use_case.ErrRetrivingDelivery)
+	}
+
+	return results, nil
+}
+
+func (m mongoDB) DeleteFailedDelivery(ctx context.Context, ID string) error {
+	ctx, span := tracer.Start(ctx, "delivery_repository.DeleteFailedDelivery")
+	defer span.End()
+
+	res, err := m.col.DeleteOne(ctx, bson.M{"id": ID})
+	if err != nil {
+		zap.L().Error("error while deleting", logger.WithTraceId(ctx), zap.Any("ID", ID), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+		return fmt.Errorf("%w", use_case.ErrSavingDelivery)
+	}
+
+	if res.DeletedCount == 0 {
+		span.SetStatus(codes.Error, fmt.Sprintf("%s: %s", ID, use_case.ErrDeliveryNotFound))
+		return fmt.Errorf("delete delivery %s: %w", ID, use_case.ErrDeliveryNotFound)
+	}
+
+	return nil
+}
+
+func (m mongoDB) HealthCheck(ctx context.Context) error {
+	return m.col.Database().Client().Ping(ctx, readpref.Primary())
+}
+
+func NewMongoDb(db *mongo.Database) use_case.DeliveryRepository {
+	m := &mongoDB{col: db.Collection("webhook_deliveries")}
+
+	return m
+}
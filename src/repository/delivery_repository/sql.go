@@ -0,0 +1,104 @@
+package delivery_repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/logger"
+	"github.com/SpeedxPz/pcrd-version-updater/src/repository/sql_schema"
+	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
+	"go.opentelemetry.io/otel/codes"
+	"go.uber.org/zap"
+)
+
+type sqlDB struct {
+	db      *sql.DB
+	dialect sql_schema.Dialect
+}
+
+func (s sqlDB) SaveFailedDelivery(ctx context.Context, d use_case.FailedDelivery) error {
+	ctx, span := tracer.Start(ctx, "delivery_repository.SaveFailedDelivery")
+	defer span.End()
+
+	_, err := s.db.ExecContext(ctx, s.dialect.Rebind(
+		`INSERT INTO webhook_deliveries (id, url, event_id, body, attempts, last_error, first_failed_at, last_attempt_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET attempts = excluded.attempts, last_error = excluded.last_error, last_attempt_at = excluded.last_attempt_at`,
+	), d.ID, d.URL, d.EventID, d.Body, d.Attempts, d.LastError, d.FirstFailedAt.UTC(), d.LastAttemptAt.UTC())
+	if err != nil {
+		zap.L().Error("error while saving", logger.WithTraceId(ctx), zap.Any("ID", d.ID), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+		return fmt.Errorf("%w", use_case.ErrSavingDelivery)
+	}
+
+	return nil
+}
+
+func (s sqlDB) ListFailedDeliveries(ctx context.Context) ([]use_case.FailedDelivery, error) {
+	ctx, span := tracer.Start(ctx, "delivery_repository.ListFailedDeliveries")
+	defer span.End()
+
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT id, url, event_id, body, attempts, last_error, first_failed_at, last_attempt_at FROM webhook_deliveries ORDER BY first_failed_at, id`,
+	)
+	if err != nil {
+		zap.L().Error("error while reading retrieving", logger.WithTraceId(ctx), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("error while retrieving: %s", err))
+		return nil, fmt.Errorf("error while retrieving: %w", use_case.ErrRetrivingDelivery)
+	}
+	defer rows.Close()
+
+	results := []use_case.FailedDelivery{}
+	for rows.Next() {
+		var o use_case.FailedDelivery
+		err := rows.Scan(&o.ID, &o.URL, &o.EventID, &o.Body, &o.Attempts, &o.LastError, &o.FirstFailedAt, &o.LastAttemptAt)
+		if err != nil {
+			zap.L().Error("error while reading retrieving", logger.WithTraceId(ctx), zap.Any("error", err))
+			span.SetStatus(codes.Error, fmt.Sprintf("error while retrieving: %s", err))
+			return nil, fmt.Errorf("error while retrieving: %w", use_case.ErrRetrivingDelivery)
+		}
+		results = append(results, o)
+	}
+
+	if err := rows.Err(); err != nil {
+		zap.L().Error("error while reading retrieving", logger.WithTraceId(ctx), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("error while retrieving: %s", err))
+		return nil, fmt.Errorf("error while retrieving: %w", use_case.ErrRetrivingDelivery)
+	}
+
+	return results, nil
+}
+
+func (s sqlDB) DeleteFailedDelivery(ctx context.Context, ID string) error {
+	ctx, span := tracer.Start(ctx, "delivery_repository.DeleteFailedDelivery")
+	defer span.End()
+
+	res, err := s.db.ExecContext(ctx, s.dialect.Rebind(`DELETE FROM webhook_deliveries WHERE id = ?`), ID)
+	if err != nil {
+		zap.L().Error("error while deleting", logger.WithTraceId(ctx), zap.Any("ID", ID), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+		return fmt.Errorf("%w", use_case.ErrSavingDelivery)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		zap.L().Error("error while deleting", logger.WithTraceId(ctx), zap.Any("ID", ID), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+		return fmt.Errorf("%w", use_case.ErrSavingDelivery)
+	}
+
+	if affected == 0 {
+		span.SetStatus(codes.Error, fmt.Sprintf("%s: %s", ID, use_case.ErrDeliveryNotFound))
+		return fmt.Errorf("delete delivery %s: %w", ID, use_case.ErrDeliveryNotFound)
+	}
+
+	return nil
+}
+
+func (s sqlDB) HealthCheck(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+// NewSQL expects the schema to be migrated with sql_schema.Migrate.
+func NewSQL(db *sql.DB, dialect sql_schema.Dialect) use_case.DeliveryRepository {
+	return &sqlDB{db: db, dialect: dialect}
+}
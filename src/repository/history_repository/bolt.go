@@ -0,0 +1,135 @@
+package history_repository
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/clock"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/logger"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/setting"
+	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
+	"go.etcd.io/bbolt"
+	"go.opentelemetry.io/otel/codes"
+	"go.uber.org/zap"
+	"time"
+)
+
+var boltBucket = []byte("histories")
+
+type bolt struct {
+	db    *bbolt.DB
+	clock clock.Clock
+}
+
+type boltVersion struct {
+	ID             string    `json:"id"`
+	ServerCode     string    `json:"serverCode"`
+	AppVersion     string    `json:"appVersion"`
+	ResVersion     string    `json:"resVersion"`
+	CreateDateTime time.Time `json:"createdAt"`
+	UpdateDateTime time.Time `json:"updatedAt"`
+}
+
+// Create appends the entry under the bucket's next sequence number, so a
+// cursor walks histories in the order they were written.
+func (b bolt) Create(ctx context.Context, version use_case.GameVersion) error {
+	ctx, span := tracer.Start(ctx, "history_repository.Create")
+	defer span.End()
+
+	now := b.clock.Now()
+	doc := boltVersion{
+		ID:             version.Setting.ID,
+		ServerCode:     string(version.Setting.ServerCode),
+		AppVersion:     version.AppVersion,
+		ResVersion:     version.ResVersion,
+		CreateDateTime: now,
+		UpdateDateTime: now,
+	}
+
+	err := b.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(boltBucket)
+		seq, err := bucket.NextSequence()
+		if err != nil {
+			return err
+		}
+
+		data, err := json.Marshal(doc)
+		if err != nil {
+			return err
+		}
+
+		key := make([]byte, 8)
+		binary.BigEndian.PutUint64(key, seq)
+		return bucket.Put(key, data)
+	})
+	if err != nil {
+		zap.L().Error("error while saving", logger.WithTraceId(ctx), zap.Any("version", version), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+		return fmt.Errorf("%w", use_case.ErrSavingVersion)
+	}
+
+	return nil
+}
+
+// ListHistories walks the whole bucket; entries are in sequence order, which
+// is also creation order.
+func (b bolt) ListHistories(ctx context.Context, ID string, from time.Time, to time.Time) ([]use_case.VersionHistory, error) {
+	ctx, span := tracer.Start(ctx, "history_repository.ListHistories")
+	defer span.End()
+
+	results := []use_case.VersionHistory{}
+	err := b.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(boltBucket).ForEach(func(k, v []byte) error {
+			var o boltVersion
+			if err := json.Unmarshal(v, &o); err != nil {
+				return err
+			}
+			if (len(ID) > 0 && o.ID != ID) || !inRange(o.CreateDateTime, from, to) {
+				return nil
+			}
+
+			serverCode, err := setting.ParseServerCode(o.ServerCode)
+			if err != nil {
+				return err
+			}
+			results = append(results, use_case.VersionHistory{
+				Version: use_case.GameVersion{
+					Setting: setting.Setting{
+						ID:         o.ID,
+						ServerCode: serverCode,
+					},
+					AppVersion: o.AppVersion,
+					ResVersion: o.ResVersion,
+				},
+				CreatedAt: o.CreateDateTime,
+			})
+			return nil
+		})
+	})
+	if err != nil {
+		zap.L().Error("error while reading retrieving", logger.WithTraceId(ctx), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("error while retrieving: %s", err))
+		return nil, fmt.Errorf("error while retrieving: %w", use_case.ErrRetrivingVersion)
+	}
+
+	return results, nil
+}
+
+func (b bolt) HealthCheck(ctx context.Context) error {
+	return b.db.View(func(tx *bbolt.Tx) error {
+		return nil
+	})
+}
+
+func NewBolt(db *bbolt.DB, clk clock.Clock) (use_case.HistoryRepository, error) {
+	err := db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(boltBucket)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &bolt{db: db, clock: clk}, nil
+}
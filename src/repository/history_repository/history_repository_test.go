@@ -0,0 +1,44 @@
+package history_repository_test
+
+import (
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/clock"
+	"github.com/SpeedxPz/pcrd-version-updater/src/repository/history_repository"
+	"github.com/SpeedxPz/pcrd-version-updater/src/repository/repository_contract"
+	"github.com/SpeedxPz/pcrd-version-updater/src/repository/sql_schema"
+	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
+	"testing"
+)
+
+func TestMemory(t *testing.T) {
+	repository_contract.HistoryRepository(t, func(t *testing.T, clk clock.Clock) use_case.HistoryRepository {
+		return history_repository.NewMemory(clk)
+	})
+}
+
+func TestBolt(t *testing.T) {
+	repository_contract.HistoryRepository(t, func(t *testing.T, clk clock.Clock) use_case.HistoryRepository {
+		repo, err := history_repository.NewBolt(repository_contract.NewBoltDB(t), clk)
+		if err != nil {
+			t.Fatalf("NewBolt: %v", err)
+		}
+		return repo
+	})
+}
+
+func TestMongoDb(t *testing.T) {
+	repository_contract.HistoryRepository(t, func(t *testing.T, clk clock.Clock) use_case.HistoryRepository {
+		return history_repository.NewMongoDb(repository_contract.NewMongoDatabase(t), clk)
+	})
+}
+
+func TestSQLite(t *testing.T) {
+	repository_contract.HistoryRepository(t, func(t *testing.T, clk clock.Clock) use_case.HistoryRepository {
+		return history_repository.NewSQL(repository_contract.NewSQLiteDB(t), sql_schema.DialectSQLite, clk)
+	})
+}
+
+func TestPostgres(t *testing.T) {
+	repository_contract.HistoryRepository(t, func(t *testing.T, clk clock.Clock) use_case.HistoryRepository {
+		return history_repository.NewSQL(repository_contract.NewPostgresDB(t), sql_schema.DialectPostgres, clk)
+	})
+}
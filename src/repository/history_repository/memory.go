@@ -0,0 +1,58 @@
+package history_repository
+
+import (
+	"context"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/clock"
+	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
+	"sync"
+	"time"
+)
+
+// Memory keeps histories in insertion order. It is exported so tests can
+// inspect what was recorded.
+type Memory struct {
+	mu        sync.RWMutex
+	histories []use_case.VersionHistory
+	clock     clock.Clock
+}
+
+func (m *Memory) Create(ctx context.Context, version use_case.GameVersion) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.histories = append(m.histories, use_case.VersionHistory{Version: version, CreatedAt: m.clock.Now()})
+	return nil
+}
+
+func (m *Memory) ListHistories(ctx context.Context, ID string, from time.Time, to time.Time) ([]use_case.VersionHistory, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	results := []use_case.VersionHistory{}
+	for _, h := range m.histories {
+		if (len(ID) <= 0 || h.Version.Setting.ID == ID) && inRange(h.CreatedAt, from, to) {
+			results = append(results, h)
+		}
+	}
+
+	return results, nil
+}
+
+func (m *Memory) HealthCheck(ctx context.Context) error {
+	return nil
+}
+
+func (m *Memory) Histories() []use_case.VersionHistory {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return append([]use_case.VersionHistory(nil), m.histories...)
+}
+
+func NewMemory(clk clock.Clock) *Memory {
+	return &Memory{clock: clk}
+}
+
+func inRange(t time.Time, from time.Time, to time.Time) bool {
+	return !t.Before(from) && (to.IsZero() || t.Before(to))
+}
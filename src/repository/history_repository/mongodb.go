@@ -3,10 +3,13 @@ package history_repository
 import (
 	"context"
 	"fmt"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/clock"
 	"github.com/SpeedxPz/pcrd-version-updater/src/entity/logger"
 	"github.com/SpeedxPz/pcrd-version-updater/src/entity/setting"
 	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.mongodb.org/mongo-driver/mongo/readpref"
 	"go.opentelemetry.io/otel/codes"
 	"go.uber.org/zap"
@@ -14,7 +17,8 @@ import (
 )
 
 type mongoDB struct {
-	col *mongo.Collection
+	col   *mongo.Collection
+	clock clock.Clock
 }
 
 type mongoDBVersion struct {
@@ -57,8 +61,9 @@ func (m mongoDB) Create(ctx context.Context, version use_case.GameVersion) error
 	defer span.End()
 
 	doc := newMongoDBVersion(version)
-	doc.CreateDateTime = time.Now()
-	doc.UpdateDateTime = time.Now()
+	now := m.clock.Now()
+	doc.CreateDateTime = now
+	doc.UpdateDateTime = now
 	_, err := m.col.InsertOne(ctx, doc)
 
 	if err != nil {
@@ -70,12 +75,62 @@ func (m mongoDB) Create(ctx context.Context, version use_case.GameVersion) error
 	return nil
 }
 
+func (m mongoDB) ListHistories(ctx context.Context, ID string, from time.Time, to time.Time) ([]use_case.VersionHistory, error) {
+	ctx, span := tracer.Start(ctx, "history_repository.ListHistories")
+	defer span.End()
+
+	createdAt := bson.M{"$gte": from}
+	if !to.IsZero() {
+		createdAt["$lt"] = to
+	}
+
+	filter := bson.M{"createdAt": createdAt}
+	if len(ID) > 0 {
+		filter["id"] = ID
+	}
+
+	// _id breaks ties in insertion order, ObjectIDs being increasing.
+	cur, err := m.col.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}, {Key: "_id", Value: 1}}))
+	if err != nil {
+		zap.L().Error("error while reading retrieving", logger.WithTraceId(ctx), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("error while retrieving: %s", err))
+		return nil, fmt.Errorf("error while retrieving: %w", use_case.ErrRetrivingVersion)
+	}
+	defer cur.Close(ctx)
+
+	results := []use_case.VersionHistory{}
+	for cur.Next(ctx) {
+		var o mongoDBVersion
+		err := cur.Decode(&o)
+		if err != nil {
+			zap.L().Error("error while reading retrieving", logger.WithTraceId(ctx), zap.Any("error", err))
+			span.SetStatus(codes.Error, fmt.Sprintf("error while retrieving: %s", err))
+			return nil, fmt.Errorf("error while retrieving: %w", use_case.ErrRetrivingVersion)
+		}
+		version, err := o.ToUseCaseGameVersion()
+		if err != nil {
+			zap.L().Error("error while reading retrieving", logger.WithTraceId(ctx), zap.Any("ID", o.ID), zap.Any("error", err))
+			span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+			return nil, fmt.Errorf("%w", use_case.ErrRetrivingVersion)
+		}
+		results = append(results, use_case.VersionHistory{Version: version, CreatedAt: o.CreateDateTime})
+	}
+
+	if err := cur.Err(); err != nil {
+		zap.L().Error("error while reading retrieving", logger.WithTraceId(ctx), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("error while retrieving: %s", err))
+		return nil, fmt.Errorf("error while retrieving: %w", use_case.ErrRetrivingVersion)
+	}
+
+	return results, nil
+}
+
 func (m mongoDB) HealthCheck(ctx context.Context) error {
 	return m.col.Database().Client().Ping(ctx, readpref.Primary())
 }
 
-func NewMongoDb(db *mongo.Database) use_case.HistoryRepository {
-	m := &mongoDB{col: db.Collection("histories")}
+func NewMongoDb(db *mongo.Database, clk clock.Clock) use_case.HistoryRepository {
+	m := &mongoDB{col: db.Collection("histories"), clock: clk}
 
 	return m
 }
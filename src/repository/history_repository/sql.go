@@ -0,0 +1,122 @@
+package history_repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/clock"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/logger"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/setting"
+	"github.com/SpeedxPz/pcrd-version-updater/src/repository/sql_schema"
+	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
+	"go.opentelemetry.io/otel/codes"
+	"go.uber.org/zap"
+	"time"
+)
+
+// Execer is what InsertSQL writes through: a *sql.DB or a *sql.Tx.
+type Execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// InsertSQL appends the history entry of version created at now through
+// exec, so that version_repository can write it in its own transaction.
+func InsertSQL(ctx context.Context, exec Execer, dialect sql_schema.Dialect, version use_case.GameVersion, now time.Time) error {
+	_, err := exec.ExecContext(ctx, dialect.Rebind(
+		`INSERT INTO histories (id, server_code, app_version, res_version, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)`,
+	), version.Setting.ID, string(version.Setting.ServerCode), version.AppVersion, version.ResVersion, now.UTC(), now.UTC())
+	return err
+}
+
+type sqlDB struct {
+	db      *sql.DB
+	dialect sql_schema.Dialect
+	clock   clock.Clock
+}
+
+func (s sqlDB) Create(ctx context.Context, version use_case.GameVersion) error {
+	ctx, span := tracer.Start(ctx, "history_repository.Create")
+	defer span.End()
+
+	err := InsertSQL(ctx, s.db, s.dialect, version, s.clock.Now())
+	if err != nil {
+		zap.L().Error("error while saving", logger.WithTraceId(ctx), zap.Any("version", version), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+		return fmt.Errorf("%w", use_case.ErrSavingVersion)
+	}
+
+	return nil
+}
+
+func (s sqlDB) ListHistories(ctx context.Context, ID string, from time.Time, to time.Time) ([]use_case.VersionHistory, error) {
+	ctx, span := tracer.Start(ctx, "history_repository.ListHistories")
+	defer span.End()
+
+	query := `SELECT id, server_code, app_version, res_version, created_at FROM histories WHERE created_at >= ?`
+	args := []interface{}{from.UTC()}
+	if !to.IsZero() {
+		query += ` AND created_at < ?`
+		args = append(args, to.UTC())
+	}
+	if len(ID) > 0 {
+		query += ` AND id = ?`
+		args = append(args, ID)
+	}
+	query += ` ORDER BY seq`
+
+	rows, err := s.db.QueryContext(ctx, s.dialect.Rebind(query), args...)
+	if err != nil {
+		zap.L().Error("error while reading retrieving", logger.WithTraceId(ctx), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("error while retrieving: %s", err))
+		return nil, fmt.Errorf("error while retrieving: %w", use_case.ErrRetrivingVersion)
+	}
+	defer rows.Close()
+
+	results := []use_case.VersionHistory{}
+	for rows.Next() {
+		var ID, serverCode, appVersion, resVersion string
+		var createdAt time.Time
+		err := rows.Scan(&ID, &serverCode, &appVersion, &resVersion, &createdAt)
+		if err != nil {
+			zap.L().Error("error while reading retrieving", logger.WithTraceId(ctx), zap.Any("error", err))
+			span.SetStatus(codes.Error, fmt.Sprintf("error while retrieving: %s", err))
+			return nil, fmt.Errorf("error while retrieving: %w", use_case.ErrRetrivingVersion)
+		}
+
+		parsedServerCode, err := setting.ParseServerCode(serverCode)
+		if err != nil {
+			zap.L().Error("error while reading retrieving", logger.WithTraceId(ctx), zap.Any("ID", ID), zap.Any("error", err))
+			span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+			return nil, fmt.Errorf("%w", use_case.ErrRetrivingVersion)
+		}
+
+		results = append(results, use_case.VersionHistory{
+			Version: use_case.GameVersion{
+				Setting: setting.Setting{
+					ID:         ID,
+					ServerCode: parsedServerCode,
+				},
+				AppVersion: appVersion,
+				ResVersion: resVersion,
+			},
+			CreatedAt: createdAt,
+		})
+	}
+
+	if err := rows.Err(); err != nil {
+		zap.L().Error("error while reading retrieving", logger.WithTraceId(ctx), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("error while retrieving: %s", err))
+		return nil, fmt.Errorf("error while retrieving: %w", use_case.ErrRetrivingVersion)
+	}
+
+	return results, nil
+}
+
+func (s sqlDB) HealthCheck(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+// NewSQL expects the schema to be migrated with sql_schema.Migrate.
+func NewSQL(db *sql.DB, dialect sql_schema.Dialect, clk clock.Clock) use_case.HistoryRepository {
+	return &sqlDB{db: db, dialect: dialect, clock: clk}
+}
@@ -0,0 +1,74 @@
+package pcrd_jp_repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/platform"
+	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
+	"strconv"
+)
+
+type memory struct {
+	// published holds the versions of every platform under
+	// PlatformTypeNone, and those of one platform only under it.
+	published map[platform.PlatformType]map[int64]struct{}
+}
+
+// GetResourceVersions guesses over the published versions with the same
+// steps as the REST implementation, without any network or sleep. Locales
+// are not told apart.
+func (m memory) GetResourceVersions(ctx context.Context, startVersion string, platforms []platform.PlatformType, locales []string) ([]use_case.PlatformVersion, error) {
+	version, err := strconv.ParseInt(startVersion, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid start version %s: %w", startVersion, use_case.ErrRetrieveData)
+	}
+
+	versions := make([]use_case.PlatformVersion, len(platforms))
+	for i, p := range platforms {
+		versions[i] = use_case.PlatformVersion{Platform: p, ResVersion: startVersion}
+	}
+	for i := 1; i < 20; i++ {
+		guessNumber := version + (int64(i) * int64(10))
+		for j, p := range platforms {
+			if !m.isPublished(p, guessNumber) {
+				use_case.RecordProbe(ctx, use_case.ProbeMiss)
+				continue
+			}
+			use_case.RecordProbe(ctx, use_case.ProbeHit)
+			versions[j].ResVersion = fmt.Sprintf("%d", guessNumber)
+		}
+	}
+
+	return versions, nil
+}
+
+func (m memory) isPublished(p platform.PlatformType, version int64) bool {
+	if _, ok := m.published[platform.PlatformTypeNone][version]; ok {
+		return true
+	}
+	_, ok := m.published[p][version]
+	return ok
+}
+
+func (m memory) HealthCheck(ctx context.Context) error {
+	return nil
+}
+
+// NewMemory publishes versions on every platform.
+func NewMemory(published ...int64) use_case.PcrdJPRepository {
+	return NewMemoryByPlatform(map[platform.PlatformType][]int64{platform.PlatformTypeNone: published})
+}
+
+// NewMemoryByPlatform publishes versions on the platform they are listed
+// under; those under PlatformTypeNone are on every platform.
+func NewMemoryByPlatform(published map[platform.PlatformType][]int64) use_case.PcrdJPRepository {
+	m := memory{published: map[platform.PlatformType]map[int64]struct{}{}}
+	for p, versions := range published {
+		m.published[p] = map[int64]struct{}{}
+		for _, v := range versions {
+			m.published[p][v] = struct{}{}
+		}
+	}
+
+	return m
+}
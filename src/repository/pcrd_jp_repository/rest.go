@@ -3,60 +3,118 @@ package pcrd_jp_repository
 import (
 	"context"
 	"fmt"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/clock"
 	"github.com/SpeedxPz/pcrd-version-updater/src/entity/logger"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/platform"
+	"github.com/SpeedxPz/pcrd-version-updater/src/repository/cassette"
 	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"go.opentelemetry.io/otel/codes"
 	"go.uber.org/zap"
+	"io"
+	"io/ioutil"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 )
 
+// probeInterval spaces the guesses out, so the CDN is not hammered.
+const probeInterval = 1 * time.Second
+
+// manifestPeek is how much of a manifest is read to tell it from an error
+// page; its first line already names a sub-manifest.
+const manifestPeek = 4096
+
+// manifestPlatforms are the path segments of each platform's manifest.
+var manifestPlatforms = map[platform.PlatformType]string{
+	platform.PlatformTypeAndroid: "Android",
+	platform.PlatformTypeIOS:     "iOS",
+	platform.PlatformTypeWindows: "Windows",
+}
+
 type rest struct {
 	client  *http.Client
 	baseURL string
-	locale  string
+	clock   clock.Clock
 }
 
-func (r rest) GetResourceVersion(ctx context.Context, startVersion string) (string, error) {
-	ctx, span := tracer.Start(ctx, "pcrd_jp_repository.GetResourceVersion")
+// GetResourceVersions probes every platform on each guess, then waits
+// probeInterval before the next one. A platform with no newer version keeps
+// startVersion.
+func (r rest) GetResourceVersions(ctx context.Context, startVersion string, platforms []platform.PlatformType, locales []string) ([]use_case.PlatformVersion, error) {
+	ctx, span := tracer.Start(ctx, "pcrd_jp_repository.GetResourceVersions")
 	defer span.End()
 
 	version, err := strconv.ParseInt(startVersion, 10, 64)
 	if err != nil {
 		zap.L().Error("invalid start version", logger.WithTraceId(ctx), zap.Any("error", err), zap.Any("startVersion", startVersion))
 		span.SetStatus(codes.Error, fmt.Sprintf("invalid start version %s: %s", startVersion, err))
-		return "", fmt.Errorf("invalid start version %s: %w", startVersion, use_case.ErrRetrieveData)
+		return nil, fmt.Errorf("invalid start version %s: %w", startVersion, use_case.ErrRetrieveData)
+	}
+	for _, p := range platforms {
+		if _, ok := manifestPlatforms[p]; !ok {
+			span.SetStatus(codes.Error, fmt.Sprintf("unknown platform %q", p))
+			return nil, fmt.Errorf("unknown platform %q: %w", p, use_case.ErrRetrieveData)
+		}
 	}
 
-	var newVersion string
+	versions := make([]use_case.PlatformVersion, len(platforms))
+	for i, p := range platforms {
+		versions[i] = use_case.PlatformVersion{Platform: p, ResVersion: startVersion}
+	}
 	for i := 1; i < 20; i++ {
+		if ctx.Err() != nil {
+			break
+		}
+
 		guessNumber := version + (int64(i) * int64(10))
 		zap.L().Debug("guesing", logger.WithTraceId(ctx), zap.Any("guessNumber", guessNumber))
-		result, err := r.call(ctx, guessNumber)
-		if err != nil {
-			zap.L().Error("guessing failed", logger.WithTraceId(ctx), zap.Any("error", err), zap.Any("version", guessNumber))
-			continue
+		for j, p := range platforms {
+			if r.isPublished(ctx, guessNumber, p, locales) {
+				versions[j].ResVersion = fmt.Sprintf("%d", guessNumber)
+				zap.L().Debug("version accept!", logger.WithTraceId(ctx), zap.Any("guessNumber", guessNumber), zap.Any("platform", p))
+			}
 		}
-		if result {
-			newVersion = fmt.Sprintf("%d", guessNumber)
-			zap.L().Debug("version accept!", logger.WithTraceId(ctx), zap.Any("guessNumber", guessNumber))
-		}
-		time.Sleep(1 * time.Second)
+
+		// Interrupted sleeps end the loop on its next ctx check.
+		_ = r.clock.Sleep(ctx, probeInterval)
 	}
 
-	if len(newVersion) <= 0 {
-		newVersion = startVersion
+	// Guessing cut short by the deadline would report the start version as
+	// the latest one.
+	if err := ctx.Err(); err != nil {
+		span.SetStatus(codes.Error, fmt.Sprintf("guessing stopped: %s", err))
+		return nil, fmt.Errorf("guessing stopped: %s: %w", err, use_case.ErrRetrieveData)
 	}
 
-	return newVersion, nil
+	return versions, nil
+}
+
+// isPublished tells whether version is out on p, which takes its manifest
+// in every locale. It stops at the first locale without one.
+func (r rest) isPublished(ctx context.Context, version int64, p platform.PlatformType, locales []string) bool {
+	for _, locale := range locales {
+		result, err := r.call(ctx, version, manifestPlatforms[p], locale)
+		if err != nil {
+			use_case.RecordProbe(ctx, use_case.ProbeError)
+			zap.L().Error("guessing failed", logger.WithTraceId(ctx), zap.Any("error", err), zap.Any("version", version), zap.Any("platform", p), zap.Any("locale", locale))
+			return false
+		}
+		if !result {
+			use_case.RecordProbe(ctx, use_case.ProbeMiss)
+			return false
+		}
+		use_case.RecordProbe(ctx, use_case.ProbeHit)
+	}
+	return true
 }
 
-func (r rest) call(ctx context.Context, version int64) (bool, error) {
+func (r rest) call(ctx context.Context, version int64, segment string, locale string) (bool, error) {
 	ctx, span := tracer.Start(ctx, fmt.Sprintf("pcrd_jp_repository.call(%d)", version))
 	defer span.End()
 
-	endpoint := fmt.Sprintf("%s/dl/Resources/%d/%s/AssetBundles/Android/manifest/manifest_assetmanifest", r.baseURL, version, r.locale)
+	endpoint := fmt.Sprintf("%s/dl/Resources/%d/%s/AssetBundles/%s/manifest/manifest_assetmanifest", r.baseURL, version, locale, segment)
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
 	if err != nil {
@@ -71,33 +129,81 @@ func (r rest) call(ctx context.Context, version int64) (bool, error) {
 		span.SetStatus(codes.Error, fmt.Sprintf("request failed: %s", err))
 		return false, fmt.Errorf("request failed: %w", use_case.ErrRetrieveData)
 	}
+	defer res.Body.Close()
 
 	if res.StatusCode != http.StatusOK {
 		return false, nil
 	}
 
+	// A misconfigured edge answers 200 with an error page, which must not
+	// pass for a published version.
+	data, err := ioutil.ReadAll(io.LimitReader(res.Body, manifestPeek))
+	if err != nil {
+		zap.L().Error("read body failed", logger.WithTraceId(ctx), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("read body failed: %s", err))
+		return false, fmt.Errorf("read body failed: %w", use_case.ErrRetrieveData)
+	}
+	if !strings.HasPrefix(string(data), "manifest/") || !strings.Contains(string(data), "_assetmanifest,") {
+		span.SetStatus(codes.Error, "answered 200 without a manifest")
+		return false, fmt.Errorf("answered 200 without a manifest: %w", use_case.ErrRetrieveData)
+	}
+
 	return true, nil
 }
 
+// HealthCheck sends a HEAD to the CDN. Any answer below 500 means it is
+// reachable; the root itself is usually forbidden.
 func (r rest) HealthCheck(ctx context.Context) error {
+	ctx, span := tracer.Start(ctx, "pcrd_jp_repository.HealthCheck")
+	defer span.End()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, r.baseURL, nil)
+	if err != nil {
+		span.SetStatus(codes.Error, fmt.Sprintf("create request failed: %s", err))
+		return fmt.Errorf("create request failed: %s: %w", err, use_case.ErrRetrieveData)
+	}
+
+	res, err := r.client.Do(req)
+	if err != nil {
+		span.SetStatus(codes.Error, fmt.Sprintf("request failed: %s", err))
+		return fmt.Errorf("request failed: %s: %w", err, use_case.ErrRetrieveData)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, fmt.Sprintf("cdn answered %d", res.StatusCode))
+		return fmt.Errorf("cdn answered %d: %w", res.StatusCode, use_case.ErrRetrieveData)
+	}
+
 	return nil
 }
 
-func NewRest(baseURL string) use_case.PcrdJPRepository {
+// CassetteOptions records and replays CDN probes, which carry no secret.
+func CassetteOptions() cassette.Options {
+	return cassette.Options{}
+}
+
+func NewRest(baseURL string, clk clock.Clock) use_case.PcrdJPRepository {
+	return NewRestWithTransport(baseURL, clk, &http.Transport{
+		MaxIdleConns:        10,
+		IdleConnTimeout:     30 * time.Second,
+		DisableCompression:  true,
+		MaxIdleConnsPerHost: 10,
+	})
+}
+
+// NewRestWithTransport probes the CDN through transport, waiting between
+// guesses on clk.
+func NewRestWithTransport(baseURL string, clk clock.Clock, transport http.RoundTripper) use_case.PcrdJPRepository {
 	c := &http.Client{
-		Timeout: 10 * time.Second,
-		Transport: &http.Transport{
-			MaxIdleConns:        10,
-			IdleConnTimeout:     30 * time.Second,
-			DisableCompression:  true,
-			MaxIdleConnsPerHost: 10,
-		},
+		Timeout:   10 * time.Second,
+		Transport: otelhttp.NewTransport(transport),
 	}
 
 	r := &rest{
 		client:  c,
 		baseURL: baseURL,
-		locale:  "Jpn",
+		clock:   clk,
 	}
 	return r
 }
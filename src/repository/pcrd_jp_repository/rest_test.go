@@ -0,0 +1,200 @@
+package pcrd_jp_repository_test
+
+import (
+	"context"
+	"errors"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/clock"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/platform"
+	"github.com/SpeedxPz/pcrd-version-updater/src/fake/pcrd_jp_cdn"
+	"github.com/SpeedxPz/pcrd-version-updater/src/repository/cassette"
+	"github.com/SpeedxPz/pcrd-version-updater/src/repository/pcrd_jp_repository"
+	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var start = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func newRest(t *testing.T, cdn *pcrd_jp_cdn.CDN, clk clock.Clock) use_case.PcrdJPRepository {
+	server := httptest.NewServer(cdn)
+	t.Cleanup(server.Close)
+	return pcrd_jp_repository.NewRest(server.URL, clk)
+}
+
+// guess runs the guessing on the default platform and locale.
+func guess(ctx context.Context, repo use_case.PcrdJPRepository, startVersion string) (string, error) {
+	versions, err := repo.GetResourceVersions(ctx, startVersion, use_case.DefaultJPPlatforms, use_case.DefaultJPLocales)
+	if err != nil {
+		return "", err
+	}
+	return versions[0].ResVersion, nil
+}
+
+func TestRestGetResourceVersions(t *testing.T) {
+	clk := clock.NewFake(start)
+	cdn := pcrd_jp_cdn.New(clk)
+	cdn.Publish(10000010, start)
+	cdn.Publish(10000030, start.Add(2*time.Second))
+	cdn.Publish(10000050, start.Add(time.Hour))
+
+	got, err := guess(context.Background(), newRest(t, cdn, clk), "10000000")
+	if err != nil {
+		t.Fatalf("GetResourceVersions: %v", err)
+	}
+	if got != "10000030" {
+		t.Fatalf("GetResourceVersions: got %q, want the latest version published while guessing", got)
+	}
+
+	requests := cdn.Requests()
+	if len(requests) != 19 {
+		t.Fatalf("got %d requests, want 19", len(requests))
+	}
+	for i, r := range requests {
+		if r.Version != 10000010+int64(i)*10 || !r.Time.Equal(start.Add(time.Duration(i)*time.Second)) {
+			t.Fatalf("request %d: got %d at %v, want guesses a second apart", i, r.Version, r.Time)
+		}
+		if r.Locale != "Jpn" || r.Platform != "Android" {
+			t.Fatalf("request %d: got %s/%s", i, r.Locale, r.Platform)
+		}
+	}
+}
+
+func TestRestGetResourceVersionsNothingNew(t *testing.T) {
+	clk := clock.NewFake(start)
+
+	got, err := guess(context.Background(), newRest(t, pcrd_jp_cdn.New(clk), clk), "10000000")
+	if err != nil || got != "10000000" {
+		t.Fatalf("GetResourceVersions: got %q, %v, want the start version", got, err)
+	}
+}
+
+func TestRestGetResourceVersionsDeadline(t *testing.T) {
+	clk := clock.NewFake(start)
+	cdn := pcrd_jp_cdn.New(clk)
+	cdn.Publish(10000010, start)
+	ctx, cancel := clk.WithTimeout(context.Background(), 5500*time.Millisecond)
+	defer cancel()
+
+	_, err := guess(ctx, newRest(t, cdn, clk), "10000000")
+	if !errors.Is(err, use_case.ErrRetrieveData) {
+		t.Fatalf("GetResourceVersions: got %v, want %v rather than a partial guess", err, use_case.ErrRetrieveData)
+	}
+	if n := len(cdn.Requests()); n != 6 {
+		t.Fatalf("got %d requests, want 6 before the deadline", n)
+	}
+}
+
+func TestRestGetResourceVersionsPlatforms(t *testing.T) {
+	clk := clock.NewFake(start)
+	cdn := pcrd_jp_cdn.New(clk)
+	cdn.Publish(10000030, start)
+	cdn.PublishOn("Android", 10000050, start)
+	cdn.PublishOn("iOS", 10000050, start.Add(time.Hour))
+	platforms := []platform.PlatformType{platform.PlatformTypeAndroid, platform.PlatformTypeIOS, platform.PlatformTypeWindows}
+
+	got, err := newRest(t, cdn, clk).GetResourceVersions(context.Background(), "10000000", platforms, []string{"Jpn", "Eng"})
+	if err != nil {
+		t.Fatalf("GetResourceVersions: %v", err)
+	}
+	want := []use_case.PlatformVersion{
+		{Platform: platform.PlatformTypeAndroid, ResVersion: "10000050"},
+		{Platform: platform.PlatformTypeIOS, ResVersion: "10000030"},
+		{Platform: platform.PlatformTypeWindows, ResVersion: "10000030"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("GetResourceVersions: got %+v, want %+v", got, want)
+	}
+
+	// A hit is checked in every locale, a miss stops at the first one.
+	requests := cdn.Requests()
+	if len(requests) != 61 {
+		t.Fatalf("got %d requests, want 61", len(requests))
+	}
+	locales := map[string]bool{}
+	for _, r := range requests {
+		if r.Platform == "Android" && r.Version == 10000050 {
+			locales[r.Locale] = true
+		}
+	}
+	if !locales["Jpn"] || !locales["Eng"] {
+		t.Fatalf("got locales %v for the Android hit, want Jpn and Eng", locales)
+	}
+}
+
+func TestRestGetResourceVersionsUnknownPlatform(t *testing.T) {
+	clk := clock.NewFake(start)
+	cdn := pcrd_jp_cdn.New(clk)
+
+	_, err := newRest(t, cdn, clk).GetResourceVersions(context.Background(), "10000000", []platform.PlatformType{"switch"}, use_case.DefaultJPLocales)
+	if !errors.Is(err, use_case.ErrRetrieveData) || len(cdn.Requests()) != 0 {
+		t.Fatalf("GetResourceVersions: got %v after %d requests, want %v before any", err, len(cdn.Requests()), use_case.ErrRetrieveData)
+	}
+}
+
+// TestRestGetResourceVersionsBogus checks that a 200 error page from the
+// CDN does not count as the manifest of a published version.
+func TestRestGetResourceVersionsBogus(t *testing.T) {
+	clk := clock.NewFake(start)
+	cdn := pcrd_jp_cdn.New(clk)
+	cdn.Publish(10000010, start)
+	cdn.Inject(pcrd_jp_cdn.Fault{Version: 10000030, Bogus: true})
+
+	got, err := guess(context.Background(), newRest(t, cdn, clk), "10000000")
+	if err != nil || got != "10000010" {
+		t.Fatalf("GetResourceVersions: got %q, %v, want 10000010 despite the error page", got, err)
+	}
+	bogus := 0
+	for _, r := range cdn.Requests() {
+		if r.Bogus {
+			bogus++
+		}
+	}
+	if bogus != 1 {
+		t.Fatalf("got %d error pages, want the one for 10000030", bogus)
+	}
+}
+
+func TestRestHealthCheck(t *testing.T) {
+	clk := clock.NewFake(start)
+
+	if err := newRest(t, pcrd_jp_cdn.New(clk), clk).HealthCheck(context.Background()); err != nil {
+		t.Fatalf("HealthCheck: %v", err)
+	}
+}
+
+// TestRestCassette replays testdata/guess.yaml, recorded against the fake CDN
+// of src/fake. With CASSETTE_MODE=record it records the cassette again.
+func TestRestCassette(t *testing.T) {
+	clk := clock.NewFake(start)
+	mode := cassette.ModeFromEnv()
+	baseURL := "http://pcrd-jp.cassette"
+	if mode == cassette.ModeRecord {
+		cdn := pcrd_jp_cdn.New(clk)
+		cdn.Publish(10000010, start)
+		cdn.Publish(10000030, start)
+		server := httptest.NewServer(cdn)
+		defer server.Close()
+		baseURL = server.URL
+	}
+
+	recorder, err := cassette.New(filepath.Join("testdata", "guess.yaml"), mode, pcrd_jp_repository.CassetteOptions())
+	if err != nil {
+		t.Fatalf("cassette.New: %v", err)
+	}
+	repo := pcrd_jp_repository.NewRestWithTransport(baseURL, clk, recorder)
+
+	got, err := guess(context.Background(), repo, "10000000")
+	if err != nil || got != "10000030" {
+		t.Fatalf("GetResourceVersions: got %q, %v, want 10000030", got, err)
+	}
+
+	if err := recorder.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if err := recorder.Done(); err != nil {
+		t.Fatalf("Done: %v", err)
+	}
+}
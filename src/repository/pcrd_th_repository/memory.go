@@ -0,0 +1,31 @@
+package pcrd_th_repository
+
+import (
+	"context"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/credential"
+	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
+)
+
+type memory struct {
+	resVersion string
+}
+
+func (m memory) GetResourceVersion(ctx context.Context, c credential.Credential, v use_case.PcrdVersion) (string, error) {
+	if len(m.resVersion) <= 0 {
+		use_case.RecordProbe(ctx, use_case.ProbeMiss)
+		return "", use_case.ErrResVerNotAvailable
+	}
+
+	use_case.RecordProbe(ctx, use_case.ProbeHit)
+	return m.resVersion, nil
+}
+
+func (m memory) HealthCheck(ctx context.Context) error {
+	return nil
+}
+
+// NewMemory answers every check with resVersion, or with
+// ErrResVerNotAvailable when it is empty.
+func NewMemory(resVersion string) use_case.PcrdTHRepository {
+	return memory{resVersion: resVersion}
+}
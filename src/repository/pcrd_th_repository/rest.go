@@ -9,8 +9,10 @@ import (
 	"github.com/SpeedxPz/pcrd-version-updater/src/entity/credential"
 	"github.com/SpeedxPz/pcrd-version-updater/src/entity/cryptography"
 	"github.com/SpeedxPz/pcrd-version-updater/src/entity/logger"
+	"github.com/SpeedxPz/pcrd-version-updater/src/repository/cassette"
 	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
 	"github.com/vmihailenco/msgpack/v5"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"go.opentelemetry.io/otel/codes"
 	"go.uber.org/zap"
 	"io/ioutil"
@@ -81,6 +83,7 @@ func (r rest) GetResourceVersion(ctx context.Context, c credential.Credential, v
 
 	result, err := r.call(ctx, c, v, function, param)
 	if err != nil {
+		use_case.RecordProbe(ctx, use_case.ProbeError)
 		span.SetStatus(codes.Error, fmt.Sprintf("call error: %s", err))
 		return "", err
 	}
@@ -89,16 +92,19 @@ func (r rest) GetResourceVersion(ctx context.Context, c credential.Credential, v
 
 	err = json.Unmarshal([]byte(result), &o)
 	if err != nil {
+		use_case.RecordProbe(ctx, use_case.ProbeError)
 		span.SetStatus(codes.Error, fmt.Sprintf("parse result error: %s", err))
 		return "", fmt.Errorf("error while unmashal the response: %w", use_case.ErrDataTransform)
 	}
 
 	if len(o.DataHeaders.RequiredResVer) <= 0 {
+		use_case.RecordProbe(ctx, use_case.ProbeMiss)
 		zap.L().Error("response not contain any version", logger.WithTraceId(ctx), zap.Any("resp", o))
 		span.SetStatus(codes.Error, fmt.Sprintf("remove resource version not available: %s", use_case.ErrResVerNotAvailable))
 		return "", use_case.ErrResVerNotAvailable
 	}
 
+	use_case.RecordProbe(ctx, use_case.ProbeHit)
 	return o.DataHeaders.RequiredResVer, nil
 }
 
@@ -126,7 +132,7 @@ func (r rest) call(ctx context.Context, c credential.Credential, v use_case.Pcrd
 	if len(c.SessionID) > 0 {
 		headers["SID"] = cryptography.MakeMD5(fmt.Sprintf("%s%s", c.SessionID, r.salt))
 	} else {
-		headers["SID"] = cryptography.MakeMD5(fmt.Sprintf("%s%s%s", c.ViewerID, c.Udid, r.salt))
+		headers["SID"] = cryptography.MakeMD5(fmt.Sprintf("%s%s%s", hashedViewerID(c.ViewerID), c.Udid, r.salt))
 	}
 
 	json, err := json.Marshal(param)
@@ -173,10 +179,18 @@ func (r rest) generateParam(c credential.Credential, function string, param inte
 	sEnc := base64.StdEncoding.EncodeToString(bytes)
 
 	pathname := fmt.Sprintf("/%s", function)
-	hash := cryptography.MakeSHA1(fmt.Sprintf("%s%s%s%s", c.Udid, pathname, sEnc, c.ViewerID))
+	hash := cryptography.MakeSHA1(fmt.Sprintf("%s%s%s%s", c.Udid, pathname, sEnc, hashedViewerID(c.ViewerID)))
 	return hash, nil
 }
 
+// hashedViewerID is the viewer ID as SID and PARAM have always hashed it:
+// through %s, which Go prints as %!s(int32=N) for an int32. The server is
+// known to accept these hashes; the decimal form is not verified against
+// it, so the bytes are kept.
+func hashedViewerID(viewerID int32) string {
+	return fmt.Sprintf("%%!s(int32=%d)", viewerID)
+}
+
 func (r rest) defaultHeader() map[string]string {
 	return map[string]string{
 		"User-Agent":           "Dalvik/2.1.0 (Linux; Android 5.1.1; SOV32 Build/32.0.D.0.282; wv)",
@@ -196,19 +210,66 @@ func (r rest) defaultHeader() map[string]string {
 	}
 }
 
+// HealthCheck sends a HEAD to the game server without calling an API, so it
+// needs no credential. Any answer below 500 means the server is reachable.
 func (r rest) HealthCheck(ctx context.Context) error {
+	ctx, span := tracer.Start(ctx, "pcrd_th_repository.HealthCheck")
+	defer span.End()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, r.baseURL, nil)
+	if err != nil {
+		span.SetStatus(codes.Error, fmt.Sprintf("create request failed: %s", err))
+		return fmt.Errorf("create request failed: %s: %w", err, use_case.ErrRetrieveData)
+	}
+
+	res, err := r.client.Do(req)
+	if err != nil {
+		span.SetStatus(codes.Error, fmt.Sprintf("request failed: %s", err))
+		return fmt.Errorf("request failed: %s: %w", err, use_case.ErrRetrieveData)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, fmt.Sprintf("game server answered %d", res.StatusCode))
+		return fmt.Errorf("game server answered %d: %w", res.StatusCode, use_case.ErrRetrieveData)
+	}
+
 	return nil
 }
 
+// CassetteOptions records and replays calls to the game server. The
+// account's UDIDs, its viewer ID and the PARAM and SID hashes made from
+// them are scrubbed before being stored and matched, along with the session
+// and player name the server hands out; TestRestHashes pins the hashes
+// instead.
+func CassetteOptions() cassette.Options {
+	return cassette.Options{
+		MatchHeaders: []string{
+			"APP-VER", "RES-VER", "UDID", "SHORT-UDID", "PARAM", "SID",
+			"DEVICE", "PLATFORM", "BATTLE-LOGIC-VERSION", "Content-Type",
+		},
+		Scrubbers: []cassette.Scrubber{
+			cassette.ScrubHeaders("UDID", "SHORT-UDID", "PARAM", "SID"),
+			cassette.ScrubJSONFields("viewer_id", "now_viewer_id", "short_udid", "sid", "now_name"),
+		},
+	}
+}
+
 func NewRest(baseURL string, salt string) use_case.PcrdTHRepository {
+	return NewRestWithTransport(baseURL, salt, &http.Transport{
+		MaxIdleConns:        10,
+		IdleConnTimeout:     30 * time.Second,
+		DisableCompression:  true,
+		MaxIdleConnsPerHost: 10,
+	})
+}
+
+// NewRestWithTransport calls the game server through transport instead of
+// a pooled http.Transport; tests replay cassettes with it.
+func NewRestWithTransport(baseURL string, salt string, transport http.RoundTripper) use_case.PcrdTHRepository {
 	c := &http.Client{
-		Timeout: 10 * time.Second,
-		Transport: &http.Transport{
-			MaxIdleConns:        10,
-			IdleConnTimeout:     30 * time.Second,
-			DisableCompression:  true,
-			MaxIdleConnsPerHost: 10,
-		},
+		Timeout:   10 * time.Second,
+		Transport: otelhttp.NewTransport(transport),
 	}
 
 	r := &rest{
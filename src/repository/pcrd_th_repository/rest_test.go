@@ -0,0 +1,217 @@
+package pcrd_th_repository_test
+
+import (
+	"context"
+	"errors"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/credential"
+	"github.com/SpeedxPz/pcrd-version-updater/src/fake/pcrd_th_server"
+	"github.com/SpeedxPz/pcrd-version-updater/src/repository/cassette"
+	"github.com/SpeedxPz/pcrd-version-updater/src/repository/pcrd_th_repository"
+	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const salt = "salt"
+
+var (
+	// recordedAt is the fake server's time in the cassette.
+	recordedAt = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	account    = credential.Credential{Udid: "udid", ShortUdid: 123, ViewerID: 456}
+	version    = use_case.PcrdVersion{AppVersion: "3.1.0", ResVersion: "00150000"}
+)
+
+func newRest(t *testing.T, fake *pcrd_th_server.Server, salt string) use_case.PcrdTHRepository {
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	return pcrd_th_repository.NewRest(server.URL, salt)
+}
+
+func TestRestGetResourceVersion(t *testing.T) {
+	ctx := context.Background()
+	fake := pcrd_th_server.New(nil, salt, account)
+	fake.Script(pcrd_th_server.Version("00150010"), pcrd_th_server.Version("00150020"))
+	repo := newRest(t, fake, salt)
+
+	for _, want := range []string{"00150010", "00150020", "00150020"} {
+		got, err := repo.GetResourceVersion(ctx, account, version)
+		if err != nil {
+			t.Fatalf("GetResourceVersion: %v", err)
+		}
+		if got != want {
+			t.Fatalf("GetResourceVersion: got %q, want %q", got, want)
+		}
+	}
+
+	requests := fake.Requests()
+	if len(requests) != 3 || requests[0].AppVersion != "3.1.0" || requests[0].ResVersion != "00150000" || len(requests[0].Rejected) > 0 {
+		t.Fatalf("got requests %+v, want 3 accepted with the client's versions", requests)
+	}
+}
+
+// The PARAM and SID of account's check/game_start with salt, worked out by
+// hand: PARAM is the SHA-1 of the UDID, the path, the base64 of the
+// msgpack body and the viewer ID; SID is the MD5 of the viewer ID, the UDID
+// and the salt. The viewer ID is written "%!s(int32=456)", as the client
+// has always sent it.
+const (
+	accountParam = "4582298058a1bdb6eef210473c06f22612a34ea1"
+	accountSID   = "a401a82eb2688bc5a35d0745eb935f6d"
+)
+
+func TestRestHashes(t *testing.T) {
+	var got http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		w.Write([]byte(`{"data_headers":{"result_code":1,"required_res_ver":"00150010"}}`))
+	}))
+	defer server.Close()
+
+	if _, err := pcrd_th_repository.NewRest(server.URL, salt).GetResourceVersion(context.Background(), account, version); err != nil {
+		t.Fatalf("GetResourceVersion: %v", err)
+	}
+	if got.Get("PARAM") != accountParam || got.Get("SID") != accountSID {
+		t.Fatalf("got PARAM %s and SID %s, want %s and %s", got.Get("PARAM"), got.Get("SID"), accountParam, accountSID)
+	}
+}
+
+func TestRestGetResourceVersionSessionID(t *testing.T) {
+	withSession := account
+	withSession.SessionID = "session"
+	fake := pcrd_th_server.New(nil, salt, withSession)
+	fake.Script(pcrd_th_server.Version("00150010"))
+
+	if _, err := newRest(t, fake, salt).GetResourceVersion(context.Background(), withSession, version); err != nil {
+		t.Fatalf("GetResourceVersion: %v", err)
+	}
+}
+
+func TestRestGetResourceVersionNotAvailable(t *testing.T) {
+	for name, response := range map[string]pcrd_th_server.Response{
+		"maintenance": pcrd_th_server.Maintenance(),
+		"result code": {ResultCode: 204},
+	} {
+		t.Run(name, func(t *testing.T) {
+			fake := pcrd_th_server.New(nil, salt, account)
+			fake.Script(response)
+
+			_, err := newRest(t, fake, salt).GetResourceVersion(context.Background(), account, version)
+			if !errors.Is(err, use_case.ErrResVerNotAvailable) {
+				t.Fatalf("GetResourceVersion: got %v, want ErrResVerNotAvailable", err)
+			}
+		})
+	}
+}
+
+func TestRestGetResourceVersionRejected(t *testing.T) {
+	for name, tc := range map[string]struct {
+		salt     string
+		account  credential.Credential
+		rejected string
+	}{
+		"wrong salt":      {salt: "other", account: account, rejected: "SID"},
+		"unknown udid":    {salt: salt, account: credential.Credential{Udid: "other", ShortUdid: 123, ViewerID: 456}, rejected: "unknown udid"},
+		"wrong viewer id": {salt: salt, account: credential.Credential{Udid: "udid", ShortUdid: 123, ViewerID: 789}, rejected: "viewer id"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			fake := pcrd_th_server.New(nil, salt, account)
+			fake.Script(pcrd_th_server.Version("00150010"))
+
+			_, err := newRest(t, fake, tc.salt).GetResourceVersion(context.Background(), tc.account, version)
+			if !errors.Is(err, use_case.ErrResVerNotAvailable) {
+				t.Fatalf("GetResourceVersion: got %v, want ErrResVerNotAvailable", err)
+			}
+			requests := fake.Requests()
+			if len(requests) != 1 || !strings.Contains(requests[0].Rejected, tc.rejected) {
+				t.Fatalf("got requests %+v, want one rejected for %s", requests, tc.rejected)
+			}
+		})
+	}
+}
+
+func TestRestHealthCheck(t *testing.T) {
+	fake := pcrd_th_server.New(nil, salt)
+	if err := newRest(t, fake, salt).HealthCheck(context.Background()); err != nil {
+		t.Fatalf("HealthCheck: %v", err)
+	}
+}
+
+// TestRestCassetteScrubbed records a session for an account whose values
+// stand out, and checks none of them, nor the hashes made from them, reach
+// the cassette.
+func TestRestCassetteScrubbed(t *testing.T) {
+	secret := credential.Credential{Udid: "5e1c7a0d-udid", ShortUdid: 918273, ViewerID: 7364519}
+	fake := pcrd_th_server.New(nil, salt, secret)
+	fake.Script(pcrd_th_server.Version("00150010"))
+	var sent http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sent = r.Header.Clone()
+		fake.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "cassette.yaml")
+	recorder, err := cassette.New(path, cassette.ModeRecord, pcrd_th_repository.CassetteOptions())
+	if err != nil {
+		t.Fatalf("cassette.New: %v", err)
+	}
+	if _, err := pcrd_th_repository.NewRestWithTransport(server.URL, salt, recorder).GetResourceVersion(context.Background(), secret, version); err != nil {
+		t.Fatalf("GetResourceVersion: %v", err)
+	}
+	if err := recorder.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	for _, value := range []string{secret.Udid, "918273", "7364519", sent.Get("SID"), sent.Get("PARAM")} {
+		if strings.Contains(string(data), value) {
+			t.Fatalf("cassette contains %q:\n%s", value, data)
+		}
+	}
+}
+
+// TestRestCassette replays testdata/check_game_start.yaml, which was
+// recorded against the fake server of src/fake with account and salt, not
+// captured from the real one. With CASSETTE_MODE=record it records the
+// cassette again.
+func TestRestCassette(t *testing.T) {
+	mode := cassette.ModeFromEnv()
+	baseURL := "http://pcrd-th.cassette"
+	if mode == cassette.ModeRecord {
+		fake := pcrd_th_server.New(func() time.Time { return recordedAt }, salt, account)
+		fake.Script(pcrd_th_server.Version("00150010"), pcrd_th_server.Maintenance())
+		server := httptest.NewServer(fake)
+		defer server.Close()
+		baseURL = server.URL
+	}
+
+	recorder, err := cassette.New(filepath.Join("testdata", "check_game_start.yaml"), mode, pcrd_th_repository.CassetteOptions())
+	if err != nil {
+		t.Fatalf("cassette.New: %v", err)
+	}
+	repo := pcrd_th_repository.NewRestWithTransport(baseURL, salt, recorder)
+
+	ctx := context.Background()
+	got, err := repo.GetResourceVersion(ctx, account, version)
+	if err != nil || got != "00150010" {
+		t.Fatalf("GetResourceVersion: got %q, %v, want 00150010", got, err)
+	}
+	if _, err := repo.GetResourceVersion(ctx, account, use_case.PcrdVersion{AppVersion: "3.1.0", ResVersion: got}); !errors.Is(err, use_case.ErrResVerNotAvailable) {
+		t.Fatalf("GetResourceVersion under maintenance: got %v, want ErrResVerNotAvailable", err)
+	}
+
+	if err := recorder.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if err := recorder.Done(); err != nil {
+		t.Fatalf("Done: %v", err)
+	}
+}
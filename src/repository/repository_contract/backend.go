@@ -0,0 +1,150 @@
+package repository_contract
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/SpeedxPz/pcrd-version-updater/src/repository/sql_schema"
+	_ "github.com/lib/pq"
+	"go.etcd.io/bbolt"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	_ "modernc.org/sqlite"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// MongoTestURIEnv names the variable pointing at a local mongod. Mongo
+// suites are skipped when it is not set.
+const MongoTestURIEnv = "MONGO_TEST_URI"
+
+// PostgresTestDSNEnv names the variable pointing at a local PostgreSQL, as a
+// postgres:// URL. Postgres suites are skipped when it is not set.
+const PostgresTestDSNEnv = "POSTGRES_TEST_DSN"
+
+var databaseSeq int64
+
+func databaseName() string {
+	return fmt.Sprintf("contract_%d_%d", time.Now().UnixNano(), atomic.AddInt64(&databaseSeq, 1))
+}
+
+// NewMongoDatabase returns an empty database that is dropped when t ends.
+func NewMongoDatabase(t *testing.T) *mongo.Database {
+	t.Helper()
+
+	uri := os.Getenv(MongoTestURIEnv)
+	if len(uri) <= 0 {
+		t.Skipf("%s is not set", MongoTestURIEnv)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatalf("mongo.Connect: %v", err)
+	}
+
+	db := client.Database(databaseName())
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		db.Drop(ctx)
+		client.Disconnect(ctx)
+	})
+
+	return db
+}
+
+// NewBoltDB returns an empty bolt file that is closed when t ends.
+func NewBoltDB(t *testing.T) *bbolt.DB {
+	t.Helper()
+
+	db, err := bbolt.Open(filepath.Join(t.TempDir(), "contract.db"), 0600, nil)
+	if err != nil {
+		t.Fatalf("bbolt.Open: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+	})
+
+	return db
+}
+
+// NewSQLiteDB returns a migrated, empty SQLite database that is closed when
+// t ends.
+func NewSQLiteDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "contract.sqlite"))
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+	})
+	// SQLite allows one writer; concurrent cases would see SQLITE_BUSY
+	// instead of the errors under test.
+	db.SetMaxOpenConns(1)
+
+	err = sql_schema.Migrate(context.Background(), db, sql_schema.DialectSQLite)
+	if err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+
+	return db
+}
+
+// NewPostgresDB returns a migrated, empty schema that is dropped when t ends.
+func NewPostgresDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	dsn := os.Getenv(PostgresTestDSNEnv)
+	if len(dsn) <= 0 {
+		t.Skipf("%s is not set", PostgresTestDSNEnv)
+	}
+
+	ctx := context.Background()
+	admin, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	t.Cleanup(func() {
+		admin.Close()
+	})
+
+	schema := databaseName()
+	if _, err := admin.ExecContext(ctx, "CREATE SCHEMA "+schema); err != nil {
+		t.Fatalf("create schema: %v", err)
+	}
+	t.Cleanup(func() {
+		admin.ExecContext(ctx, "DROP SCHEMA "+schema+" CASCADE")
+	})
+
+	u, err := url.Parse(dsn)
+	if err != nil {
+		t.Fatalf("parse %s: %v", PostgresTestDSNEnv, err)
+	}
+	q := u.Query()
+	q.Set("search_path", schema)
+	u.RawQuery = q.Encode()
+
+	db, err := sql.Open("postgres", u.String())
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+	})
+
+	err = sql_schema.Migrate(ctx, db, sql_schema.DialectPostgres)
+	if err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+
+	return db
+}
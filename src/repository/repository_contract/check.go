@@ -0,0 +1,141 @@
+package repository_contract
+
+import (
+	"context"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/setting"
+	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func checkRecord(ID string, settingID string, outcome use_case.RunOutcome, startedAt time.Time) use_case.CheckRecord {
+	return use_case.CheckRecord{
+		ID:         ID,
+		SettingID:  settingID,
+		ServerCode: setting.ServerCodeJP,
+		Outcome:    outcome,
+		Before:     &use_case.PcrdVersion{AppVersion: "6.0.0", ResVersion: "10000010"},
+		After:      &use_case.PcrdVersion{AppVersion: "6.0.0", ResVersion: "10000020"},
+		Probes:     use_case.ProbeCounts{Sent: 19, Hits: 1, Errors: 2},
+		TraceID:    "4bf92f3577b34da6a3ce929d0e0e4736",
+		StartedAt:  startedAt,
+		FinishedAt: startedAt.Add(20 * time.Second),
+	}
+}
+
+// CheckRepository runs the contract against a fresh, empty repository
+// returned by newRepo for every case.
+func CheckRepository(t *testing.T, newRepo func(t *testing.T) use_case.CheckRepository) {
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	t.Run("create then list round-trips every field", func(t *testing.T) {
+		repo := newRepo(t)
+
+		want := checkRecord("a", "jp.app", use_case.RunUpdated, now)
+		want.ExpiresAt = now.Add(time.Hour)
+		failed := checkRecord("b", "jp.app", use_case.RunFailed, now.Add(time.Minute))
+		failed.FailureClass = use_case.FailureRemote
+		failed.Failure = "guessing stopped"
+		failed.Before, failed.After = nil, nil
+		for _, c := range []use_case.CheckRecord{want, failed} {
+			if err := repo.CreateCheck(ctx, c); err != nil {
+				t.Fatalf("CreateCheck: %v", err)
+			}
+		}
+
+		got, err := repo.ListChecks(ctx, use_case.CheckQuery{})
+		if err != nil {
+			t.Fatalf("ListChecks: %v", err)
+		}
+		if len(got) != 2 {
+			t.Fatalf("ListChecks: got %d checks, want 2", len(got))
+		}
+		for i, c := range []use_case.CheckRecord{failed, want} {
+			if !got[i].StartedAt.Equal(c.StartedAt) || !got[i].FinishedAt.Equal(c.FinishedAt) || !got[i].ExpiresAt.Equal(c.ExpiresAt) {
+				t.Fatalf("ListChecks: got times %+v, want %+v", got[i], c)
+			}
+			got[i].StartedAt, got[i].FinishedAt, got[i].ExpiresAt = c.StartedAt, c.FinishedAt, c.ExpiresAt
+			if !reflect.DeepEqual(got[i], c) {
+				t.Fatalf("ListChecks: got %+v, want %+v", got[i], c)
+			}
+		}
+	})
+
+	t.Run("list filters newest first", func(t *testing.T) {
+		repo := newRepo(t)
+
+		for _, c := range []use_case.CheckRecord{
+			checkRecord("a", "jp.app", use_case.RunUnchanged, now),
+			checkRecord("b", "th.app", use_case.RunUnchanged, now.Add(time.Minute)),
+			checkRecord("c", "jp.app", use_case.RunFailed, now.Add(2*time.Minute)),
+			checkRecord("d", "jp.app", use_case.RunUnchanged, now.Add(3*time.Minute)),
+		} {
+			if err := repo.CreateCheck(ctx, c); err != nil {
+				t.Fatalf("CreateCheck: %v", err)
+			}
+		}
+
+		for _, tc := range []struct {
+			name  string
+			query use_case.CheckQuery
+			want  []string
+		}{
+			{"setting", use_case.CheckQuery{SettingID: "jp.app"}, []string{"d", "c", "a"}},
+			{"outcome", use_case.CheckQuery{Outcome: use_case.RunUnchanged}, []string{"d", "b", "a"}},
+			{"range", use_case.CheckQuery{From: now.Add(time.Minute), To: now.Add(3 * time.Minute)}, []string{"c", "b"}},
+			{"limit", use_case.CheckQuery{SettingID: "jp.app", Limit: 2}, []string{"d", "c"}},
+		} {
+			got, err := repo.ListChecks(ctx, tc.query)
+			if err != nil {
+				t.Fatalf("%s: ListChecks: %v", tc.name, err)
+			}
+			ids := make([]string, len(got))
+			for i := range got {
+				ids[i] = got[i].ID
+			}
+			if !reflect.DeepEqual(ids, tc.want) {
+				t.Fatalf("%s: ListChecks: got %v, want %v", tc.name, ids, tc.want)
+			}
+		}
+	})
+
+	t.Run("delete expired", func(t *testing.T) {
+		repo := newRepo(t)
+
+		expiredCheck := checkRecord("a", "jp.app", use_case.RunUnchanged, now)
+		expiredCheck.ExpiresAt = now.Add(-time.Second)
+		liveCheck := checkRecord("b", "jp.app", use_case.RunUnchanged, now)
+		liveCheck.ExpiresAt = now.Add(time.Hour)
+		keptCheck := checkRecord("c", "jp.app", use_case.RunUnchanged, now)
+		for _, c := range []use_case.CheckRecord{expiredCheck, liveCheck, keptCheck} {
+			if err := repo.CreateCheck(ctx, c); err != nil {
+				t.Fatalf("CreateCheck: %v", err)
+			}
+		}
+
+		deleted, err := repo.DeleteExpiredChecks(ctx, now)
+		if err != nil {
+			t.Fatalf("DeleteExpiredChecks: %v", err)
+		}
+		if deleted != 1 {
+			t.Fatalf("DeleteExpiredChecks: got %d, want 1", deleted)
+		}
+		got, err := repo.ListChecks(ctx, use_case.CheckQuery{})
+		if err != nil {
+			t.Fatalf("ListChecks: %v", err)
+		}
+		if len(got) != 2 {
+			t.Fatalf("ListChecks: got %+v, want b and c", got)
+		}
+	})
+
+	t.Run("health check", func(t *testing.T) {
+		repo := newRepo(t)
+
+		if err := repo.HealthCheck(ctx); err != nil {
+			t.Fatalf("HealthCheck: %v", err)
+		}
+	})
+}
@@ -0,0 +1,83 @@
+package repository_contract
+
+import (
+	"context"
+	"errors"
+	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
+	"testing"
+	"time"
+)
+
+// CheckStateRepository runs the contract against a fresh, empty repository
+// returned by newRepo for every case.
+func CheckStateRepository(t *testing.T, newRepo func(t *testing.T) use_case.CheckStateRepository) {
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	t.Run("get missing", func(t *testing.T) {
+		repo := newRepo(t)
+
+		_, err := repo.GetCheckState(ctx, "a")
+		if !errors.Is(err, use_case.ErrCheckStateNotFound) {
+			t.Fatalf("GetCheckState: got %v, want %v", err, use_case.ErrCheckStateNotFound)
+		}
+	})
+
+	t.Run("save then get", func(t *testing.T) {
+		repo := newRepo(t)
+
+		want := use_case.CheckState{
+			ID:                  "a",
+			ConsecutiveFailures: 3,
+			LastFailureClass:    use_case.FailureRemote,
+			LastCheckedAt:       now,
+			LastSuccessAt:       now.Add(-time.Hour),
+			LastChangedAt:       now.Add(-24 * time.Hour),
+			Alerts:              []use_case.AlertType{use_case.AlertRepeatedFailure, use_case.AlertStale},
+		}
+		if err := repo.SaveCheckState(ctx, want); err != nil {
+			t.Fatalf("SaveCheckState: %v", err)
+		}
+
+		got, err := repo.GetCheckState(ctx, "a")
+		if err != nil {
+			t.Fatalf("GetCheckState: %v", err)
+		}
+		if got.ID != want.ID || got.ConsecutiveFailures != want.ConsecutiveFailures || got.LastFailureClass != want.LastFailureClass ||
+			!got.LastCheckedAt.Equal(want.LastCheckedAt) || !got.LastSuccessAt.Equal(want.LastSuccessAt) || !got.LastChangedAt.Equal(want.LastChangedAt) ||
+			len(got.Alerts) != 2 || got.Alerts[0] != want.Alerts[0] || got.Alerts[1] != want.Alerts[1] {
+			t.Fatalf("GetCheckState: got %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("save replaces and keeps zero times", func(t *testing.T) {
+		repo := newRepo(t)
+
+		s := use_case.CheckState{ID: "a", ConsecutiveFailures: 1, LastCheckedAt: now, LastChangedAt: now,
+			Alerts: []use_case.AlertType{use_case.AlertStale}}
+		if err := repo.SaveCheckState(ctx, s); err != nil {
+			t.Fatalf("SaveCheckState: %v", err)
+		}
+		s.ConsecutiveFailures = 2
+		s.Alerts = nil
+		if err := repo.SaveCheckState(ctx, s); err != nil {
+			t.Fatalf("SaveCheckState: %v", err)
+		}
+
+		got, err := repo.GetCheckState(ctx, "a")
+		if err != nil {
+			t.Fatalf("GetCheckState: %v", err)
+		}
+		if got.ConsecutiveFailures != 2 || len(got.Alerts) != 0 || !got.LastSuccessAt.IsZero() {
+			t.Fatalf("GetCheckState: got %+v", got)
+		}
+	})
+
+	t.Run("health check", func(t *testing.T) {
+		repo := newRepo(t)
+
+		if err := repo.HealthCheck(ctx); err != nil {
+			t.Fatalf("HealthCheck: %v", err)
+		}
+	})
+}
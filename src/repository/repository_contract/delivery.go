@@ -0,0 +1,109 @@
+package repository_contract
+
+import (
+	"context"
+	"errors"
+	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
+	"testing"
+	"time"
+)
+
+func failedDelivery(ID string, firstFailedAt time.Time) use_case.FailedDelivery {
+	return use_case.FailedDelivery{
+		ID:            ID,
+		URL:           "https://example.com/hooks/" + ID,
+		EventID:       "event-" + ID,
+		Body:          []byte(`{"id":"` + ID + `"}`),
+		Attempts:      1,
+		LastError:     "503 Service Unavailable",
+		FirstFailedAt: firstFailedAt,
+		LastAttemptAt: firstFailedAt,
+	}
+}
+
+// DeliveryRepository runs the contract against a fresh, empty repository
+// returned by newRepo for every case.
+func DeliveryRepository(t *testing.T, newRepo func(t *testing.T) use_case.DeliveryRepository) {
+	ctx := context.Background()
+	// Truncated so every backend round-trips it exactly.
+	now := time.Now().UTC().Truncate(time.Second)
+
+	t.Run("save then list oldest first", func(t *testing.T) {
+		repo := newRepo(t)
+
+		for _, d := range []use_case.FailedDelivery{
+			failedDelivery("b", now.Add(time.Minute)),
+			failedDelivery("a", now),
+		} {
+			if err := repo.SaveFailedDelivery(ctx, d); err != nil {
+				t.Fatalf("SaveFailedDelivery: %v", err)
+			}
+		}
+
+		got, err := repo.ListFailedDeliveries(ctx)
+		if err != nil {
+			t.Fatalf("ListFailedDeliveries: %v", err)
+		}
+		if len(got) != 2 || got[0].ID != "a" || got[1].ID != "b" {
+			t.Fatalf("ListFailedDeliveries: got %+v, want a then b", got)
+		}
+		if string(got[0].Body) != `{"id":"a"}` || !got[0].FirstFailedAt.Equal(now) {
+			t.Fatalf("ListFailedDeliveries: got %+v", got[0])
+		}
+	})
+
+	t.Run("save replaces attempts of an existing delivery", func(t *testing.T) {
+		repo := newRepo(t)
+
+		d := failedDelivery("a", now)
+		if err := repo.SaveFailedDelivery(ctx, d); err != nil {
+			t.Fatalf("SaveFailedDelivery: %v", err)
+		}
+		d.Attempts = 6
+		d.LastError = "connection refused"
+		d.LastAttemptAt = now.Add(time.Hour)
+		if err := repo.SaveFailedDelivery(ctx, d); err != nil {
+			t.Fatalf("SaveFailedDelivery: %v", err)
+		}
+
+		got, err := repo.ListFailedDeliveries(ctx)
+		if err != nil {
+			t.Fatalf("ListFailedDeliveries: %v", err)
+		}
+		if len(got) != 1 || got[0].Attempts != 6 || got[0].LastError != "connection refused" || !got[0].LastAttemptAt.Equal(d.LastAttemptAt) {
+			t.Fatalf("ListFailedDeliveries: got %+v", got)
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		repo := newRepo(t)
+
+		if err := repo.SaveFailedDelivery(ctx, failedDelivery("a", now)); err != nil {
+			t.Fatalf("SaveFailedDelivery: %v", err)
+		}
+		if err := repo.DeleteFailedDelivery(ctx, "a"); err != nil {
+			t.Fatalf("DeleteFailedDelivery: %v", err)
+		}
+
+		got, err := repo.ListFailedDeliveries(ctx)
+		if err != nil {
+			t.Fatalf("ListFailedDeliveries: %v", err)
+		}
+		if len(got) != 0 {
+			t.Fatalf("ListFailedDeliveries: got %+v, want none", got)
+		}
+
+		err = repo.DeleteFailedDelivery(ctx, "a")
+		if !errors.Is(err, use_case.ErrDeliveryNotFound) {
+			t.Fatalf("DeleteFailedDelivery: got %v, want %v", err, use_case.ErrDeliveryNotFound)
+		}
+	})
+
+	t.Run("health check", func(t *testing.T) {
+		repo := newRepo(t)
+
+		if err := repo.HealthCheck(ctx); err != nil {
+			t.Fatalf("HealthCheck: %v", err)
+		}
+	})
+}
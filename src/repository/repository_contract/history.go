@@ -0,0 +1,136 @@
+package repository_contract
+
+import (
+	"context"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/clock"
+	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// HistoryRepository runs the contract against a fresh, empty repository
+// returned by newRepo for every case. The repository must stamp entries with
+// clk, which the contract moves forward between writes.
+func HistoryRepository(t *testing.T, newRepo func(t *testing.T, clk clock.Clock) use_case.HistoryRepository) {
+	ctx := context.Background()
+
+	t.Run("create appends entries for the same id", func(t *testing.T) {
+		repo := newRepo(t, clock.NewFake(time.Unix(1600000000, 0)))
+
+		for _, v := range []string{"10", "20", "20"} {
+			if err := repo.Create(ctx, gameVersion("th.app", "1.0.0", v)); err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+		}
+
+		histories, err := repo.ListHistories(ctx, "th.app", time.Time{}, time.Time{})
+		if err != nil {
+			t.Fatalf("ListHistories: %v", err)
+		}
+		var got []use_case.GameVersion
+		for _, h := range histories {
+			got = append(got, h.Version)
+		}
+		want := []use_case.GameVersion{
+			gameVersion("th.app", "1.0.0", "10"),
+			gameVersion("th.app", "1.0.0", "20"),
+			gameVersion("th.app", "1.0.0", "20"),
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("ListHistories: got %+v, want every entry kept, duplicates included", got)
+		}
+	})
+
+	t.Run("list within a time range, oldest first", func(t *testing.T) {
+		start := time.Unix(1600000000, 0)
+		clk := clock.NewFake(start)
+		repo := newRepo(t, clk)
+
+		if err := repo.Create(ctx, gameVersion("th.app", "1.0.0", "10")); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		clk.Advance(time.Minute)
+		mid := clk.Now()
+		clk.Advance(time.Minute)
+		for _, v := range []string{"20", "30"} {
+			if err := repo.Create(ctx, gameVersion("th.app", "1.0.0", v)); err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+		}
+
+		for _, c := range []struct {
+			from time.Time
+			to   time.Time
+			want []string
+		}{
+			{time.Time{}, time.Time{}, []string{"10", "20", "30"}},
+			{mid, time.Time{}, []string{"20", "30"}},
+			{time.Time{}, mid, []string{"10"}},
+			{mid.Add(time.Hour), time.Time{}, nil},
+		} {
+			histories, err := repo.ListHistories(ctx, "", c.from, c.to)
+			if err != nil {
+				t.Fatalf("ListHistories: %v", err)
+			}
+			var got []string
+			for _, h := range histories {
+				got = append(got, h.Version.ResVersion)
+				if h.CreatedAt.IsZero() || h.Version.Setting.ID != "th.app" {
+					t.Fatalf("ListHistories: got %+v", h)
+				}
+			}
+			if !reflect.DeepEqual(got, c.want) {
+				t.Fatalf("ListHistories(%v, %v): got %v, want %v", c.from, c.to, got, c.want)
+			}
+		}
+
+		histories, err := repo.ListHistories(ctx, "", time.Time{}, mid)
+		if err != nil {
+			t.Fatalf("ListHistories: %v", err)
+		}
+		if !histories[0].CreatedAt.Equal(start) {
+			t.Fatalf("CreatedAt: got %v, want the clock's %v", histories[0].CreatedAt, start)
+		}
+	})
+
+	t.Run("list only the entries of one id", func(t *testing.T) {
+		repo := newRepo(t, clock.NewFake(time.Unix(1600000000, 0)))
+
+		for _, v := range []struct{ id, res string }{{"th.app", "10"}, {"jp.app", "11"}, {"th.app", "20"}} {
+			if err := repo.Create(ctx, gameVersion(v.id, "1.0.0", v.res)); err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+		}
+
+		for id, want := range map[string][]string{
+			"th.app":  {"10", "20"},
+			"jp.app":  {"11"},
+			"unknown": nil,
+			"":        {"10", "11", "20"},
+		} {
+			histories, err := repo.ListHistories(ctx, id, time.Time{}, time.Time{})
+			if err != nil {
+				t.Fatalf("ListHistories: %v", err)
+			}
+			var got []string
+			for _, h := range histories {
+				got = append(got, h.Version.ResVersion)
+				if len(id) > 0 && h.Version.Setting.ID != id {
+					t.Fatalf("ListHistories(%q): got %+v", id, h)
+				}
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("ListHistories(%q): got %v, want %v", id, got, want)
+			}
+		}
+	})
+
+	t.Run("health check", func(t *testing.T) {
+		repo := newRepo(t, clock.Real())
+
+		if err := repo.HealthCheck(ctx); err != nil {
+			t.Fatalf("HealthCheck: %v", err)
+		}
+	})
+}
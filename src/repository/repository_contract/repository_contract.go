@@ -0,0 +1,45 @@
+// Package repository_contract holds the behaviour every storage backend of
+// the use case repositories must share. Each backend runs these suites from
+// its own tests.
+package repository_contract
+
+import (
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/credential"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/setting"
+	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
+)
+
+func thSetting(ID string) use_case.PCRDSetting {
+	return use_case.PCRDSetting{
+		Setting: setting.Setting{
+			ID:         ID,
+			ServerCode: setting.ServerCodeTH,
+		},
+		Credential: credential.Credential{
+			Udid:      "f7b4c3e2-0000-4000-8000-" + ID,
+			ShortUdid: 123456,
+			ViewerID:  987654,
+		},
+	}
+}
+
+func jpSetting(ID string) use_case.PCRDSetting {
+	return use_case.PCRDSetting{
+		Setting: setting.Setting{
+			ID:         ID,
+			ServerCode: setting.ServerCodeJP,
+		},
+		GuessStartVersion: "10020800",
+	}
+}
+
+func gameVersion(ID string, appVersion string, resVersion string) use_case.GameVersion {
+	return use_case.GameVersion{
+		Setting: setting.Setting{
+			ID:         ID,
+			ServerCode: setting.ServerCodeTH,
+		},
+		AppVersion: appVersion,
+		ResVersion: resVersion,
+	}
+}
@@ -0,0 +1,208 @@
+package repository_contract
+
+import (
+	"context"
+	"errors"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/platform"
+	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// SettingRepository runs the contract against a fresh, empty repository
+// returned by newRepo for every case.
+func SettingRepository(t *testing.T, newRepo func(t *testing.T) use_case.SettingRepository) {
+	ctx := context.Background()
+
+	t.Run("get missing returns ErrSettingNotExists", func(t *testing.T) {
+		repo := newRepo(t)
+
+		_, err := repo.GetSettingByID(ctx, "missing")
+		if !errors.Is(err, use_case.ErrSettingNotExists) {
+			t.Fatalf("GetSettingByID: got %v, want %v", err, use_case.ErrSettingNotExists)
+		}
+	})
+
+	t.Run("create then get returns the same setting", func(t *testing.T) {
+		repo := newRepo(t)
+		want := thSetting("th.app")
+
+		if err := repo.CreateSetting(ctx, want); err != nil {
+			t.Fatalf("CreateSetting: %v", err)
+		}
+
+		got, err := repo.GetSettingByID(ctx, want.Setting.ID)
+		if err != nil {
+			t.Fatalf("GetSettingByID: %v", err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("GetSettingByID: got %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("create duplicate returns ErrSettingAlreadyExists", func(t *testing.T) {
+		repo := newRepo(t)
+
+		if err := repo.CreateSetting(ctx, thSetting("th.app")); err != nil {
+			t.Fatalf("CreateSetting: %v", err)
+		}
+
+		err := repo.CreateSetting(ctx, thSetting("th.app"))
+		if !errors.Is(err, use_case.ErrSettingAlreadyExists) {
+			t.Fatalf("CreateSetting: got %v, want %v", err, use_case.ErrSettingAlreadyExists)
+		}
+	})
+
+	t.Run("concurrent creates of one id let only one through", func(t *testing.T) {
+		repo := newRepo(t)
+
+		errs := make(chan error, 8)
+		var wg sync.WaitGroup
+		for i := 0; i < cap(errs); i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs <- repo.CreateSetting(ctx, thSetting("th.app"))
+			}()
+		}
+		wg.Wait()
+		close(errs)
+
+		created := 0
+		for err := range errs {
+			switch {
+			case err == nil:
+				created++
+			case !errors.Is(err, use_case.ErrSettingAlreadyExists):
+				t.Fatalf("CreateSetting: got %v, want %v", err, use_case.ErrSettingAlreadyExists)
+			}
+		}
+		if created != 1 {
+			t.Fatalf("CreateSetting: %d creates succeeded, want 1", created)
+		}
+	})
+
+	t.Run("list returns settings ordered by id", func(t *testing.T) {
+		repo := newRepo(t)
+
+		got, err := repo.ListSettings(ctx)
+		if err != nil {
+			t.Fatalf("ListSettings: %v", err)
+		}
+		if len(got) != 0 {
+			t.Fatalf("ListSettings: got %d settings on an empty repository", len(got))
+		}
+
+		want := []use_case.PCRDSetting{jpSetting("a.jp.app"), thSetting("b.th.app"), jpSetting("c.jp.app")}
+		for _, i := range []int{2, 0, 1} {
+			if err := repo.CreateSetting(ctx, want[i]); err != nil {
+				t.Fatalf("CreateSetting: %v", err)
+			}
+		}
+
+		got, err = repo.ListSettings(ctx)
+		if err != nil {
+			t.Fatalf("ListSettings: %v", err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("ListSettings: got %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("update replaces the setting", func(t *testing.T) {
+		repo := newRepo(t)
+		want := thSetting("th.app")
+
+		if err := repo.CreateSetting(ctx, want); err != nil {
+			t.Fatalf("CreateSetting: %v", err)
+		}
+
+		want.Credential.ViewerID = 111
+		want.Credential.Udid = "rotated"
+		if err := repo.UpdateSetting(ctx, want); err != nil {
+			t.Fatalf("UpdateSetting: %v", err)
+		}
+
+		got, err := repo.GetSettingByID(ctx, want.Setting.ID)
+		if err != nil {
+			t.Fatalf("GetSettingByID: %v", err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("GetSettingByID: got %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("jp platforms and locales are kept", func(t *testing.T) {
+		repo := newRepo(t)
+		want := jpSetting("jp.app")
+		want.Platforms = []platform.PlatformType{platform.PlatformTypeIOS, platform.PlatformTypeAndroid}
+		want.Locales = []string{"Jpn"}
+
+		if err := repo.CreateSetting(ctx, want); err != nil {
+			t.Fatalf("CreateSetting: %v", err)
+		}
+		got, err := repo.GetSettingByID(ctx, want.Setting.ID)
+		if err != nil {
+			t.Fatalf("GetSettingByID: %v", err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("GetSettingByID: got %+v, want %+v", got, want)
+		}
+
+		want.Platforms = append(want.Platforms, platform.PlatformTypeWindows)
+		want.Locales = nil
+		if err := repo.UpdateSetting(ctx, want); err != nil {
+			t.Fatalf("UpdateSetting: %v", err)
+		}
+		got, err = repo.GetSettingByID(ctx, want.Setting.ID)
+		if err != nil {
+			t.Fatalf("GetSettingByID: %v", err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("GetSettingByID: got %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("update missing returns ErrSettingNotExists", func(t *testing.T) {
+		repo := newRepo(t)
+
+		err := repo.UpdateSetting(ctx, thSetting("missing"))
+		if !errors.Is(err, use_case.ErrSettingNotExists) {
+			t.Fatalf("UpdateSetting: got %v, want %v", err, use_case.ErrSettingNotExists)
+		}
+	})
+
+	t.Run("delete removes the setting", func(t *testing.T) {
+		repo := newRepo(t)
+
+		if err := repo.CreateSetting(ctx, jpSetting("jp.app")); err != nil {
+			t.Fatalf("CreateSetting: %v", err)
+		}
+		if err := repo.DeleteSettingByID(ctx, "jp.app"); err != nil {
+			t.Fatalf("DeleteSettingByID: %v", err)
+		}
+
+		_, err := repo.GetSettingByID(ctx, "jp.app")
+		if !errors.Is(err, use_case.ErrSettingNotExists) {
+			t.Fatalf("GetSettingByID: got %v, want %v", err, use_case.ErrSettingNotExists)
+		}
+	})
+
+	t.Run("delete missing returns ErrSettingNotExists", func(t *testing.T) {
+		repo := newRepo(t)
+
+		err := repo.DeleteSettingByID(ctx, "missing")
+		if !errors.Is(err, use_case.ErrSettingNotExists) {
+			t.Fatalf("DeleteSettingByID: got %v, want %v", err, use_case.ErrSettingNotExists)
+		}
+	})
+
+	t.Run("health check", func(t *testing.T) {
+		repo := newRepo(t)
+
+		if err := repo.HealthCheck(ctx); err != nil {
+			t.Fatalf("HealthCheck: %v", err)
+		}
+	})
+}
@@ -0,0 +1,159 @@
+package repository_contract
+
+import (
+	"context"
+	"errors"
+	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
+	"reflect"
+	"testing"
+)
+
+// VersionRepository runs the contract against a fresh, empty repository
+// returned by newRepo for every case.
+func VersionRepository(t *testing.T, newRepo func(t *testing.T) use_case.VersionRepository) {
+	ctx := context.Background()
+
+	t.Run("get missing returns ErrVersionNotFound", func(t *testing.T) {
+		repo := newRepo(t)
+
+		_, err := repo.GetByID(ctx, "missing")
+		if !errors.Is(err, use_case.ErrVersionNotFound) {
+			t.Fatalf("GetByID: got %v, want %v", err, use_case.ErrVersionNotFound)
+		}
+	})
+
+	t.Run("get without id returns ErrMissingAppID", func(t *testing.T) {
+		repo := newRepo(t)
+
+		_, err := repo.GetByID(ctx, "")
+		if !errors.Is(err, use_case.ErrMissingAppID) {
+			t.Fatalf("GetByID: got %v, want %v", err, use_case.ErrMissingAppID)
+		}
+	})
+
+	t.Run("create then get returns the same version", func(t *testing.T) {
+		repo := newRepo(t)
+		want := gameVersion("th.app", "1.2.0", "")
+
+		if err := repo.Create(ctx, want); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+
+		got, err := repo.GetByID(ctx, want.Setting.ID)
+		if err != nil {
+			t.Fatalf("GetByID: %v", err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("GetByID: got %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("update changes app and resource version", func(t *testing.T) {
+		repo := newRepo(t)
+
+		if err := repo.Create(ctx, gameVersion("th.app", "1.2.0", "")); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		if err := repo.Create(ctx, gameVersion("other.app", "1.0.0", "10")); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+
+		want := gameVersion("th.app", "1.3.0", "00150010")
+		if err := repo.Update(ctx, want); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+
+		got, err := repo.GetByID(ctx, want.Setting.ID)
+		if err != nil {
+			t.Fatalf("GetByID: %v", err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("GetByID: got %+v, want %+v", got, want)
+		}
+
+		other, err := repo.GetByID(ctx, "other.app")
+		if err != nil {
+			t.Fatalf("GetByID: %v", err)
+		}
+		if !reflect.DeepEqual(other, gameVersion("other.app", "1.0.0", "10")) {
+			t.Fatalf("Update changed another version: %+v", other)
+		}
+	})
+
+	t.Run("list returns every version ordered by id", func(t *testing.T) {
+		repo := newRepo(t)
+
+		got, err := repo.ListVersions(ctx)
+		if err != nil {
+			t.Fatalf("ListVersions: %v", err)
+		}
+		if len(got) != 0 {
+			t.Fatalf("ListVersions: got %+v, want none", got)
+		}
+
+		want := []use_case.GameVersion{
+			gameVersion("a.app", "1.0.0", "10"),
+			gameVersion("b.app", "2.0.0", ""),
+		}
+		for _, v := range []use_case.GameVersion{want[1], want[0]} {
+			if err := repo.Create(ctx, v); err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+		}
+
+		got, err = repo.ListVersions(ctx)
+		if err != nil {
+			t.Fatalf("ListVersions: %v", err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("ListVersions: got %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("update missing returns ErrVersionNotFound", func(t *testing.T) {
+		repo := newRepo(t)
+
+		err := repo.Update(ctx, gameVersion("missing", "1.0.0", "10"))
+		if !errors.Is(err, use_case.ErrVersionNotFound) {
+			t.Fatalf("Update: got %v, want %v", err, use_case.ErrVersionNotFound)
+		}
+	})
+
+	t.Run("update with history", func(t *testing.T) {
+		repo := newRepo(t)
+		historyRepo, ok := repo.(use_case.VersionHistoryRepository)
+		if !ok {
+			t.Skip("backend does not implement VersionHistoryRepository")
+		}
+
+		err := historyRepo.UpdateWithHistory(ctx, gameVersion("missing", "1.0.0", "10"))
+		if !errors.Is(err, use_case.ErrVersionNotFound) {
+			t.Fatalf("UpdateWithHistory: got %v, want %v", err, use_case.ErrVersionNotFound)
+		}
+
+		if err := repo.Create(ctx, gameVersion("th.app", "1.2.0", "")); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+
+		want := gameVersion("th.app", "1.3.0", "00150010")
+		if err := historyRepo.UpdateWithHistory(ctx, want); err != nil {
+			t.Fatalf("UpdateWithHistory: %v", err)
+		}
+
+		got, err := repo.GetByID(ctx, want.Setting.ID)
+		if err != nil {
+			t.Fatalf("GetByID: %v", err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("GetByID: got %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("health check", func(t *testing.T) {
+		repo := newRepo(t)
+
+		if err := repo.HealthCheck(ctx); err != nil {
+			t.Fatalf("HealthCheck: %v", err)
+		}
+	})
+}
@@ -0,0 +1,308 @@
+package setting_repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/credential"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/cryptography"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/logger"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/platform"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/setting"
+	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
+	"go.etcd.io/bbolt"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+var boltBucket = []byte("settings")
+
+type bolt struct {
+	db      *bbolt.DB
+	keyring *cryptography.Keyring
+}
+
+type boltSetting struct {
+	ID                string         `json:"id"`
+	ServerCode        string         `json:"serverCode"`
+	Credential        boltCredential `json:"credential"`
+	GuessStartVersion string         `json:"guessStartVersion"`
+	Platforms         []string       `json:"platforms,omitempty"`
+	Locales           []string       `json:"locales,omitempty"`
+}
+
+type boltCredential struct {
+	UDID      string                   `json:"udid,omitempty"`
+	ShortUDID int32                    `json:"shortUdid,omitempty"`
+	ViewerID  int32                    `json:"viewerId,omitempty"`
+	Encrypted *boltEncryptedCredential `json:"encrypted,omitempty"`
+}
+
+type boltEncryptedCredential struct {
+	KeyID      string `json:"keyId"`
+	DataKey    []byte `json:"dataKey"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+func newBoltSetting(s use_case.PCRDSetting, keyring *cryptography.Keyring) (boltSetting, error) {
+	doc := boltSetting{
+		ID:                s.Setting.ID,
+		ServerCode:        string(s.Setting.ServerCode),
+		GuessStartVersion: s.GuessStartVersion,
+		Platforms:         platform.Names(s.Platforms),
+		Locales:           s.Locales,
+	}
+
+	if keyring == nil {
+		doc.Credential = boltCredential{
+			UDID:      s.Credential.Udid,
+			ShortUDID: s.Credential.ShortUdid,
+			ViewerID:  s.Credential.ViewerID,
+		}
+		return doc, nil
+	}
+
+	sealed, err := sealCredential(keyring, s.Setting.ID, s.Credential)
+	if err != nil {
+		return boltSetting{}, err
+	}
+	doc.Credential = boltCredential{
+		Encrypted: &boltEncryptedCredential{
+			KeyID:      sealed.KeyID,
+			DataKey:    sealed.DataKey,
+			Ciphertext: sealed.Ciphertext,
+		},
+	}
+
+	return doc, nil
+}
+
+func (b boltSetting) toUsecasePCRDSetting(keyring *cryptography.Keyring) (use_case.PCRDSetting, error) {
+
+	serverCode, err := setting.ParseServerCode(b.ServerCode)
+	if err != nil {
+		return use_case.PCRDSetting{}, err
+	}
+
+	c := credential.Credential{
+		Udid:      b.Credential.UDID,
+		ShortUdid: b.Credential.ShortUDID,
+		ViewerID:  b.Credential.ViewerID,
+	}
+	if b.Credential.Encrypted != nil {
+		c, err = openCredential(keyring, b.ID, cryptography.Sealed{
+			KeyID:      b.Credential.Encrypted.KeyID,
+			DataKey:    b.Credential.Encrypted.DataKey,
+			Ciphertext: b.Credential.Encrypted.Ciphertext,
+		})
+		if err != nil {
+			return use_case.PCRDSetting{}, err
+		}
+	}
+
+	platforms, err := platform.ParseList(b.Platforms)
+	if err != nil {
+		return use_case.PCRDSetting{}, err
+	}
+
+	return use_case.PCRDSetting{
+		Setting: setting.Setting{
+			ID:         b.ID,
+			ServerCode: serverCode,
+		},
+		Credential:        c,
+		GuessStartVersion: b.GuessStartVersion,
+		Platforms:         platforms,
+		Locales:           b.Locales,
+	}, nil
+}
+
+func (b bolt) GetSettingByID(ctx context.Context, ID string) (use_case.PCRDSetting, error) {
+	ctx, span := tracer.Start(ctx, "setting_repository.GetSettingByID")
+	defer span.End()
+
+	var data []byte
+	err := b.db.View(func(tx *bbolt.Tx) error {
+		if v := tx.Bucket(boltBucket).Get([]byte(ID)); v != nil {
+			data = append(data, v...)
+		}
+		return nil
+	})
+	if err != nil {
+		zap.L().Error("error while reading retrieving", logger.WithTraceId(ctx), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("error while retrieving: %s", err))
+		return use_case.PCRDSetting{}, fmt.Errorf("error while retrieving: %w", use_case.ErrRetrivingSetting)
+	}
+
+	if data == nil {
+		zap.L().Error("not exists", logger.WithTraceId(ctx), zap.Any("ID", ID), zap.Any("error", use_case.ErrSettingNotExists))
+		span.SetStatus(codes.Error, fmt.Sprintf("%s: %s", ID, use_case.ErrSettingNotExists))
+		return use_case.PCRDSetting{}, fmt.Errorf("%s: %w", ID, use_case.ErrSettingNotExists)
+	}
+
+	var o boltSetting
+	err = json.Unmarshal(data, &o)
+	if err != nil {
+		zap.L().Error("error while reading retrieving", logger.WithTraceId(ctx), zap.Any("ID", ID), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+		return use_case.PCRDSetting{}, fmt.Errorf("%w", use_case.ErrRetrivingSetting)
+	}
+
+	result, err := o.toUsecasePCRDSetting(b.keyring)
+	if err != nil {
+		zap.L().Error("error while reading retrieving", logger.WithTraceId(ctx), zap.Any("ID", ID), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+		return use_case.PCRDSetting{}, fmt.Errorf("%w", use_case.ErrRetrivingSetting)
+	}
+
+	return result, nil
+}
+
+// ListSettings returns settings ordered by ID, which is the bucket's key order.
+func (b bolt) ListSettings(ctx context.Context) ([]use_case.PCRDSetting, error) {
+	ctx, span := tracer.Start(ctx, "setting_repository.ListSettings")
+	defer span.End()
+
+	var dbEntities []boltSetting
+	err := b.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(boltBucket).ForEach(func(k, v []byte) error {
+			var o boltSetting
+			if err := json.Unmarshal(v, &o); err != nil {
+				return err
+			}
+			dbEntities = append(dbEntities, o)
+			return nil
+		})
+	})
+	if err != nil {
+		zap.L().Error("error while reading retrieving", logger.WithTraceId(ctx), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("error while retrieving: %s", err))
+		return nil, fmt.Errorf("error while retrieving: %w", use_case.ErrRetrivingSetting)
+	}
+
+	results := make([]use_case.PCRDSetting, len(dbEntities))
+	for i := range dbEntities {
+		result, err := dbEntities[i].toUsecasePCRDSetting(b.keyring)
+		if err != nil {
+			zap.L().Error("error while reading retrieving", logger.WithTraceId(ctx), zap.Any("ID", dbEntities[i].ID), zap.Any("error", err))
+			span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+			return nil, fmt.Errorf("%w", use_case.ErrRetrivingSetting)
+		}
+		results[i] = result
+	}
+
+	return results, nil
+}
+
+func (b bolt) CreateSetting(ctx context.Context, s use_case.PCRDSetting) error {
+	ctx, span := tracer.Start(ctx, "setting_repository.CreateSetting")
+	defer span.End()
+
+	return b.put(ctx, span, s, false)
+}
+
+func (b bolt) UpdateSetting(ctx context.Context, s use_case.PCRDSetting) error {
+	ctx, span := tracer.Start(ctx, "setting_repository.UpdateSetting")
+	defer span.End()
+
+	return b.put(ctx, span, s, true)
+}
+
+// put writes s, requiring the key to exist for an update and to be absent
+// for a create, in the same transaction as the write.
+func (b bolt) put(ctx context.Context, span trace.Span, s use_case.PCRDSetting, update bool) error {
+	doc, err := newBoltSetting(s, b.keyring)
+	if err != nil {
+		zap.L().Error("error while encrypting", logger.WithTraceId(ctx), zap.Any("ID", s.Setting.ID), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+		return fmt.Errorf("%w", use_case.ErrSavingSetting)
+	}
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		zap.L().Error("error while saving", logger.WithTraceId(ctx), zap.Any("ID", s.Setting.ID), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+		return fmt.Errorf("%w", use_case.ErrSavingSetting)
+	}
+
+	var exists bool
+	err = b.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(boltBucket)
+		exists = bucket.Get([]byte(s.Setting.ID)) != nil
+		if exists != update {
+			return nil
+		}
+		return bucket.Put([]byte(s.Setting.ID), data)
+	})
+	if err != nil {
+		zap.L().Error("error while saving", logger.WithTraceId(ctx), zap.Any("ID", s.Setting.ID), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+		return fmt.Errorf("%w", use_case.ErrSavingSetting)
+	}
+
+	if update && !exists {
+		zap.L().Error("cannot update", logger.WithTraceId(ctx), zap.Any("ID", s.Setting.ID), zap.Any("error", use_case.ErrSettingNotExists))
+		span.SetStatus(codes.Error, fmt.Sprintf("%s: %s", s.Setting.ID, use_case.ErrSettingNotExists))
+		return fmt.Errorf("update setting %s: %w", s.Setting.ID, use_case.ErrSettingNotExists)
+	}
+	if !update && exists {
+		zap.L().Error("already exists", logger.WithTraceId(ctx), zap.Any("ID", s.Setting.ID), zap.Any("error", use_case.ErrSettingAlreadyExists))
+		span.SetStatus(codes.Error, fmt.Sprintf("%s: %s", s.Setting.ID, use_case.ErrSettingAlreadyExists))
+		return fmt.Errorf("%s: %w", s.Setting.ID, use_case.ErrSettingAlreadyExists)
+	}
+
+	return nil
+}
+
+func (b bolt) DeleteSettingByID(ctx context.Context, ID string) error {
+	ctx, span := tracer.Start(ctx, "setting_repository.DeleteSettingByID")
+	defer span.End()
+
+	var exists bool
+	err := b.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(boltBucket)
+		exists = bucket.Get([]byte(ID)) != nil
+		if !exists {
+			return nil
+		}
+		return bucket.Delete([]byte(ID))
+	})
+	if err != nil {
+		zap.L().Error("error while deleting", logger.WithTraceId(ctx), zap.Any("ID", ID), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+		return fmt.Errorf("%w", use_case.ErrDeletingSetting)
+	}
+
+	if !exists {
+		zap.L().Error("cannot delete", logger.WithTraceId(ctx), zap.Any("ID", ID), zap.Any("error", use_case.ErrSettingNotExists))
+		span.SetStatus(codes.Error, fmt.Sprintf("%s: %s", ID, use_case.ErrSettingNotExists))
+		return fmt.Errorf("delete setting %s: %w", ID, use_case.ErrSettingNotExists)
+	}
+
+	return nil
+}
+
+func (b bolt) Encrypts() bool {
+	return b.keyring != nil
+}
+
+func (b bolt) HealthCheck(ctx context.Context) error {
+	return b.db.View(func(tx *bbolt.Tx) error {
+		return nil
+	})
+}
+
+// NewBolt stores settings in a single bbolt file. Credentials are encrypted
+// with keyring the same way as NewMongoDb.
+func NewBolt(db *bbolt.DB, keyring *cryptography.Keyring) (use_case.SettingRepository, error) {
+	err := db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(boltBucket)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &bolt{db: db, keyring: keyring}, nil
+}
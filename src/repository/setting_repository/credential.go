@@ -0,0 +1,52 @@
+package setting_repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/credential"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/cryptography"
+)
+
+type sealedCredential struct {
+	UDID      string `json:"udid"`
+	ShortUDID int32  `json:"shortUdid"`
+	ViewerID  int32  `json:"viewerId"`
+}
+
+// sealCredential encrypts the credential fields together. The setting ID is
+// bound as additional data so a ciphertext cannot be moved to another setting.
+func sealCredential(keyring *cryptography.Keyring, ID string, c credential.Credential) (cryptography.Sealed, error) {
+	plaintext, err := json.Marshal(sealedCredential{
+		UDID:      c.Udid,
+		ShortUDID: c.ShortUdid,
+		ViewerID:  c.ViewerID,
+	})
+	if err != nil {
+		return cryptography.Sealed{}, err
+	}
+
+	return keyring.Seal(plaintext, []byte(ID))
+}
+
+func openCredential(keyring *cryptography.Keyring, ID string, s cryptography.Sealed) (credential.Credential, error) {
+	if keyring == nil {
+		return credential.Credential{}, fmt.Errorf("credential of %s is encrypted but no key is configured: %w", ID, cryptography.ErrUnknownKey)
+	}
+
+	plaintext, err := keyring.Open(s, []byte(ID))
+	if err != nil {
+		return credential.Credential{}, err
+	}
+
+	var o sealedCredential
+	err = json.Unmarshal(plaintext, &o)
+	if err != nil {
+		return credential.Credential{}, fmt.Errorf("%s: %w", err, cryptography.ErrDecrypt)
+	}
+
+	return credential.Credential{
+		Udid:      o.UDID,
+		ShortUdid: o.ShortUDID,
+		ViewerID:  o.ViewerID,
+	}, nil
+}
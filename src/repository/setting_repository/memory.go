@@ -0,0 +1,90 @@
+package setting_repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
+	"sort"
+	"sync"
+)
+
+type memory struct {
+	mu       sync.RWMutex
+	settings map[string]use_case.PCRDSetting
+}
+
+func (m *memory) GetSettingByID(ctx context.Context, ID string) (use_case.PCRDSetting, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result, ok := m.settings[ID]
+	if !ok {
+		return use_case.PCRDSetting{}, fmt.Errorf("%s: %w", ID, use_case.ErrSettingNotExists)
+	}
+
+	return result, nil
+}
+
+func (m *memory) ListSettings(ctx context.Context) ([]use_case.PCRDSetting, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	results := make([]use_case.PCRDSetting, 0, len(m.settings))
+	for _, s := range m.settings {
+		results = append(results, s)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Setting.ID < results[j].Setting.ID
+	})
+
+	return results, nil
+}
+
+func (m *memory) CreateSetting(ctx context.Context, s use_case.PCRDSetting) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.settings[s.Setting.ID]; ok {
+		return fmt.Errorf("%s: %w", s.Setting.ID, use_case.ErrSettingAlreadyExists)
+	}
+
+	m.settings[s.Setting.ID] = s
+	return nil
+}
+
+func (m *memory) UpdateSetting(ctx context.Context, s use_case.PCRDSetting) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.settings[s.Setting.ID]; !ok {
+		return fmt.Errorf("update setting %s: %w", s.Setting.ID, use_case.ErrSettingNotExists)
+	}
+
+	m.settings[s.Setting.ID] = s
+	return nil
+}
+
+func (m *memory) DeleteSettingByID(ctx context.Context, ID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.settings[ID]; !ok {
+		return fmt.Errorf("delete setting %s: %w", ID, use_case.ErrSettingNotExists)
+	}
+
+	delete(m.settings, ID)
+	return nil
+}
+
+func (m *memory) HealthCheck(ctx context.Context) error {
+	return nil
+}
+
+func NewMemory(settings ...use_case.PCRDSetting) use_case.SettingRepository {
+	m := &memory{settings: map[string]use_case.PCRDSetting{}}
+	for _, s := range settings {
+		m.settings[s.Setting.ID] = s
+	}
+
+	return m
+}
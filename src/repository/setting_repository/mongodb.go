@@ -4,18 +4,22 @@ import (
 	"context"
 	"fmt"
 	"github.com/SpeedxPz/pcrd-version-updater/src/entity/credential"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/cryptography"
 	"github.com/SpeedxPz/pcrd-version-updater/src/entity/logger"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/platform"
 	"github.com/SpeedxPz/pcrd-version-updater/src/entity/setting"
 	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.mongodb.org/mongo-driver/mongo/readpref"
 	"go.opentelemetry.io/otel/codes"
 	"go.uber.org/zap"
 )
 
 type mongoDB struct {
-	col *mongo.Collection
+	col     *mongo.Collection
+	keyring *cryptography.Keyring
 }
 
 type mongoDBSetting struct {
@@ -25,66 +29,133 @@ type mongoDBSetting struct {
 	GuessConfig mongoDBGuessConfig `bson:"guess"`
 }
 
+// mongoDBCredential keeps the plaintext fields only for documents written
+// before encryption was enabled, or when no key is configured.
 type mongoDBCredential struct {
-	UDID      string `bson:"udid"`
-	ShortUDID int32  `bson:"shortUdid"`
-	ViewerID  int32  `bson:"viewerId"`
+	UDID      string                      `bson:"udid,omitempty"`
+	ShortUDID int32                       `bson:"shortUdid,omitempty"`
+	ViewerID  int32                       `bson:"viewerId,omitempty"`
+	Encrypted *mongoDBEncryptedCredential `bson:"encrypted,omitempty"`
+}
+
+type mongoDBEncryptedCredential struct {
+	KeyID      string `bson:"keyId"`
+	DataKey    []byte `bson:"dataKey"`
+	Ciphertext []byte `bson:"ciphertext"`
 }
 
 type mongoDBGuessConfig struct {
-	StartVersion string `bson:"startVersion"`
+	StartVersion string   `bson:"startVersion"`
+	Platforms    []string `bson:"platforms,omitempty"`
+	Locales      []string `bson:"locales,omitempty"`
 }
 
-func (m mongoDBSetting) toUsecasePCRDSetting() (use_case.PCRDSetting, error) {
+func newMongoDBSetting(s use_case.PCRDSetting, keyring *cryptography.Keyring) (mongoDBSetting, error) {
+	doc := mongoDBSetting{
+		ID:         s.Setting.ID,
+		ServerCode: string(s.Setting.ServerCode),
+		GuessConfig: mongoDBGuessConfig{
+			StartVersion: s.GuessStartVersion,
+			Platforms:    platform.Names(s.Platforms),
+			Locales:      s.Locales,
+		},
+	}
+
+	if keyring == nil {
+		doc.Credential = mongoDBCredential{
+			UDID:      s.Credential.Udid,
+			ShortUDID: s.Credential.ShortUdid,
+			ViewerID:  s.Credential.ViewerID,
+		}
+		return doc, nil
+	}
+
+	sealed, err := sealCredential(keyring, s.Setting.ID, s.Credential)
+	if err != nil {
+		return mongoDBSetting{}, err
+	}
+	doc.Credential = mongoDBCredential{
+		Encrypted: &mongoDBEncryptedCredential{
+			KeyID:      sealed.KeyID,
+			DataKey:    sealed.DataKey,
+			Ciphertext: sealed.Ciphertext,
+		},
+	}
+
+	return doc, nil
+}
+
+func (m mongoDBSetting) toUsecasePCRDSetting(keyring *cryptography.Keyring) (use_case.PCRDSetting, error) {
 
 	serverCode, err := setting.ParseServerCode(m.ServerCode)
 	if err != nil {
 		return use_case.PCRDSetting{}, err
 	}
 
+	c := credential.Credential{
+		Udid:      m.Credential.UDID,
+		ShortUdid: m.Credential.ShortUDID,
+		ViewerID:  m.Credential.ViewerID,
+		SessionID: "",
+	}
+	if m.Credential.Encrypted != nil {
+		c, err = openCredential(keyring, m.ID, cryptography.Sealed{
+			KeyID:      m.Credential.Encrypted.KeyID,
+			DataKey:    m.Credential.Encrypted.DataKey,
+			Ciphertext: m.Credential.Encrypted.Ciphertext,
+		})
+		if err != nil {
+			return use_case.PCRDSetting{}, err
+		}
+	}
+
+	platforms, err := platform.ParseList(m.GuessConfig.Platforms)
+	if err != nil {
+		return use_case.PCRDSetting{}, err
+	}
+
 	return use_case.PCRDSetting{
 		Setting: setting.Setting{
 			ID:         m.ID,
 			ServerCode: serverCode,
 		},
-		Credential: credential.Credential{
-			Udid:      m.Credential.UDID,
-			ShortUdid: m.Credential.ShortUDID,
-			ViewerID:  m.Credential.ViewerID,
-			SessionID: "",
-		},
+		Credential:        c,
 		GuessStartVersion: m.GuessConfig.StartVersion,
+		Platforms:         platforms,
+		Locales:           m.GuessConfig.Locales,
 	}, nil
 }
 
-func (m mongoDB) GetSettingByID(ctx context.Context, ID string) (use_case.PCRDSetting, error) {
-	ctx, span := tracer.Start(ctx, "setting_repository.GetSettingByID")
-	defer span.End()
-
+func (m mongoDB) find(ctx context.Context, filter bson.M, opts ...*options.FindOptions) ([]mongoDBSetting, error) {
 	var dbEntities []mongoDBSetting
 
-	filter := bson.M{}
-	filter["id"] = ID
-
-	cur, err := m.col.Find(ctx, filter)
+	cur, err := m.col.Find(ctx, filter, opts...)
 	if err != nil {
-		zap.L().Error("error while reading retrieving", logger.WithTraceId(ctx), zap.Any("error", err))
-		span.SetStatus(codes.Error, fmt.Sprintf("error while retrieving: %s", err))
-		return use_case.PCRDSetting{}, fmt.Errorf("error while retrieving: %w", use_case.ErrRetrivingSetting)
+		return nil, err
 	}
 	defer cur.Close(ctx)
 	for cur.Next(ctx) {
 		var o mongoDBSetting
 		err := cur.Decode(&o)
 		if err != nil {
-			zap.L().Error("error while reading retrieving", logger.WithTraceId(ctx), zap.Any("error", err))
-			span.SetStatus(codes.Error, fmt.Sprintf("error while retrieving: %s", err))
-			return use_case.PCRDSetting{}, fmt.Errorf("error while retrieving: %w", use_case.ErrRetrivingSetting)
+			return nil, err
 		}
 		dbEntities = append(dbEntities, o)
 	}
 
 	if err := cur.Err(); err != nil {
+		return nil, err
+	}
+
+	return dbEntities, nil
+}
+
+func (m mongoDB) GetSettingByID(ctx context.Context, ID string) (use_case.PCRDSetting, error) {
+	ctx, span := tracer.Start(ctx, "setting_repository.GetSettingByID")
+	defer span.End()
+
+	dbEntities, err := m.find(ctx, bson.M{"id": ID})
+	if err != nil {
 		zap.L().Error("error while reading retrieving", logger.WithTraceId(ctx), zap.Any("error", err))
 		span.SetStatus(codes.Error, fmt.Sprintf("error while retrieving: %s", err))
 		return use_case.PCRDSetting{}, fmt.Errorf("error while retrieving: %w", use_case.ErrRetrivingSetting)
@@ -96,7 +167,7 @@ func (m mongoDB) GetSettingByID(ctx context.Context, ID string) (use_case.PCRDSe
 		return use_case.PCRDSetting{}, fmt.Errorf("%s: %w", ID, use_case.ErrSettingNotExists)
 	}
 
-	result, err := dbEntities[0].toUsecasePCRDSetting()
+	result, err := dbEntities[0].toUsecasePCRDSetting(m.keyring)
 	if err != nil {
 		zap.L().Error("error while reading retrieving", logger.WithTraceId(ctx), zap.Any("ID", ID), zap.Any("error", err))
 		span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
@@ -106,12 +177,126 @@ func (m mongoDB) GetSettingByID(ctx context.Context, ID string) (use_case.PCRDSe
 	return result, nil
 }
 
+func (m mongoDB) ListSettings(ctx context.Context) ([]use_case.PCRDSetting, error) {
+	ctx, span := tracer.Start(ctx, "setting_repository.ListSettings")
+	defer span.End()
+
+	dbEntities, err := m.find(ctx, bson.M{}, options.Find().SetSort(bson.M{"id": 1}))
+	if err != nil {
+		zap.L().Error("error while reading retrieving", logger.WithTraceId(ctx), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("error while retrieving: %s", err))
+		return nil, fmt.Errorf("error while retrieving: %w", use_case.ErrRetrivingSetting)
+	}
+
+	results := make([]use_case.PCRDSetting, len(dbEntities))
+	for i := range dbEntities {
+		result, err := dbEntities[i].toUsecasePCRDSetting(m.keyring)
+		if err != nil {
+			zap.L().Error("error while reading retrieving", logger.WithTraceId(ctx), zap.Any("ID", dbEntities[i].ID), zap.Any("error", err))
+			span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+			return nil, fmt.Errorf("%w", use_case.ErrRetrivingSetting)
+		}
+		results[i] = result
+	}
+
+	return results, nil
+}
+
+func (m mongoDB) CreateSetting(ctx context.Context, s use_case.PCRDSetting) error {
+	ctx, span := tracer.Start(ctx, "setting_repository.CreateSetting")
+	defer span.End()
+
+	doc, err := newMongoDBSetting(s, m.keyring)
+	if err != nil {
+		zap.L().Error("error while encrypting", logger.WithTraceId(ctx), zap.Any("ID", s.Setting.ID), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+		return fmt.Errorf("%w", use_case.ErrSavingSetting)
+	}
+
+	// The unique index on id rejects a concurrent create of the same setting.
+	_, err = m.col.InsertOne(ctx, doc)
+	if mongo.IsDuplicateKeyError(err) {
+		zap.L().Error("already exists", logger.WithTraceId(ctx), zap.Any("ID", s.Setting.ID), zap.Any("error", use_case.ErrSettingAlreadyExists))
+		span.SetStatus(codes.Error, fmt.Sprintf("%s: %s", s.Setting.ID, use_case.ErrSettingAlreadyExists))
+		return fmt.Errorf("%s: %w", s.Setting.ID, use_case.ErrSettingAlreadyExists)
+	}
+	if err != nil {
+		zap.L().Error("error while saving", logger.WithTraceId(ctx), zap.Any("ID", s.Setting.ID), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+		return fmt.Errorf("%w", use_case.ErrSavingSetting)
+	}
+
+	return nil
+}
+
+func (m mongoDB) UpdateSetting(ctx context.Context, s use_case.PCRDSetting) error {
+	ctx, span := tracer.Start(ctx, "setting_repository.UpdateSetting")
+	defer span.End()
+
+	doc, err := newMongoDBSetting(s, m.keyring)
+	if err != nil {
+		zap.L().Error("error while encrypting", logger.WithTraceId(ctx), zap.Any("ID", s.Setting.ID), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+		return fmt.Errorf("%w", use_case.ErrSavingSetting)
+	}
+
+	res, err := m.col.ReplaceOne(ctx, bson.M{"id": s.Setting.ID}, doc)
+	if err != nil {
+		zap.L().Error("error while saving", logger.WithTraceId(ctx), zap.Any("ID", s.Setting.ID), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+		return fmt.Errorf("%w", use_case.ErrSavingSetting)
+	}
+
+	if res.MatchedCount == 0 {
+		zap.L().Error("cannot update", logger.WithTraceId(ctx), zap.Any("ID", s.Setting.ID), zap.Any("error", use_case.ErrSettingNotExists))
+		span.SetStatus(codes.Error, fmt.Sprintf("%s: %s", s.Setting.ID, use_case.ErrSettingNotExists))
+		return fmt.Errorf("update setting %s: %w", s.Setting.ID, use_case.ErrSettingNotExists)
+	}
+
+	return nil
+}
+
+func (m mongoDB) DeleteSettingByID(ctx context.Context, ID string) error {
+	ctx, span := tracer.Start(ctx, "setting_repository.DeleteSettingByID")
+	defer span.End()
+
+	res, err := m.col.DeleteOne(ctx, bson.M{"id": ID})
+	if err != nil {
+		zap.L().Error("error while deleting", logger.WithTraceId(ctx), zap.Any("ID", ID), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+		return fmt.Errorf("%w", use_case.ErrDeletingSetting)
+	}
+
+	if res.DeletedCount == 0 {
+		zap.L().Error("cannot delete", logger.WithTraceId(ctx), zap.Any("ID", ID), zap.Any("error", use_case.ErrSettingNotExists))
+		span.SetStatus(codes.Error, fmt.Sprintf("%s: %s", ID, use_case.ErrSettingNotExists))
+		return fmt.Errorf("delete setting %s: %w", ID, use_case.ErrSettingNotExists)
+	}
+
+	return nil
+}
+
+func (m mongoDB) Encrypts() bool {
+	return m.keyring != nil
+}
+
 func (m mongoDB) HealthCheck(ctx context.Context) error {
 	return m.col.Database().Client().Ping(ctx, readpref.Primary())
 }
 
-func NewMongoDb(db *mongo.Database) use_case.SettingRepository {
-	m := &mongoDB{col: db.Collection("settings")}
+// NewMongoDb stores credentials encrypted with keyring. A nil keyring keeps
+// the legacy plaintext format. It creates the unique index on id that
+// CreateSetting relies on to reject duplicates.
+func NewMongoDb(ctx context.Context, db *mongo.Database, keyring *cryptography.Keyring) (use_case.SettingRepository, error) {
+	m := &mongoDB{col: db.Collection("settings"), keyring: keyring}
+
+	_, err := m.col.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return nil, err
+	}
 
-	return m
+	return m, nil
 }
@@ -0,0 +1,101 @@
+package setting_repository_test
+
+import (
+	"context"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/cryptography"
+	"github.com/SpeedxPz/pcrd-version-updater/src/repository/repository_contract"
+	"github.com/SpeedxPz/pcrd-version-updater/src/repository/setting_repository"
+	"github.com/SpeedxPz/pcrd-version-updater/src/repository/sql_schema"
+	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
+	"testing"
+)
+
+func testKeyring(t *testing.T) *cryptography.Keyring {
+	keyring, err := cryptography.ParseKeyring("test:AAECAwQFBgcICQoLDA0ODxAREhMUFRYXGBkaGxwdHh8=")
+	if err != nil {
+		t.Fatalf("ParseKeyring: %v", err)
+	}
+	return keyring
+}
+
+func TestMemory(t *testing.T) {
+	repository_contract.SettingRepository(t, func(t *testing.T) use_case.SettingRepository {
+		return setting_repository.NewMemory()
+	})
+}
+
+func TestBolt(t *testing.T) {
+	repository_contract.SettingRepository(t, func(t *testing.T) use_case.SettingRepository {
+		repo, err := setting_repository.NewBolt(repository_contract.NewBoltDB(t), nil)
+		if err != nil {
+			t.Fatalf("NewBolt: %v", err)
+		}
+		return repo
+	})
+}
+
+func TestBoltEncrypted(t *testing.T) {
+	repository_contract.SettingRepository(t, func(t *testing.T) use_case.SettingRepository {
+		repo, err := setting_repository.NewBolt(repository_contract.NewBoltDB(t), testKeyring(t))
+		if err != nil {
+			t.Fatalf("NewBolt: %v", err)
+		}
+		return repo
+	})
+}
+
+func TestBoltEncrypts(t *testing.T) {
+	for _, c := range []struct {
+		keyring *cryptography.Keyring
+		want    bool
+	}{
+		{nil, false},
+		{testKeyring(t), true},
+	} {
+		repo, err := setting_repository.NewBolt(repository_contract.NewBoltDB(t), c.keyring)
+		if err != nil {
+			t.Fatalf("NewBolt: %v", err)
+		}
+		if got := repo.(use_case.EncryptingSettingRepository).Encrypts(); got != c.want {
+			t.Fatalf("Encrypts: got %v, want %v", got, c.want)
+		}
+	}
+}
+
+func TestMongoDb(t *testing.T) {
+	repository_contract.SettingRepository(t, func(t *testing.T) use_case.SettingRepository {
+		repo, err := setting_repository.NewMongoDb(context.Background(), repository_contract.NewMongoDatabase(t), nil)
+		if err != nil {
+			t.Fatalf("NewMongoDb: %v", err)
+		}
+		return repo
+	})
+}
+
+func TestMongoDbEncrypted(t *testing.T) {
+	repository_contract.SettingRepository(t, func(t *testing.T) use_case.SettingRepository {
+		repo, err := setting_repository.NewMongoDb(context.Background(), repository_contract.NewMongoDatabase(t), testKeyring(t))
+		if err != nil {
+			t.Fatalf("NewMongoDb: %v", err)
+		}
+		return repo
+	})
+}
+
+func TestSQLite(t *testing.T) {
+	repository_contract.SettingRepository(t, func(t *testing.T) use_case.SettingRepository {
+		return setting_repository.NewSQL(repository_contract.NewSQLiteDB(t), sql_schema.DialectSQLite, nil)
+	})
+}
+
+func TestSQLiteEncrypted(t *testing.T) {
+	repository_contract.SettingRepository(t, func(t *testing.T) use_case.SettingRepository {
+		return setting_repository.NewSQL(repository_contract.NewSQLiteDB(t), sql_schema.DialectSQLite, testKeyring(t))
+	})
+}
+
+func TestPostgres(t *testing.T) {
+	repository_contract.SettingRepository(t, func(t *testing.T) use_case.SettingRepository {
+		return setting_repository.NewSQL(repository_contract.NewPostgresDB(t), sql_schema.DialectPostgres, testKeyring(t))
+	})
+}
@@ -0,0 +1,331 @@
+package setting_repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/credential"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/cryptography"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/logger"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/platform"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/setting"
+	"github.com/SpeedxPz/pcrd-version-updater/src/repository/sql_schema"
+	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
+	"go.opentelemetry.io/otel/codes"
+	"go.uber.org/zap"
+	"strings"
+)
+
+const sqlSettingColumns = `id, server_code, udid, short_udid, viewer_id, credential_key_id, credential_data_key, credential_ciphertext, guess_start_version, jp_platforms, jp_locales`
+
+type sqlDB struct {
+	db      *sql.DB
+	dialect sql_schema.Dialect
+	keyring *cryptography.Keyring
+}
+
+type sqlSetting struct {
+	ID                   string
+	ServerCode           string
+	UDID                 string
+	ShortUDID            int32
+	ViewerID             int32
+	CredentialKeyID      string
+	CredentialDataKey    []byte
+	CredentialCiphertext []byte
+	GuessStartVersion    string
+	// JPPlatforms and JPLocales are comma-separated.
+	JPPlatforms string
+	JPLocales   string
+}
+
+type sqlScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanSQLSetting(row sqlScanner) (sqlSetting, error) {
+	var o sqlSetting
+	err := row.Scan(
+		&o.ID,
+		&o.ServerCode,
+		&o.UDID,
+		&o.ShortUDID,
+		&o.ViewerID,
+		&o.CredentialKeyID,
+		&o.CredentialDataKey,
+		&o.CredentialCiphertext,
+		&o.GuessStartVersion,
+		&o.JPPlatforms,
+		&o.JPLocales,
+	)
+	return o, err
+}
+
+func newSQLSetting(s use_case.PCRDSetting, keyring *cryptography.Keyring) (sqlSetting, error) {
+	row := sqlSetting{
+		ID:                s.Setting.ID,
+		ServerCode:        string(s.Setting.ServerCode),
+		GuessStartVersion: s.GuessStartVersion,
+		JPPlatforms:       strings.Join(platform.Names(s.Platforms), ","),
+		JPLocales:         strings.Join(s.Locales, ","),
+	}
+
+	if keyring == nil {
+		row.UDID = s.Credential.Udid
+		row.ShortUDID = s.Credential.ShortUdid
+		row.ViewerID = s.Credential.ViewerID
+		return row, nil
+	}
+
+	sealed, err := sealCredential(keyring, s.Setting.ID, s.Credential)
+	if err != nil {
+		return sqlSetting{}, err
+	}
+	row.CredentialKeyID = sealed.KeyID
+	row.CredentialDataKey = sealed.DataKey
+	row.CredentialCiphertext = sealed.Ciphertext
+
+	return row, nil
+}
+
+func (o sqlSetting) args() []interface{} {
+	return []interface{}{
+		o.ID,
+		o.ServerCode,
+		o.UDID,
+		o.ShortUDID,
+		o.ViewerID,
+		o.CredentialKeyID,
+		o.CredentialDataKey,
+		o.CredentialCiphertext,
+		o.GuessStartVersion,
+		o.JPPlatforms,
+		o.JPLocales,
+	}
+}
+
+func (o sqlSetting) toUsecasePCRDSetting(keyring *cryptography.Keyring) (use_case.PCRDSetting, error) {
+
+	serverCode, err := setting.ParseServerCode(o.ServerCode)
+	if err != nil {
+		return use_case.PCRDSetting{}, err
+	}
+
+	c := credential.Credential{
+		Udid:      o.UDID,
+		ShortUdid: o.ShortUDID,
+		ViewerID:  o.ViewerID,
+	}
+	if len(o.CredentialKeyID) > 0 {
+		c, err = openCredential(keyring, o.ID, cryptography.Sealed{
+			KeyID:      o.CredentialKeyID,
+			DataKey:    o.CredentialDataKey,
+			Ciphertext: o.CredentialCiphertext,
+		})
+		if err != nil {
+			return use_case.PCRDSetting{}, err
+		}
+	}
+
+	platforms, err := platform.ParseList(platform.SplitList(o.JPPlatforms))
+	if err != nil {
+		return use_case.PCRDSetting{}, err
+	}
+
+	return use_case.PCRDSetting{
+		Setting: setting.Setting{
+			ID:         o.ID,
+			ServerCode: serverCode,
+		},
+		Credential:        c,
+		GuessStartVersion: o.GuessStartVersion,
+		Platforms:         platforms,
+		Locales:           platform.SplitList(o.JPLocales),
+	}, nil
+}
+
+func (s sqlDB) GetSettingByID(ctx context.Context, ID string) (use_case.PCRDSetting, error) {
+	ctx, span := tracer.Start(ctx, "setting_repository.GetSettingByID")
+	defer span.End()
+
+	o, err := scanSQLSetting(s.db.QueryRowContext(ctx, s.dialect.Rebind(
+		`SELECT `+sqlSettingColumns+` FROM settings WHERE id = ?`,
+	), ID))
+	if errors.Is(err, sql.ErrNoRows) {
+		zap.L().Error("not exists", logger.WithTraceId(ctx), zap.Any("ID", ID), zap.Any("error", use_case.ErrSettingNotExists))
+		span.SetStatus(codes.Error, fmt.Sprintf("%s: %s", ID, use_case.ErrSettingNotExists))
+		return use_case.PCRDSetting{}, fmt.Errorf("%s: %w", ID, use_case.ErrSettingNotExists)
+	}
+	if err != nil {
+		zap.L().Error("error while reading retrieving", logger.WithTraceId(ctx), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("error while retrieving: %s", err))
+		return use_case.PCRDSetting{}, fmt.Errorf("error while retrieving: %w", use_case.ErrRetrivingSetting)
+	}
+
+	result, err := o.toUsecasePCRDSetting(s.keyring)
+	if err != nil {
+		zap.L().Error("error while reading retrieving", logger.WithTraceId(ctx), zap.Any("ID", ID), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+		return use_case.PCRDSetting{}, fmt.Errorf("%w", use_case.ErrRetrivingSetting)
+	}
+
+	return result, nil
+}
+
+func (s sqlDB) ListSettings(ctx context.Context) ([]use_case.PCRDSetting, error) {
+	ctx, span := tracer.Start(ctx, "setting_repository.ListSettings")
+	defer span.End()
+
+	rows, err := s.db.QueryContext(ctx, `SELECT `+sqlSettingColumns+` FROM settings ORDER BY id`)
+	if err != nil {
+		zap.L().Error("error while reading retrieving", logger.WithTraceId(ctx), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("error while retrieving: %s", err))
+		return nil, fmt.Errorf("error while retrieving: %w", use_case.ErrRetrivingSetting)
+	}
+	defer rows.Close()
+
+	var results []use_case.PCRDSetting
+	for rows.Next() {
+		o, err := scanSQLSetting(rows)
+		if err != nil {
+			zap.L().Error("error while reading retrieving", logger.WithTraceId(ctx), zap.Any("error", err))
+			span.SetStatus(codes.Error, fmt.Sprintf("error while retrieving: %s", err))
+			return nil, fmt.Errorf("error while retrieving: %w", use_case.ErrRetrivingSetting)
+		}
+
+		result, err := o.toUsecasePCRDSetting(s.keyring)
+		if err != nil {
+			zap.L().Error("error while reading retrieving", logger.WithTraceId(ctx), zap.Any("ID", o.ID), zap.Any("error", err))
+			span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+			return nil, fmt.Errorf("%w", use_case.ErrRetrivingSetting)
+		}
+		results = append(results, result)
+	}
+
+	if err := rows.Err(); err != nil {
+		zap.L().Error("error while reading retrieving", logger.WithTraceId(ctx), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("error while retrieving: %s", err))
+		return nil, fmt.Errorf("error while retrieving: %w", use_case.ErrRetrivingSetting)
+	}
+
+	if results == nil {
+		results = []use_case.PCRDSetting{}
+	}
+
+	return results, nil
+}
+
+func (s sqlDB) CreateSetting(ctx context.Context, p use_case.PCRDSetting) error {
+	ctx, span := tracer.Start(ctx, "setting_repository.CreateSetting")
+	defer span.End()
+
+	row, err := newSQLSetting(p, s.keyring)
+	if err != nil {
+		zap.L().Error("error while encrypting", logger.WithTraceId(ctx), zap.Any("ID", p.Setting.ID), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+		return fmt.Errorf("%w", use_case.ErrSavingSetting)
+	}
+
+	res, err := s.db.ExecContext(ctx, s.dialect.Rebind(
+		`INSERT INTO settings (`+sqlSettingColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) ON CONFLICT (id) DO NOTHING`,
+	), row.args()...)
+	if err != nil {
+		zap.L().Error("error while saving", logger.WithTraceId(ctx), zap.Any("ID", p.Setting.ID), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+		return fmt.Errorf("%w", use_case.ErrSavingSetting)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		zap.L().Error("error while saving", logger.WithTraceId(ctx), zap.Any("ID", p.Setting.ID), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+		return fmt.Errorf("%w", use_case.ErrSavingSetting)
+	}
+
+	if affected == 0 {
+		zap.L().Error("already exists", logger.WithTraceId(ctx), zap.Any("ID", p.Setting.ID), zap.Any("error", use_case.ErrSettingAlreadyExists))
+		span.SetStatus(codes.Error, fmt.Sprintf("%s: %s", p.Setting.ID, use_case.ErrSettingAlreadyExists))
+		return fmt.Errorf("%s: %w", p.Setting.ID, use_case.ErrSettingAlreadyExists)
+	}
+
+	return nil
+}
+
+func (s sqlDB) UpdateSetting(ctx context.Context, p use_case.PCRDSetting) error {
+	ctx, span := tracer.Start(ctx, "setting_repository.UpdateSetting")
+	defer span.End()
+
+	row, err := newSQLSetting(p, s.keyring)
+	if err != nil {
+		zap.L().Error("error while encrypting", logger.WithTraceId(ctx), zap.Any("ID", p.Setting.ID), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+		return fmt.Errorf("%w", use_case.ErrSavingSetting)
+	}
+
+	args := append(row.args()[1:], row.ID)
+	res, err := s.db.ExecContext(ctx, s.dialect.Rebind(
+		`UPDATE settings SET server_code = ?, udid = ?, short_udid = ?, viewer_id = ?, credential_key_id = ?, credential_data_key = ?, credential_ciphertext = ?, guess_start_version = ?, jp_platforms = ?, jp_locales = ? WHERE id = ?`,
+	), args...)
+	if err != nil {
+		zap.L().Error("error while saving", logger.WithTraceId(ctx), zap.Any("ID", p.Setting.ID), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+		return fmt.Errorf("%w", use_case.ErrSavingSetting)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		zap.L().Error("error while saving", logger.WithTraceId(ctx), zap.Any("ID", p.Setting.ID), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+		return fmt.Errorf("%w", use_case.ErrSavingSetting)
+	}
+
+	if affected == 0 {
+		zap.L().Error("cannot update", logger.WithTraceId(ctx), zap.Any("ID", p.Setting.ID), zap.Any("error", use_case.ErrSettingNotExists))
+		span.SetStatus(codes.Error, fmt.Sprintf("%s: %s", p.Setting.ID, use_case.ErrSettingNotExists))
+		return fmt.Errorf("update setting %s: %w", p.Setting.ID, use_case.ErrSettingNotExists)
+	}
+
+	return nil
+}
+
+func (s sqlDB) DeleteSettingByID(ctx context.Context, ID string) error {
+	ctx, span := tracer.Start(ctx, "setting_repository.DeleteSettingByID")
+	defer span.End()
+
+	res, err := s.db.ExecContext(ctx, s.dialect.Rebind(`DELETE FROM settings WHERE id = ?`), ID)
+	if err != nil {
+		zap.L().Error("error while deleting", logger.WithTraceId(ctx), zap.Any("ID", ID), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+		return fmt.Errorf("%w", use_case.ErrDeletingSetting)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		zap.L().Error("error while deleting", logger.WithTraceId(ctx), zap.Any("ID", ID), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+		return fmt.Errorf("%w", use_case.ErrDeletingSetting)
+	}
+
+	if affected == 0 {
+		zap.L().Error("cannot delete", logger.WithTraceId(ctx), zap.Any("ID", ID), zap.Any("error", use_case.ErrSettingNotExists))
+		span.SetStatus(codes.Error, fmt.Sprintf("%s: %s", ID, use_case.ErrSettingNotExists))
+		return fmt.Errorf("delete setting %s: %w", ID, use_case.ErrSettingNotExists)
+	}
+
+	return nil
+}
+
+func (s sqlDB) Encrypts() bool {
+	return s.keyring != nil
+}
+
+func (s sqlDB) HealthCheck(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+// NewSQL expects the schema to be migrated with sql_schema.Migrate.
+// Credentials are encrypted with keyring the same way as NewMongoDb.
+func NewSQL(db *sql.DB, dialect sql_schema.Dialect, keyring *cryptography.Keyring) use_case.SettingRepository {
+	return &sqlDB{db: db, dialect: dialect, keyring: keyring}
+}
@@ -0,0 +1,316 @@
+// Package sql_schema owns the database/sql schema shared by the SQL
+// implementations of the setting, version and history repositories.
+package sql_schema
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrUnknownDialect = errors.New("unknown sql dialect")
+)
+
+type Dialect string
+
+const (
+	DialectSQLite   Dialect = "sqlite"
+	DialectPostgres Dialect = "postgres"
+)
+
+func ParseDialect(s string) (Dialect, error) {
+	switch Dialect(s) {
+	case DialectSQLite, DialectPostgres:
+		return Dialect(s), nil
+	}
+	return "", fmt.Errorf("cannot parse:[%s] as dialect: %w", s, ErrUnknownDialect)
+}
+
+// Rebind rewrites the ? placeholders used by the repositories into the
+// dialect's own placeholder style.
+func (d Dialect) Rebind(query string) string {
+	if d != DialectPostgres {
+		return query
+	}
+
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			fmt.Fprintf(&b, "$%d", n)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+type migration struct {
+	version    int
+	statements map[Dialect][]string
+}
+
+// migrations are append only: once released, a migration must never change.
+var migrations = []migration{
+	{
+		version: 1,
+		statements: map[Dialect][]string{
+			DialectSQLite: {
+				`CREATE TABLE settings (
+					id TEXT PRIMARY KEY,
+					server_code TEXT NOT NULL,
+					udid TEXT NOT NULL DEFAULT '',
+					short_udid INTEGER NOT NULL DEFAULT 0,
+					viewer_id INTEGER NOT NULL DEFAULT 0,
+					credential_key_id TEXT NOT NULL DEFAULT '',
+					credential_data_key BLOB,
+					credential_ciphertext BLOB,
+					guess_start_version TEXT NOT NULL DEFAULT ''
+				)`,
+				`CREATE TABLE versions (
+					id TEXT PRIMARY KEY,
+					server_code TEXT NOT NULL,
+					app_version TEXT NOT NULL,
+					res_version TEXT NOT NULL,
+					created_at TIMESTAMP NOT NULL,
+					updated_at TIMESTAMP NOT NULL
+				)`,
+				`CREATE TABLE histories (
+					seq INTEGER PRIMARY KEY AUTOINCREMENT,
+					id TEXT NOT NULL,
+					server_code TEXT NOT NULL,
+					app_version TEXT NOT NULL,
+					res_version TEXT NOT NULL,
+					created_at TIMESTAMP NOT NULL,
+					updated_at TIMESTAMP NOT NULL
+				)`,
+				`CREATE INDEX histories_id_seq ON histories (id, seq)`,
+			},
+			DialectPostgres: {
+				`CREATE TABLE settings (
+					id TEXT PRIMARY KEY,
+					server_code TEXT NOT NULL,
+					udid TEXT NOT NULL DEFAULT '',
+					short_udid INTEGER NOT NULL DEFAULT 0,
+					viewer_id INTEGER NOT NULL DEFAULT 0,
+					credential_key_id TEXT NOT NULL DEFAULT '',
+					credential_data_key BYTEA,
+					credential_ciphertext BYTEA,
+					guess_start_version TEXT NOT NULL DEFAULT ''
+				)`,
+				`CREATE TABLE versions (
+					id TEXT PRIMARY KEY,
+					server_code TEXT NOT NULL,
+					app_version TEXT NOT NULL,
+					res_version TEXT NOT NULL,
+					created_at TIMESTAMPTZ NOT NULL,
+					updated_at TIMESTAMPTZ NOT NULL
+				)`,
+				`CREATE TABLE histories (
+					seq BIGSERIAL PRIMARY KEY,
+					id TEXT NOT NULL,
+					server_code TEXT NOT NULL,
+					app_version TEXT NOT NULL,
+					res_version TEXT NOT NULL,
+					created_at TIMESTAMPTZ NOT NULL,
+					updated_at TIMESTAMPTZ NOT NULL
+				)`,
+				`CREATE INDEX histories_id_seq ON histories (id, seq)`,
+			},
+		},
+	},
+	{
+		version: 2,
+		statements: map[Dialect][]string{
+			DialectSQLite: {
+				`CREATE TABLE webhook_deliveries (
+					id TEXT PRIMARY KEY,
+					url TEXT NOT NULL,
+					event_id TEXT NOT NULL,
+					body BLOB NOT NULL,
+					attempts INTEGER NOT NULL,
+					last_error TEXT NOT NULL,
+					first_failed_at TIMESTAMP NOT NULL,
+					last_attempt_at TIMESTAMP NOT NULL
+				)`,
+			},
+			DialectPostgres: {
+				`CREATE TABLE webhook_deliveries (
+					id TEXT PRIMARY KEY,
+					url TEXT NOT NULL,
+					event_id TEXT NOT NULL,
+					body BYTEA NOT NULL,
+					attempts INTEGER NOT NULL,
+					last_error TEXT NOT NULL,
+					first_failed_at TIMESTAMPTZ NOT NULL,
+					last_attempt_at TIMESTAMPTZ NOT NULL
+				)`,
+			},
+		},
+	},
+	{
+		version: 3,
+		statements: map[Dialect][]string{
+			DialectSQLite: {
+				`CREATE TABLE check_states (
+					id TEXT PRIMARY KEY,
+					consecutive_failures INTEGER NOT NULL,
+					last_failure_class TEXT NOT NULL,
+					last_checked_at TIMESTAMP NOT NULL,
+					last_success_at TIMESTAMP,
+					last_changed_at TIMESTAMP NOT NULL,
+					alerts TEXT NOT NULL
+				)`,
+			},
+			DialectPostgres: {
+				`CREATE TABLE check_states (
+					id TEXT PRIMARY KEY,
+					consecutive_failures INTEGER NOT NULL,
+					last_failure_class TEXT NOT NULL,
+					last_checked_at TIMESTAMPTZ NOT NULL,
+					last_success_at TIMESTAMPTZ,
+					last_changed_at TIMESTAMPTZ NOT NULL,
+					alerts TEXT NOT NULL
+				)`,
+			},
+		},
+	},
+	{
+		version: 4,
+		statements: map[Dialect][]string{
+			DialectSQLite: {
+				`CREATE TABLE checks (
+					id TEXT PRIMARY KEY,
+					setting_id TEXT NOT NULL,
+					server_code TEXT NOT NULL,
+					outcome TEXT NOT NULL,
+					failure_class TEXT NOT NULL,
+					failure TEXT NOT NULL,
+					before_app_version TEXT,
+					before_res_version TEXT,
+					after_app_version TEXT,
+					after_res_version TEXT,
+					probes_sent INTEGER NOT NULL,
+					probes_hits INTEGER NOT NULL,
+					probes_errors INTEGER NOT NULL,
+					trace_id TEXT NOT NULL,
+					started_at TIMESTAMP NOT NULL,
+					finished_at TIMESTAMP NOT NULL,
+					expires_at TIMESTAMP
+				)`,
+				`CREATE INDEX checks_setting_id_started_at ON checks (setting_id, started_at)`,
+				`CREATE INDEX checks_started_at ON checks (started_at)`,
+				`CREATE INDEX checks_expires_at ON checks (expires_at)`,
+			},
+			DialectPostgres: {
+				`CREATE TABLE checks (
+					id TEXT PRIMARY KEY,
+					setting_id TEXT NOT NULL,
+					server_code TEXT NOT NULL,
+					outcome TEXT NOT NULL,
+					failure_class TEXT NOT NULL,
+					failure TEXT NOT NULL,
+					before_app_version TEXT,
+					before_res_version TEXT,
+					after_app_version TEXT,
+					after_res_version TEXT,
+					probes_sent INTEGER NOT NULL,
+					probes_hits INTEGER NOT NULL,
+					probes_errors INTEGER NOT NULL,
+					trace_id TEXT NOT NULL,
+					started_at TIMESTAMPTZ NOT NULL,
+					finished_at TIMESTAMPTZ NOT NULL,
+					expires_at TIMESTAMPTZ
+				)`,
+				`CREATE INDEX checks_setting_id_started_at ON checks (setting_id, started_at)`,
+				`CREATE INDEX checks_started_at ON checks (started_at)`,
+				`CREATE INDEX checks_expires_at ON checks (expires_at)`,
+			},
+		},
+	},
+	{
+		version: 5,
+		statements: map[Dialect][]string{
+			DialectSQLite: {
+				`ALTER TABLE settings ADD COLUMN jp_platforms TEXT NOT NULL DEFAULT ''`,
+				`ALTER TABLE settings ADD COLUMN jp_locales TEXT NOT NULL DEFAULT ''`,
+			},
+			DialectPostgres: {
+				`ALTER TABLE settings ADD COLUMN jp_platforms TEXT NOT NULL DEFAULT ''`,
+				`ALTER TABLE settings ADD COLUMN jp_locales TEXT NOT NULL DEFAULT ''`,
+			},
+		},
+	},
+}
+
+// Latest is the schema version Migrate brings a database to.
+func Latest() int {
+	return migrations[len(migrations)-1].version
+}
+
+// Version returns the schema version of db, 0 for an empty database. It
+// creates the schema_migrations table when missing.
+func Version(ctx context.Context, db *sql.DB) (int, error) {
+	_, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER PRIMARY KEY)`)
+	if err != nil {
+		return 0, fmt.Errorf("create schema_migrations: %w", err)
+	}
+
+	var current int
+	err = db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current)
+	if err != nil {
+		return 0, fmt.Errorf("read schema version: %w", err)
+	}
+	return current, nil
+}
+
+// Migrate applies every migration newer than the recorded schema version,
+// each one in its own transaction.
+func Migrate(ctx context.Context, db *sql.DB, d Dialect) error {
+	if _, err := ParseDialect(string(d)); err != nil {
+		return err
+	}
+
+	current, err := Version(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+
+		err := apply(ctx, db, d, m)
+		if err != nil {
+			return fmt.Errorf("migration %d: %w", m.version, err)
+		}
+	}
+
+	return nil
+}
+
+func apply(ctx context.Context, db *sql.DB, d Dialect, m migration) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, statement := range m.statements[d] {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, d.Rebind(`INSERT INTO schema_migrations (version) VALUES (?)`), m.version)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
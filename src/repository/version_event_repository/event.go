@@ -0,0 +1,126 @@
+package version_event_repository
+
+import (
+	"fmt"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/cloudevent"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/cryptography"
+	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
+	"time"
+)
+
+// schemaBaseURL is where the JSON Schemas under /schemas are published.
+// A data schema version is bumped only for breaking changes; adding an
+// optional field keeps the current version.
+const schemaBaseURL = "https://raw.githubusercontent.com/SpeedxPz/pcrd-version-updater/main/schemas"
+
+// eventTypes maps use case event types to CloudEvent types. The data of
+// every type is versionEventDataV1, each with its own schema so the types
+// can evolve apart.
+var eventTypes = map[use_case.VersionEventType]string{
+	use_case.VersionEventCreated:         "pcrd.version.created",
+	use_case.VersionEventAppChanged:      "pcrd.version.app_changed",
+	use_case.VersionEventResourceChanged: "pcrd.version.resource_changed",
+	use_case.VersionEventCheckFailed:     "pcrd.check.failed",
+	use_case.VersionEventSnapshot:        "pcrd.version.snapshot",
+	use_case.VersionEventAlertRaised:     "pcrd.alert.raised",
+	use_case.VersionEventAlertResolved:   "pcrd.alert.resolved",
+}
+
+func eventSchema(eventType string) string {
+	return schemaBaseURL + "/" + eventType + "/v1.json"
+}
+
+// versionEventDataV1 is described by schemas/<type>/v1.json.
+type versionEventDataV1 struct {
+	ID         string         `json:"id"`
+	ServerCode string         `json:"serverCode,omitempty"`
+	Before     *versionDataV1 `json:"before"`
+	After      *versionDataV1 `json:"after"`
+	Failure    *failureDataV1 `json:"failure,omitempty"`
+	Alert      *alertDataV1   `json:"alert,omitempty"`
+	OccurredAt time.Time      `json:"occurredAt"`
+}
+
+type versionDataV1 struct {
+	AppVersion string `json:"appVersion"`
+	ResVersion string `json:"resVersion"`
+}
+
+type failureDataV1 struct {
+	Class   string `json:"class"`
+	Message string `json:"message"`
+}
+
+type alertDataV1 struct {
+	Type                string     `json:"type"`
+	ConsecutiveFailures int        `json:"consecutiveFailures"`
+	LastFailureClass    string     `json:"lastFailureClass,omitempty"`
+	LastSuccessAt       *time.Time `json:"lastSuccessAt"`
+	LastChangedAt       time.Time  `json:"lastChangedAt"`
+}
+
+func newAlertDataV1(a *use_case.Alert) *alertDataV1 {
+	data := &alertDataV1{
+		Type:                string(a.Type),
+		ConsecutiveFailures: a.ConsecutiveFailures,
+		LastFailureClass:    string(a.LastFailureClass),
+		LastChangedAt:       a.LastChangedAt,
+	}
+	if !a.LastSuccessAt.IsZero() {
+		data.LastSuccessAt = &a.LastSuccessAt
+	}
+	return data
+}
+
+func newVersionDataV1(v *use_case.PcrdVersion) *versionDataV1 {
+	if v == nil {
+		return nil
+	}
+	return &versionDataV1{AppVersion: v.AppVersion, ResVersion: v.ResVersion}
+}
+
+func (v *versionDataV1) String() string {
+	if v == nil {
+		return ""
+	}
+	return v.AppVersion + "/" + v.ResVersion
+}
+
+// newVersionEvent wraps e in a CloudEvent. A version change's ID is derived
+// from the change itself, so publishing it twice yields the same ID and
+// consumers can deduplicate. Every failed check and every alert is a
+// distinct event.
+func newVersionEvent(source string, e use_case.VersionEvent) (cloudevent.Event, error) {
+	eventType, ok := eventTypes[e.Type]
+	if !ok {
+		return cloudevent.Event{}, fmt.Errorf("unknown event type %s", e.Type)
+	}
+
+	data := versionEventDataV1{
+		ID:         e.Setting.ID,
+		ServerCode: string(e.Setting.ServerCode),
+		Before:     newVersionDataV1(e.Before),
+		After:      newVersionDataV1(e.After),
+		OccurredAt: e.Time,
+	}
+
+	ID := cryptography.MakeSHA1(fmt.Sprintf("%s|%s|%s|%s", eventType, e.Setting.ID, data.Before, data.After))
+	if e.Failure != nil {
+		data.Failure = &failureDataV1{Class: string(e.Failure.Class), Message: e.Failure.Message}
+		ID = cryptography.MakeSHA1(fmt.Sprintf("%s|%s|%s|%d", eventType, e.Setting.ID, e.Failure.Class, e.Time.UnixNano()))
+	}
+	if e.Alert != nil {
+		data.Alert = newAlertDataV1(e.Alert)
+		ID = cryptography.MakeSHA1(fmt.Sprintf("%s|%s|%s|%d", eventType, e.Setting.ID, e.Alert.Type, e.Time.UnixNano()))
+	}
+
+	return cloudevent.New(
+		ID,
+		eventType,
+		source,
+		e.Setting.ID,
+		eventSchema(eventType),
+		e.Time,
+		data,
+	)
+}
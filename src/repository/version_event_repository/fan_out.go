@@ -0,0 +1,288 @@
+package version_event_repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/clock"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/logger"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/setting"
+	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrInvalidPolicy   = errors.New("unknow sink policy")
+	ErrInvalidSinkSpec = errors.New("invalid sink spec")
+	ErrNoRedelivery    = errors.New("no sink keeps failed deliveries")
+)
+
+// Policy decides how a sink's failure affects the publish.
+type Policy string
+
+const (
+	// PolicyRequired fails the publish when the sink fails.
+	PolicyRequired Policy = "required"
+	// PolicyBestEffort waits for the sink but only logs its failure.
+	PolicyBestEffort Policy = "best-effort"
+	// PolicyAsync publishes in the background; FanOut.Close waits for it.
+	PolicyAsync Policy = "async"
+)
+
+// asyncTimeout bounds a background publish, which no longer follows the
+// caller's context.
+const asyncTimeout = 30 * time.Second
+
+func ParsePolicy(s string) (d Policy, e error) {
+	dataTypes := map[Policy]struct{}{
+		PolicyRequired:   {},
+		PolicyBestEffort: {},
+		PolicyAsync:      {},
+	}
+
+	dat := Policy(s)
+	_, ok := dataTypes[dat]
+	if !ok {
+		return d, fmt.Errorf("cannot parse:[%s] as sink policy: %w", s, ErrInvalidPolicy)
+	}
+	return dat, nil
+}
+
+// SinkSpec declares a sink by name, its policy and the server codes routed
+// to it. No server codes means every event.
+type SinkSpec struct {
+	Name        string
+	Policy      Policy
+	ServerCodes []setting.ServerCode
+}
+
+// ParseSinkSpecs parses comma-separated `name[:policy[:server|server...]]`
+// entries, e.g. `kafka:required,webhook:async:jp`. The policy defaults to
+// required.
+func ParseSinkSpecs(s string) ([]SinkSpec, error) {
+	var specs []SinkSpec
+	seen := map[string]struct{}{}
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if len(entry) <= 0 {
+			continue
+		}
+
+		parts := strings.Split(entry, ":")
+		if len(parts) > 3 || len(parts[0]) <= 0 {
+			return nil, fmt.Errorf("cannot parse:[%s] as sink: %w", entry, ErrInvalidSinkSpec)
+		}
+		if _, ok := seen[parts[0]]; ok {
+			return nil, fmt.Errorf("sink %s declared twice: %w", parts[0], ErrInvalidSinkSpec)
+		}
+		seen[parts[0]] = struct{}{}
+
+		spec := SinkSpec{Name: parts[0], Policy: PolicyRequired}
+		if len(parts) > 1 && len(parts[1]) > 0 {
+			policy, err := ParsePolicy(parts[1])
+			if err != nil {
+				return nil, err
+			}
+			spec.Policy = policy
+		}
+		if len(parts) > 2 {
+			for _, code := range strings.Split(parts[2], "|") {
+				serverCode, err := setting.ParseServerCode(code)
+				if err != nil || serverCode == setting.ServerCodeNone {
+					return nil, fmt.Errorf("cannot parse:[%s] as sink server code: %w", code, ErrInvalidSinkSpec)
+				}
+				spec.ServerCodes = append(spec.ServerCodes, serverCode)
+			}
+		}
+
+		specs = append(specs, spec)
+	}
+
+	if len(specs) <= 0 {
+		return nil, fmt.Errorf("no sink declared: %w", ErrInvalidSinkSpec)
+	}
+
+	return specs, nil
+}
+
+type Sink struct {
+	SinkSpec
+	Publisher use_case.VersionEventRepository
+}
+
+func (s Sink) routes(event use_case.VersionEvent) bool {
+	if len(s.ServerCodes) <= 0 {
+		return true
+	}
+	for _, code := range s.ServerCodes {
+		if code == event.Setting.ServerCode {
+			return true
+		}
+	}
+	return false
+}
+
+// FanOut publishes each event to every sink routed to its server code.
+type FanOut struct {
+	sinks []Sink
+	async sync.WaitGroup
+	clock clock.Clock
+}
+
+func (f *FanOut) PublishEvent(ctx context.Context, event use_case.VersionEvent) error {
+	ctx, span := tracer.Start(ctx, "version_event_repository.FanOut.PublishEvent")
+	defer span.End()
+
+	var failed []string
+	for _, sink := range f.sinks {
+		if !sink.routes(event) {
+			continue
+		}
+
+		if sink.Policy == PolicyAsync {
+			f.publishAsync(ctx, sink, event)
+			continue
+		}
+
+		err := sink.Publisher.PublishEvent(ctx, event)
+		if err == nil {
+			continue
+		}
+		zap.L().Error("sink publish failed", logger.WithTraceId(ctx), zap.String("sink", sink.Name), zap.String("policy", string(sink.Policy)), zap.Error(err))
+		if sink.Policy == PolicyRequired {
+			failed = append(failed, sink.Name)
+		}
+	}
+
+	if len(failed) > 0 {
+		span.SetStatus(codes.Error, fmt.Sprintf("required sinks failed: %s", strings.Join(failed, ", ")))
+		return fmt.Errorf("required sinks failed: %s: %w", strings.Join(failed, ", "), use_case.ErrVersionPublish)
+	}
+
+	return nil
+}
+
+// publishAsync runs the publish under its own span, linked to the caller's,
+// so it outlives the caller's context.
+func (f *FanOut) publishAsync(ctx context.Context, sink Sink, event use_case.VersionEvent) {
+	link := trace.LinkFromContext(ctx)
+	f.async.Add(1)
+	go func() {
+		defer f.async.Done()
+
+		ctx, cancel := f.clock.WithTimeout(context.Background(), asyncTimeout)
+		defer cancel()
+		ctx, span := tracer.Start(ctx, "version_event_repository.FanOut.publishAsync",
+			trace.WithLinks(link),
+			trace.WithAttributes(attribute.String("sink", sink.Name)),
+		)
+		defer span.End()
+
+		err := sink.Publisher.PublishEvent(ctx, event)
+		if err != nil {
+			zap.L().Error("sink publish failed", logger.WithTraceId(ctx), zap.String("sink", sink.Name), zap.String("policy", string(sink.Policy)), zap.Error(err))
+			span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+		}
+	}()
+}
+
+// Redeliver runs Redeliver of every sink that keeps failed deliveries.
+func (f *FanOut) Redeliver(ctx context.Context) (int, error) {
+	type redeliverer interface {
+		Redeliver(ctx context.Context) (int, error)
+	}
+
+	found := false
+	total := 0
+	var firstErr error
+	for _, sink := range f.sinks {
+		r, ok := sink.Publisher.(redeliverer)
+		if !ok {
+			continue
+		}
+		found = true
+
+		delivered, err := r.Redeliver(ctx)
+		total += delivered
+		if err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("%s: %w", sink.Name, err)
+		}
+	}
+
+	if !found {
+		return 0, fmt.Errorf("%w", ErrNoRedelivery)
+	}
+
+	return total, firstErr
+}
+
+// HealthCheck checks every sink that can check itself. Only a required sink
+// makes it fail, since the others cannot fail a run either.
+func (f *FanOut) HealthCheck(ctx context.Context) error {
+	var failed []string
+	var firstErr error
+	for _, sink := range f.sinks {
+		checker, ok := sink.Publisher.(use_case.HealthChecker)
+		if !ok {
+			continue
+		}
+
+		err := checker.HealthCheck(ctx)
+		if err == nil {
+			continue
+		}
+		if sink.Policy != PolicyRequired {
+			zap.L().Warn("sink health check failed", logger.WithTraceId(ctx), zap.String("sink", sink.Name), zap.String("policy", string(sink.Policy)), zap.Error(err))
+			continue
+		}
+		failed = append(failed, sink.Name)
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	if firstErr != nil {
+		return fmt.Errorf("sinks %s: %w", strings.Join(failed, ", "), firstErr)
+	}
+	return nil
+}
+
+// Close waits for async publishes to finish, or for ctx to be done, then
+// closes every sink that is an io.Closer so buffered events are flushed.
+func (f *FanOut) Close(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		f.async.Wait()
+		close(done)
+	}()
+
+	var firstErr error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		firstErr = ctx.Err()
+	}
+
+	for _, sink := range f.sinks {
+		closer, ok := sink.Publisher.(io.Closer)
+		if !ok {
+			continue
+		}
+		if err := closer.Close(); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("close %s: %w", sink.Name, err)
+		}
+	}
+
+	return firstErr
+}
+
+func NewFanOut(clk clock.Clock, sinks ...Sink) *FanOut {
+	return &FanOut{sinks: sinks, clock: clk}
+}
@@ -0,0 +1,110 @@
+package version_event_repository
+
+import (
+	"context"
+	"errors"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/clock"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/setting"
+	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
+	"reflect"
+	"testing"
+)
+
+type failingPublisher struct{}
+
+func (failingPublisher) PublishEvent(ctx context.Context, event use_case.VersionEvent) error {
+	return use_case.ErrVersionPublish
+}
+
+func (failingPublisher) HealthCheck(ctx context.Context) error {
+	return use_case.ErrVersionPublish
+}
+
+func TestParseSinkSpecs(t *testing.T) {
+	got, err := ParseSinkSpecs("kafka, webhook:async:jp|th ,audit:best-effort")
+	if err != nil {
+		t.Fatalf("ParseSinkSpecs: %v", err)
+	}
+	want := []SinkSpec{
+		{Name: "kafka", Policy: PolicyRequired},
+		{Name: "webhook", Policy: PolicyAsync, ServerCodes: []setting.ServerCode{setting.ServerCodeJP, setting.ServerCodeTH}},
+		{Name: "audit", Policy: PolicyBestEffort},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("ParseSinkSpecs: got %+v, want %+v", got, want)
+	}
+
+	for _, spec := range []string{"", "kafka:sometimes", "kafka:required:kr", "kafka,kafka", "a:b:c:d"} {
+		if _, err := ParseSinkSpecs(spec); err == nil {
+			t.Errorf("ParseSinkSpecs(%q): want error", spec)
+		}
+	}
+}
+
+func TestFanOutRoutesAndPolicies(t *testing.T) {
+	ctx := context.Background()
+	all := NewMemory()
+	jpOnly := NewMemory()
+	async := NewMemory()
+	f := NewFanOut(clock.Real(),
+		Sink{SinkSpec: SinkSpec{Name: "all", Policy: PolicyRequired}, Publisher: all},
+		Sink{SinkSpec: SinkSpec{Name: "jp", Policy: PolicyRequired, ServerCodes: []setting.ServerCode{setting.ServerCodeJP}}, Publisher: jpOnly},
+		Sink{SinkSpec: SinkSpec{Name: "flaky", Policy: PolicyBestEffort}, Publisher: failingPublisher{}},
+		Sink{SinkSpec: SinkSpec{Name: "async", Policy: PolicyAsync}, Publisher: async},
+	)
+
+	if err := f.PublishEvent(ctx, testEvent); err != nil {
+		t.Fatalf("PublishEvent: %v", err)
+	}
+	if err := f.Close(ctx); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	if len(all.Published()) != 1 || len(async.Published()) != 1 {
+		t.Fatalf("got %d and %d events, want 1 each", len(all.Published()), len(async.Published()))
+	}
+	if len(jpOnly.Published()) != 0 {
+		t.Fatalf("th event routed to jp sink")
+	}
+}
+
+func TestFanOutRequiredFailure(t *testing.T) {
+	other := NewMemory()
+	f := NewFanOut(clock.Real(),
+		Sink{SinkSpec: SinkSpec{Name: "broken", Policy: PolicyRequired}, Publisher: failingPublisher{}},
+		Sink{SinkSpec: SinkSpec{Name: "other", Policy: PolicyRequired}, Publisher: other},
+	)
+
+	err := f.PublishEvent(context.Background(), testEvent)
+	if !errors.Is(err, use_case.ErrVersionPublish) {
+		t.Fatalf("PublishEvent: got %v, want %v", err, use_case.ErrVersionPublish)
+	}
+	if len(other.Published()) != 1 {
+		t.Fatalf("a failing sink stopped the others")
+	}
+}
+
+func TestFanOutRedeliverWithoutWebhook(t *testing.T) {
+	f := NewFanOut(clock.Real(), Sink{SinkSpec: SinkSpec{Name: "memory", Policy: PolicyRequired}, Publisher: NewMemory()})
+
+	_, err := f.Redeliver(context.Background())
+	if !errors.Is(err, ErrNoRedelivery) {
+		t.Fatalf("Redeliver: got %v, want %v", err, ErrNoRedelivery)
+	}
+}
+
+func TestFanOutHealthCheck(t *testing.T) {
+	ctx := context.Background()
+	f := NewFanOut(clock.Real(),
+		Sink{SinkSpec: SinkSpec{Name: "memory", Policy: PolicyRequired}, Publisher: NewMemory()},
+		Sink{SinkSpec: SinkSpec{Name: "flaky", Policy: PolicyBestEffort}, Publisher: failingPublisher{}},
+	)
+	if err := f.HealthCheck(ctx); err != nil {
+		t.Fatalf("HealthCheck with a failing best-effort sink: %v", err)
+	}
+
+	f = NewFanOut(clock.Real(), Sink{SinkSpec: SinkSpec{Name: "broken", Policy: PolicyRequired}, Publisher: failingPublisher{}})
+	if err := f.HealthCheck(ctx); !errors.Is(err, use_case.ErrVersionPublish) {
+		t.Fatalf("HealthCheck with a failing required sink: got %v, want %v", err, use_case.ErrVersionPublish)
+	}
+}
@@ -2,52 +2,63 @@ package version_event_repository
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/cloudevent"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/kafka_trace"
 	"github.com/SpeedxPz/pcrd-version-updater/src/entity/logger"
 	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
 	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
 	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"net"
+	"os"
+	"sort"
+	"strings"
 	"time"
 )
 
-type kafkaMQVersionEvent struct {
-	ID             string    `json:"id"`
-	ServerCode     string    `json:"serverCode"`
-	AppVersion     string    `json:"appVersion"`
-	ResVersion     string    `json:"resVersion"`
-	UpdateDateTime time.Time `json:"updatedAt"`
-}
+var ErrInvalidKafkaConfig = errors.New("invalid kafka config")
 
 type kafkaMQ struct {
 	client *kafka.Writer
+	mode   cloudevent.Mode
+	source string
 }
 
-func (k kafkaMQ) PublishVersion(ctx context.Context, version use_case.GameVersion) error {
-	ctx, span := tracer.Start(ctx, "version_event_repository.PublishVersion")
+func (k kafkaMQ) PublishEvent(ctx context.Context, event use_case.VersionEvent) error {
+	ctx, span := tracer.Start(ctx, "version_event_repository.PublishEvent",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			semconv.MessagingSystemKey.String("kafka"),
+			semconv.MessagingDestinationKey.String(k.client.Topic),
+			semconv.MessagingDestinationKindTopic,
+		),
+	)
 	defer span.End()
 
-	ver := kafkaMQVersionEvent{
-		ID:             version.Setting.ID,
-		ServerCode:     string(version.Setting.ServerCode),
-		AppVersion:     version.AppVersion,
-		ResVersion:     version.ResVersion,
-		UpdateDateTime: time.Now(),
+	ce, err := newVersionEvent(k.source, event)
+	if err != nil {
+		zap.L().Error("error while saving data", logger.WithTraceId(ctx), zap.Any("error", err), zap.Any("event", event))
+		span.SetStatus(codes.Error, fmt.Sprintf("error while saving data %+v: %s", event, err))
+		return fmt.Errorf("error while saving data: %w", use_case.ErrVersionPublish)
 	}
 
-	messageBytes, err := json.Marshal(ver)
+	message, err := k.newMessage(ce)
 	if err != nil {
-		zap.L().Error("error while saving data", logger.WithTraceId(ctx), zap.Any("error", err), zap.Any("Ver", ver))
-		span.SetStatus(codes.Error, fmt.Sprintf("error while saving data %+v: %s", ver, err))
+		zap.L().Error("error while saving data", logger.WithTraceId(ctx), zap.Any("error", err), zap.Any("event", event))
+		span.SetStatus(codes.Error, fmt.Sprintf("error while saving data %+v: %s", event, err))
 		return fmt.Errorf("error while saving data: %w", use_case.ErrVersionPublish)
 	}
 
-	message := kafka.Message{
-		Key:   []byte(fmt.Sprintf("%s", ver.ID)),
-		Value: messageBytes,
-	}
+	kafka_trace.Inject(ctx, &message)
 
 	err = k.client.WriteMessages(ctx, message)
 	if err != nil {
@@ -59,21 +70,233 @@ func (k kafkaMQ) PublishVersion(ctx context.Context, version use_case.GameVersio
 	return nil
 }
 
-func NewKafkaMQ(boostrapServer string, topic string) use_case.VersionEventRepository {
-	var w kafka.Writer
+// newMessage follows the CloudEvents Kafka protocol binding: binary mode
+// puts the attributes in ce_ headers and the data in the value, structured
+// mode puts the whole event in the value.
+func (k kafkaMQ) newMessage(event cloudevent.Event) (kafka.Message, error) {
+	message := kafka.Message{
+		Key:  []byte(event.Subject),
+		Time: event.Time,
+	}
+
+	if k.mode == cloudevent.ModeStructured {
+		value, err := json.Marshal(event)
+		if err != nil {
+			return kafka.Message{}, err
+		}
+		message.Value = value
+		message.Headers = []kafka.Header{
+			{Key: "content-type", Value: []byte(cloudevent.ContentTypeStructured)},
+		}
+		return message, nil
+	}
+
+	message.Value = event.Data
+	message.Headers = []kafka.Header{
+		{Key: "content-type", Value: []byte(event.DataContentType)},
+	}
+	attributes := event.Attributes()
+	names := make([]string, 0, len(attributes))
+	for name := range attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		message.Headers = append(message.Headers, kafka.Header{Key: "ce_" + name, Value: []byte(attributes[name])})
+	}
+
+	return message, nil
+}
+
+// HealthCheck asks the brokers for the metadata of the topic, which fails
+// when no broker is reachable or the topic does not exist.
+func (k kafkaMQ) HealthCheck(ctx context.Context) error {
+	ctx, span := tracer.Start(ctx, "version_event_repository.HealthCheck",
+		trace.WithAttributes(
+			semconv.MessagingSystemKey.String("kafka"),
+			semconv.MessagingDestinationKey.String(k.client.Topic),
+		),
+	)
+	defer span.End()
+
+	client := kafka.Client{Addr: k.client.Addr, Transport: k.client.Transport}
+	res, err := client.Metadata(ctx, &kafka.MetadataRequest{Topics: []string{k.client.Topic}})
+	if err != nil {
+		span.SetStatus(codes.Error, fmt.Sprintf("kafka metadata: %s", err))
+		return fmt.Errorf("kafka metadata: %s: %w", err, use_case.ErrVersionPublish)
+	}
+	for _, topic := range res.Topics {
+		if topic.Name == k.client.Topic && topic.Error != nil {
+			span.SetStatus(codes.Error, fmt.Sprintf("kafka topic %s: %s", topic.Name, topic.Error))
+			return fmt.Errorf("kafka topic %s: %s: %w", topic.Name, topic.Error, use_case.ErrVersionPublish)
+		}
+	}
+	return nil
+}
+
+// Close flushes pending messages and closes the connections.
+func (k kafkaMQ) Close() error {
+	return k.client.Close()
+}
+
+// KafkaConfig configures the Kafka producer. Zero values of RequiredAcks,
+// Compression and BatchTimeout select all, none and one second.
+type KafkaConfig struct {
+	Brokers []string
+	Topic   string
+
+	TLS bool
+	// TLSCAFile is a PEM bundle trusted instead of the system roots.
+	TLSCAFile             string
+	TLSInsecureSkipVerify bool
+
+	// SASLMechanism is empty, plain, scram-sha-256 or scram-sha-512.
+	SASLMechanism string
+	SASLUsername  string
+	SASLPassword  string
+
+	// RequiredAcks is all, one or none.
+	RequiredAcks string
+	// Compression is none, gzip, snappy, lz4 or zstd.
+	Compression  string
+	BatchTimeout time.Duration
+}
+
+var (
+	kafkaRequiredAcks = map[string]kafka.RequiredAcks{
+		"all":  kafka.RequireAll,
+		"one":  kafka.RequireOne,
+		"none": kafka.RequireNone,
+	}
+	kafkaCompressions = map[string]kafka.Compression{
+		"none":   0,
+		"gzip":   kafka.Gzip,
+		"snappy": kafka.Snappy,
+		"lz4":    kafka.Lz4,
+		"zstd":   kafka.Zstd,
+	}
+)
+
+// Validate reports every misconfiguration at once, so a deployment can be
+// fixed in one go.
+func (c KafkaConfig) Validate() error {
+	var problems []string
+
+	if len(c.Brokers) <= 0 {
+		problems = append(problems, "no broker")
+	}
+	for _, broker := range c.Brokers {
+		if _, _, err := net.SplitHostPort(broker); err != nil {
+			problems = append(problems, fmt.Sprintf("broker %q: %s", broker, err))
+		}
+	}
+	if len(c.Topic) <= 0 {
+		problems = append(problems, "no topic")
+	}
+	if !c.TLS && (len(c.TLSCAFile) > 0 || c.TLSInsecureSkipVerify) {
+		problems = append(problems, "TLS options set but TLS disabled")
+	}
+	switch c.SASLMechanism {
+	case "":
+		if len(c.SASLUsername) > 0 || len(c.SASLPassword) > 0 {
+			problems = append(problems, "SASL credentials set without a mechanism")
+		}
+	case "plain", "scram-sha-256", "scram-sha-512":
+		if len(c.SASLUsername) <= 0 || len(c.SASLPassword) <= 0 {
+			problems = append(problems, fmt.Sprintf("SASL %s needs a username and password", c.SASLMechanism))
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("unknown SASL mechanism %q", c.SASLMechanism))
+	}
+	if _, ok := kafkaRequiredAcks[c.RequiredAcks]; !ok && len(c.RequiredAcks) > 0 {
+		problems = append(problems, fmt.Sprintf("unknown required acks %q", c.RequiredAcks))
+	}
+	if _, ok := kafkaCompressions[c.Compression]; !ok && len(c.Compression) > 0 {
+		problems = append(problems, fmt.Sprintf("unknown compression %q", c.Compression))
+	}
+	if c.BatchTimeout < 0 {
+		problems = append(problems, "negative batch timeout")
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%s: %w", strings.Join(problems, "; "), ErrInvalidKafkaConfig)
+	}
+	return nil
+}
+
+func (c KafkaConfig) transport() (*kafka.Transport, error) {
+	t := &kafka.Transport{
+		Dial: (&net.Dialer{
+			Timeout: 10 * time.Second,
+		}).DialContext,
+	}
+
+	if c.TLS {
+		t.TLS = &tls.Config{
+			MinVersion:         tls.VersionTLS12,
+			InsecureSkipVerify: c.TLSInsecureSkipVerify,
+		}
+		if len(c.TLSCAFile) > 0 {
+			pem, err := os.ReadFile(c.TLSCAFile)
+			if err != nil {
+				return nil, fmt.Errorf("read TLS CA file: %s: %w", err, ErrInvalidKafkaConfig)
+			}
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("TLS CA file %s has no certificate: %w", c.TLSCAFile, ErrInvalidKafkaConfig)
+			}
+			t.TLS.RootCAs = pool
+		}
+	}
+
+	var err error
+	switch c.SASLMechanism {
+	case "plain":
+		t.SASL = plain.Mechanism{Username: c.SASLUsername, Password: c.SASLPassword}
+	case "scram-sha-256":
+		t.SASL, err = scram.Mechanism(scram.SHA256, c.SASLUsername, c.SASLPassword)
+	case "scram-sha-512":
+		t.SASL, err = scram.Mechanism(scram.SHA512, c.SASLUsername, c.SASLPassword)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("SASL %s: %s: %w", c.SASLMechanism, err, ErrInvalidKafkaConfig)
+	}
+
+	return t, nil
+}
+
+// NewKafkaMQ validates cfg and builds the producer. The returned publisher
+// implements io.Closer; close it to flush before exiting.
+func NewKafkaMQ(cfg KafkaConfig, mode cloudevent.Mode, source string) (use_case.VersionEventRepository, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	transport, err := cfg.transport()
+	if err != nil {
+		return nil, err
+	}
+
+	requiredAcks := kafka.RequireAll
+	if len(cfg.RequiredAcks) > 0 {
+		requiredAcks = kafkaRequiredAcks[cfg.RequiredAcks]
+	}
+	batchTimeout := cfg.BatchTimeout
+	if batchTimeout <= 0 {
+		batchTimeout = time.Second
+	}
 
-	w = kafka.Writer{
-		Addr:     kafka.TCP(boostrapServer),
-		Topic:    topic,
-		Balancer: &kafka.Hash{},
-		Transport: &kafka.Transport{
-			Dial: (&net.Dialer{
-				Timeout: 10 * time.Second,
-			}).DialContext,
-		},
+	w := kafka.Writer{
+		Addr:         kafka.TCP(cfg.Brokers...),
+		Topic:        cfg.Topic,
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: requiredAcks,
+		Compression:  kafkaCompressions[cfg.Compression],
+		BatchTimeout: batchTimeout,
+		Transport:    transport,
 	}
 
-	k := kafkaMQ{client: &w}
+	k := kafkaMQ{client: &w, mode: mode, source: source}
 
-	return k
+	return k, nil
 }
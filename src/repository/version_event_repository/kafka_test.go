@@ -0,0 +1,248 @@
+package version_event_repository
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/cloudevent"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/setting"
+	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
+	"github.com/segmentio/kafka-go"
+	"io"
+	"testing"
+	"time"
+)
+
+const testSource = "/pcrd-version-updater"
+
+const testEventType = "pcrd.version.resource_changed"
+
+var testEvent = newTestEvent(time.Unix(1, 0))
+
+func newTestEvent(t time.Time) use_case.VersionEvent {
+	return use_case.VersionEvent{
+		Type:    use_case.VersionEventResourceChanged,
+		Setting: setting.Setting{ID: "th.app", ServerCode: setting.ServerCodeTH},
+		Before:  &use_case.PcrdVersion{AppVersion: "3.1.0", ResVersion: "00150000"},
+		After:   &use_case.PcrdVersion{AppVersion: "3.1.0", ResVersion: "00150010"},
+		Time:    t,
+	}
+}
+
+func header(m kafka.Message, key string) string {
+	for _, h := range m.Headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+func TestNewVersionEventIDIsStable(t *testing.T) {
+	a, err := newVersionEvent(testSource, testEvent)
+	if err != nil {
+		t.Fatalf("newVersionEvent: %v", err)
+	}
+	b, err := newVersionEvent(testSource, newTestEvent(time.Unix(2, 0)))
+	if err != nil {
+		t.Fatalf("newVersionEvent: %v", err)
+	}
+	if a.ID != b.ID {
+		t.Fatalf("same version got different ids %s and %s", a.ID, b.ID)
+	}
+
+	changed := newTestEvent(time.Unix(1, 0))
+	changed.After = &use_case.PcrdVersion{AppVersion: "3.1.0", ResVersion: "00150020"}
+	c, err := newVersionEvent(testSource, changed)
+	if err != nil {
+		t.Fatalf("newVersionEvent: %v", err)
+	}
+	if a.ID == c.ID {
+		t.Fatalf("different versions got the same id %s", a.ID)
+	}
+}
+
+func TestNewVersionEventCheckFailed(t *testing.T) {
+	failed := use_case.VersionEvent{
+		Type:    use_case.VersionEventCheckFailed,
+		Setting: setting.Setting{ID: "th.app", ServerCode: setting.ServerCodeTH},
+		Before:  &use_case.PcrdVersion{AppVersion: "3.1.0", ResVersion: "00150010"},
+		Failure: &use_case.CheckFailure{Class: use_case.FailureNotAvailable, Message: "maintenance"},
+		Time:    time.Unix(1, 0),
+	}
+	a, err := newVersionEvent(testSource, failed)
+	if err != nil {
+		t.Fatalf("newVersionEvent: %v", err)
+	}
+	failed.Time = time.Unix(2, 0)
+	b, err := newVersionEvent(testSource, failed)
+	if err != nil {
+		t.Fatalf("newVersionEvent: %v", err)
+	}
+	if a.ID == b.ID {
+		t.Fatalf("two failed checks got the same id %s", a.ID)
+	}
+
+	var data versionEventDataV1
+	if err := json.Unmarshal(a.Data, &data); err != nil {
+		t.Fatalf("data: %v", err)
+	}
+	if a.Type != "pcrd.check.failed" || data.After != nil || data.Before == nil || data.Failure == nil || data.Failure.Class != "not_available" {
+		t.Fatalf("got %s %+v", a.Type, data)
+	}
+}
+
+func TestNewVersionEventAlert(t *testing.T) {
+	event, err := newVersionEvent(testSource, use_case.VersionEvent{
+		Type:    use_case.VersionEventAlertRaised,
+		Setting: setting.Setting{ID: "th.app", ServerCode: setting.ServerCodeTH},
+		Alert: &use_case.Alert{
+			Type:                use_case.AlertRepeatedFailure,
+			ConsecutiveFailures: 3,
+			LastFailureClass:    use_case.FailureRemote,
+			LastChangedAt:       time.Unix(1, 0),
+		},
+		Time: time.Unix(2, 0),
+	})
+	if err != nil {
+		t.Fatalf("newVersionEvent: %v", err)
+	}
+
+	var data versionEventDataV1
+	if err := json.Unmarshal(event.Data, &data); err != nil {
+		t.Fatalf("data: %v", err)
+	}
+	if event.Type != "pcrd.alert.raised" || data.Alert == nil || data.Alert.Type != "repeated_failure" || data.Alert.ConsecutiveFailures != 3 {
+		t.Fatalf("got %s %+v", event.Type, data)
+	}
+	if data.Alert.LastSuccessAt != nil || data.Before != nil || data.After != nil {
+		t.Fatalf("got %+v, want no last success and no versions", data)
+	}
+}
+
+func TestKafkaBinaryMessage(t *testing.T) {
+	event, err := newVersionEvent(testSource, testEvent)
+	if err != nil {
+		t.Fatalf("newVersionEvent: %v", err)
+	}
+
+	m, err := kafkaMQ{mode: cloudevent.ModeBinary}.newMessage(event)
+	if err != nil {
+		t.Fatalf("newMessage: %v", err)
+	}
+
+	if string(m.Key) != "th.app" {
+		t.Fatalf("key: got %s", m.Key)
+	}
+	if !m.Time.Equal(event.Time) {
+		t.Fatalf("time: got %v, want the event time %v", m.Time, event.Time)
+	}
+	for key, want := range map[string]string{
+		"content-type":   cloudevent.ContentTypeJSON,
+		"ce_specversion": cloudevent.SpecVersion,
+		"ce_id":          event.ID,
+		"ce_type":        testEventType,
+		"ce_source":      testSource,
+		"ce_subject":     "th.app",
+		"ce_dataschema":  eventSchema(testEventType),
+	} {
+		if got := header(m, key); got != want {
+			t.Fatalf("header %s: got %q, want %q", key, got, want)
+		}
+	}
+
+	var data versionEventDataV1
+	if err := json.Unmarshal(m.Value, &data); err != nil {
+		t.Fatalf("value is not version data: %v", err)
+	}
+	if data.ID != "th.app" || data.Before.ResVersion != "00150000" || data.After.ResVersion != "00150010" {
+		t.Fatalf("value: got %+v", data)
+	}
+}
+
+func TestKafkaStructuredMessage(t *testing.T) {
+	event, err := newVersionEvent(testSource, testEvent)
+	if err != nil {
+		t.Fatalf("newVersionEvent: %v", err)
+	}
+
+	m, err := kafkaMQ{mode: cloudevent.ModeStructured}.newMessage(event)
+	if err != nil {
+		t.Fatalf("newMessage: %v", err)
+	}
+
+	if got := header(m, "content-type"); got != cloudevent.ContentTypeStructured {
+		t.Fatalf("content-type: got %q", got)
+	}
+
+	var got cloudevent.Event
+	if err := json.Unmarshal(m.Value, &got); err != nil {
+		t.Fatalf("value is not a cloudevent: %v", err)
+	}
+	if got.ID != event.ID || got.Type != testEventType || got.Subject != "th.app" || got.DataSchema != eventSchema(testEventType) {
+		t.Fatalf("value: got %+v", got)
+	}
+}
+
+func TestKafkaConfigValidate(t *testing.T) {
+	valid := KafkaConfig{
+		Brokers:       []string{"b1:9093", "b2:9093"},
+		Topic:         "versions",
+		TLS:           true,
+		SASLMechanism: "scram-sha-512",
+		SASLUsername:  "user",
+		SASLPassword:  "secret",
+		RequiredAcks:  "all",
+		Compression:   "zstd",
+	}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+
+	for name, mutate := range map[string]func(c *KafkaConfig){
+		"no broker":           func(c *KafkaConfig) { c.Brokers = nil },
+		"broker without port": func(c *KafkaConfig) { c.Brokers = []string{"b1"} },
+		"no topic":            func(c *KafkaConfig) { c.Topic = "" },
+		"ca without tls":      func(c *KafkaConfig) { c.TLS = false; c.TLSCAFile = "ca.pem" },
+		"unknown mechanism":   func(c *KafkaConfig) { c.SASLMechanism = "gssapi" },
+		"missing password":    func(c *KafkaConfig) { c.SASLPassword = "" },
+		"unknown acks":        func(c *KafkaConfig) { c.RequiredAcks = "two" },
+		"unknown codec":       func(c *KafkaConfig) { c.Compression = "brotli" },
+	} {
+		c := valid
+		mutate(&c)
+		if err := c.Validate(); !errors.Is(err, ErrInvalidKafkaConfig) {
+			t.Errorf("%s: got %v, want %v", name, err, ErrInvalidKafkaConfig)
+		}
+	}
+}
+
+func TestNewKafkaMQ(t *testing.T) {
+	repo, err := NewKafkaMQ(KafkaConfig{
+		Brokers:       []string{"b1:9093", "b2:9093"},
+		Topic:         "versions",
+		TLS:           true,
+		SASLMechanism: "scram-sha-256",
+		SASLUsername:  "user",
+		SASLPassword:  "secret",
+		Compression:   "snappy",
+		BatchTimeout:  10 * time.Millisecond,
+	}, cloudevent.ModeBinary, testSource)
+	if err != nil {
+		t.Fatalf("NewKafkaMQ: %v", err)
+	}
+
+	w := repo.(kafkaMQ).client
+	if w.RequiredAcks != kafka.RequireAll || w.Compression != kafka.Snappy || w.BatchTimeout != 10*time.Millisecond {
+		t.Fatalf("writer: got acks %v, compression %v, batch timeout %v", w.RequiredAcks, w.Compression, w.BatchTimeout)
+	}
+	if w.Addr.String() != "b1:9093,b2:9093" {
+		t.Fatalf("writer addr: got %s", w.Addr)
+	}
+	transport := w.Transport.(*kafka.Transport)
+	if transport.TLS == nil || transport.SASL == nil || transport.SASL.Name() != "SCRAM-SHA-256" {
+		t.Fatalf("transport: got TLS %v, SASL %v", transport.TLS, transport.SASL)
+	}
+	if err := repo.(io.Closer).Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+}
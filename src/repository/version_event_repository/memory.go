@@ -0,0 +1,33 @@
+package version_event_repository
+
+import (
+	"context"
+	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
+	"sync"
+)
+
+// Memory records published events. It is exported so tests can inspect
+// what was published.
+type Memory struct {
+	mu        sync.RWMutex
+	published []use_case.VersionEvent
+}
+
+func (m *Memory) PublishEvent(ctx context.Context, event use_case.VersionEvent) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.published = append(m.published, event)
+	return nil
+}
+
+func (m *Memory) Published() []use_case.VersionEvent {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return append([]use_case.VersionEvent(nil), m.published...)
+}
+
+func NewMemory() *Memory {
+	return &Memory{}
+}
@@ -0,0 +1,234 @@
+package version_event_repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/clock"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/cloudevent"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/cryptography"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/logger"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/webhook_signature"
+	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// errRetryable marks a delivery failure worth another attempt: a network
+// error, a 5xx or a 429. Any other status is final.
+var errRetryable = errors.New("retryable")
+
+// errNotSaved marks a failed delivery that could not be saved either, so
+// only the caller can still get it out.
+var errNotSaved = errors.New("not saved for redelivery")
+
+// Webhook POSTs each version as a structured CloudEvent to every URL, signed
+// with webhook_signature. A delivery that still fails after maxAttempts, or
+// once ctx has no time left for the next one, is saved to deliveries so
+// Redeliver can send it later; only then does PublishEvent fail, as
+// publishing again would resend the event to the URLs that got it.
+type Webhook struct {
+	client      *http.Client
+	urls        []string
+	secret      string
+	source      string
+	maxAttempts int
+	backoff     time.Duration
+	deliveries  use_case.DeliveryRepository
+	clock       clock.Clock
+}
+
+func (w *Webhook) PublishEvent(ctx context.Context, event use_case.VersionEvent) error {
+	ctx, span := tracer.Start(ctx, "version_event_repository.PublishEvent",
+		trace.WithSpanKind(trace.SpanKindProducer),
+	)
+	defer span.End()
+
+	ce, err := newVersionEvent(w.source, event)
+	if err != nil {
+		zap.L().Error("error while saving data", logger.WithTraceId(ctx), zap.Any("error", err), zap.Any("event", event))
+		span.SetStatus(codes.Error, fmt.Sprintf("error while saving data %+v: %s", event, err))
+		return fmt.Errorf("error while saving data: %w", use_case.ErrVersionPublish)
+	}
+
+	body, err := json.Marshal(ce)
+	if err != nil {
+		zap.L().Error("error while saving data", logger.WithTraceId(ctx), zap.Any("error", err), zap.Any("event", event))
+		span.SetStatus(codes.Error, fmt.Sprintf("error while saving data %+v: %s", event, err))
+		return fmt.Errorf("error while saving data: %w", use_case.ErrVersionPublish)
+	}
+
+	failed, saved := 0, 0
+	for _, url := range w.urls {
+		now := w.clock.Now()
+		d := use_case.FailedDelivery{
+			ID:            cryptography.MakeSHA1(fmt.Sprintf("%s|%s", ce.ID, url)),
+			URL:           url,
+			EventID:       ce.ID,
+			Body:          body,
+			FirstFailedAt: now,
+			LastAttemptAt: now,
+		}
+		err := w.deliver(ctx, &d)
+		switch {
+		case errors.Is(err, errNotSaved):
+			failed++
+		case err != nil:
+			saved++
+		}
+	}
+
+	if saved > 0 {
+		span.SetStatus(codes.Error, fmt.Sprintf("%d of %d webhooks saved for redelivery", saved, len(w.urls)))
+	}
+	if failed > 0 {
+		span.SetStatus(codes.Error, fmt.Sprintf("%d of %d webhooks failed", failed, len(w.urls)))
+		return fmt.Errorf("%d of %d webhooks failed: %w", failed, len(w.urls), use_case.ErrVersionPublish)
+	}
+
+	return nil
+}
+
+// Redeliver sends the saved failed deliveries again, oldest first, and
+// returns how many succeeded. A delivery that fails again stays saved with
+// its attempts increased.
+func (w *Webhook) Redeliver(ctx context.Context) (int, error) {
+	ctx, span := tracer.Start(ctx, "version_event_repository.Redeliver")
+	defer span.End()
+
+	deliveries, err := w.deliveries.ListFailedDeliveries(ctx)
+	if err != nil {
+		span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+		return 0, err
+	}
+
+	delivered := 0
+	for i := range deliveries {
+		if err := w.deliver(ctx, &deliveries[i]); err != nil {
+			continue
+		}
+		err := w.deliveries.DeleteFailedDelivery(ctx, deliveries[i].ID)
+		if err != nil && !errors.Is(err, use_case.ErrDeliveryNotFound) {
+			span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+			return delivered, err
+		}
+		delivered++
+	}
+
+	if delivered < len(deliveries) {
+		span.SetStatus(codes.Error, fmt.Sprintf("%d of %d redeliveries failed", len(deliveries)-delivered, len(deliveries)))
+		return delivered, fmt.Errorf("%d of %d redeliveries failed: %w", len(deliveries)-delivered, len(deliveries), use_case.ErrVersionPublish)
+	}
+
+	return delivered, nil
+}
+
+// deliver tries d up to maxAttempts times, doubling the wait after each
+// retryable failure, but never waits past ctx's deadline. When every attempt
+// fails, d is saved with its attempts and last error; the error returned
+// wraps errNotSaved if that fails too.
+func (w *Webhook) deliver(ctx context.Context, d *use_case.FailedDelivery) error {
+	var err error
+	wait := w.backoff
+	for attempt := 1; attempt <= w.maxAttempts; attempt++ {
+		d.Attempts++
+		d.LastAttemptAt = w.clock.Now()
+
+		err = w.send(ctx, *d)
+		if err == nil {
+			return nil
+		}
+		d.LastError = err.Error()
+		zap.L().Warn("webhook delivery failed", logger.WithTraceId(ctx), zap.String("url", d.URL), zap.String("eventId", d.EventID), zap.Int("attempt", attempt), zap.Error(err))
+
+		if !errors.Is(err, errRetryable) || attempt == w.maxAttempts {
+			break
+		}
+		if deadline, ok := ctx.Deadline(); ok && w.clock.Now().Add(wait).After(deadline) {
+			break
+		}
+		if sleepErr := w.clock.Sleep(ctx, wait); sleepErr != nil {
+			d.LastError = sleepErr.Error()
+			break
+		}
+		wait *= 2
+	}
+
+	// Saved with a fresh context so a cancelled run still keeps the delivery.
+	saveCtx, cancel := w.clock.WithTimeout(trace.ContextWithSpan(context.Background(), trace.SpanFromContext(ctx)), 10*time.Second)
+	defer cancel()
+	if saveErr := w.deliveries.SaveFailedDelivery(saveCtx, *d); saveErr != nil {
+		zap.L().Error("error while saving failed delivery", logger.WithTraceId(ctx), zap.String("url", d.URL), zap.String("eventId", d.EventID), zap.Error(saveErr))
+		return fmt.Errorf("%s: %s: %w", err, saveErr, errNotSaved)
+	}
+
+	return err
+}
+
+// send signs the body with the current time, so a redelivered event is not
+// rejected as a replay.
+func (w *Webhook) send(ctx context.Context, d use_case.FailedDelivery) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.URL, bytes.NewReader(d.Body))
+	if err != nil {
+		return err
+	}
+
+	now := w.clock.Now()
+	req.Header.Set("Content-Type", cloudevent.ContentTypeStructured)
+	req.Header.Set(webhook_signature.HeaderTimestamp, strconv.FormatInt(now.Unix(), 10))
+	req.Header.Set(webhook_signature.HeaderSignature, webhook_signature.Sign(w.secret, now, d.Body))
+	req.Header.Set(webhook_signature.HeaderEventID, d.EventID)
+
+	res, err := w.client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return err
+		}
+		return fmt.Errorf("%s: %w", err, errRetryable)
+	}
+	defer res.Body.Close()
+	io.Copy(ioutil.Discard, res.Body)
+
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return nil
+	}
+	if res.StatusCode >= 500 || res.StatusCode == http.StatusTooManyRequests {
+		return fmt.Errorf("%s: %w", res.Status, errRetryable)
+	}
+	return fmt.Errorf("%s", res.Status)
+}
+
+func NewWebhook(urls []string, secret string, source string, maxAttempts int, backoff time.Duration, deliveries use_case.DeliveryRepository, clk clock.Clock) *Webhook {
+	if maxAttempts <= 0 {
+		maxAttempts = 1
+	}
+
+	c := &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: otelhttp.NewTransport(&http.Transport{
+			MaxIdleConns:        10,
+			IdleConnTimeout:     30 * time.Second,
+			MaxIdleConnsPerHost: 10,
+		}),
+	}
+
+	return &Webhook{
+		client:      c,
+		urls:        urls,
+		secret:      secret,
+		source:      source,
+		maxAttempts: maxAttempts,
+		backoff:     backoff,
+		deliveries:  deliveries,
+		clock:       clk,
+	}
+}
@@ -0,0 +1,189 @@
+package version_event_repository
+
+import (
+	"context"
+	"errors"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/clock"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/webhook_signature"
+	"github.com/SpeedxPz/pcrd-version-updater/src/repository/delivery_repository"
+	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const testSecret = "webhook-secret"
+
+func TestWebhookSignsRequest(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		body, _ := ioutil.ReadAll(r.Body)
+		err := webhook_signature.Verify(testSecret,
+			r.Header.Get(webhook_signature.HeaderSignature),
+			r.Header.Get(webhook_signature.HeaderTimestamp),
+			body, time.Now(), webhook_signature.DefaultTolerance)
+		if err != nil || r.Header.Get(webhook_signature.HeaderEventID) == "" {
+			t.Errorf("Verify: %v", err)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	w := NewWebhook([]string{srv.URL}, testSecret, testSource, 3, time.Millisecond, delivery_repository.NewMemory(), clock.Real())
+	if err := w.PublishEvent(context.Background(), testEvent); err != nil {
+		t.Fatalf("PublishEvent: %v", err)
+	}
+	if calls != 1 {
+		t.Fatalf("got %d calls, want 1", calls)
+	}
+}
+
+func TestWebhookRetriesThenRedelivers(t *testing.T) {
+	var calls int32
+	var healthy int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if atomic.LoadInt32(&healthy) == 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	start := time.Unix(1600000000, 0)
+	clk := clock.NewFake(start)
+	deliveries := delivery_repository.NewMemory()
+	w := NewWebhook([]string{srv.URL}, testSecret, testSource, 3, time.Minute, deliveries, clk)
+
+	// Saved for redelivery, the event counts as published.
+	if err := w.PublishEvent(ctx, testEvent); err != nil {
+		t.Fatalf("PublishEvent: %v", err)
+	}
+	if calls != 3 {
+		t.Fatalf("got %d calls, want 3", calls)
+	}
+
+	saved, err := deliveries.ListFailedDeliveries(ctx)
+	if err != nil {
+		t.Fatalf("ListFailedDeliveries: %v", err)
+	}
+	if len(saved) != 1 || saved[0].Attempts != 3 || saved[0].URL != srv.URL {
+		t.Fatalf("ListFailedDeliveries: got %+v", saved)
+	}
+	// Backing off one minute, then two.
+	if !saved[0].FirstFailedAt.Equal(start) || !saved[0].LastAttemptAt.Equal(start.Add(3*time.Minute)) {
+		t.Fatalf("ListFailedDeliveries: got first failure %v, last attempt %v", saved[0].FirstFailedAt, saved[0].LastAttemptAt)
+	}
+
+	atomic.StoreInt32(&healthy, 1)
+	delivered, err := w.Redeliver(ctx)
+	if err != nil || delivered != 1 {
+		t.Fatalf("Redeliver: got %d, %v, want 1", delivered, err)
+	}
+
+	saved, err = deliveries.ListFailedDeliveries(ctx)
+	if err != nil {
+		t.Fatalf("ListFailedDeliveries: %v", err)
+	}
+	if len(saved) != 0 {
+		t.Fatalf("redelivered delivery still saved: %+v", saved)
+	}
+}
+
+func TestWebhookDoesNotRetryClientError(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	deliveries := delivery_repository.NewMemory()
+	w := NewWebhook([]string{srv.URL}, testSecret, testSource, 3, time.Millisecond, deliveries, clock.Real())
+	if err := w.PublishEvent(context.Background(), testEvent); err != nil {
+		t.Fatalf("PublishEvent: %v", err)
+	}
+	if calls != 1 {
+		t.Fatalf("got %d calls, want 1", calls)
+	}
+	if saved, _ := deliveries.ListFailedDeliveries(context.Background()); len(saved) != 1 {
+		t.Fatalf("ListFailedDeliveries: got %+v, want the delivery saved", saved)
+	}
+}
+
+// unsavedDeliveries cannot save anything.
+type unsavedDeliveries struct {
+	use_case.DeliveryRepository
+}
+
+func (unsavedDeliveries) SaveFailedDelivery(ctx context.Context, d use_case.FailedDelivery) error {
+	return use_case.ErrSavingDelivery
+}
+
+func TestWebhookPartialFailure(t *testing.T) {
+	var good, bad int32
+	goodSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&good, 1)
+	}))
+	defer goodSrv.Close()
+	badSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&bad, 1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer badSrv.Close()
+	urls := []string{goodSrv.URL, badSrv.URL}
+
+	ctx := context.Background()
+	w := NewWebhook(urls, testSecret, testSource, 1, time.Millisecond, unsavedDeliveries{delivery_repository.NewMemory()}, clock.Real())
+	if err := w.PublishEvent(ctx, testEvent); !errors.Is(err, use_case.ErrVersionPublish) {
+		t.Fatalf("PublishEvent: got %v, want %v when the failure is not saved", err, use_case.ErrVersionPublish)
+	}
+
+	deliveries := delivery_repository.NewMemory()
+	w = NewWebhook(urls, testSecret, testSource, 1, time.Millisecond, deliveries, clock.Real())
+	if err := w.PublishEvent(ctx, testEvent); err != nil {
+		t.Fatalf("PublishEvent: %v", err)
+	}
+	if delivered, err := w.Redeliver(ctx); delivered != 0 || err == nil {
+		t.Fatalf("Redeliver: got %d, %v, want the bad URL failing again", delivered, err)
+	}
+	// Only the failed URL is sent the event again.
+	if good != 2 || bad != 3 {
+		t.Fatalf("got %d and %d calls, want 2 and 3", good, bad)
+	}
+}
+
+func TestWebhookRetriesWithinDeadline(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	start := time.Unix(1600000000, 0)
+	clk := clock.NewFake(start)
+	deliveries := delivery_repository.NewMemory()
+	w := NewWebhook([]string{srv.URL}, testSecret, testSource, 5, time.Minute, deliveries, clk)
+
+	// The second wait, of two minutes, would end past the deadline.
+	ctx, cancel := clk.WithTimeout(context.Background(), 90*time.Second)
+	defer cancel()
+	if err := w.PublishEvent(ctx, testEvent); err != nil {
+		t.Fatalf("PublishEvent: %v", err)
+	}
+	if calls != 2 || !clk.Now().Equal(start.Add(time.Minute)) {
+		t.Fatalf("got %d calls by %v, want 2 within the deadline", calls, clk.Now())
+	}
+	if saved, _ := deliveries.ListFailedDeliveries(context.Background()); len(saved) != 1 || saved[0].Attempts != 2 {
+		t.Fatalf("ListFailedDeliveries: got %+v, want the delivery saved after 2 attempts", saved)
+	}
+}
@@ -0,0 +1,214 @@
+package version_repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/clock"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/logger"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/setting"
+	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
+	"go.etcd.io/bbolt"
+	"go.opentelemetry.io/otel/codes"
+	"go.uber.org/zap"
+	"time"
+)
+
+var boltBucket = []byte("versions")
+
+type bolt struct {
+	db    *bbolt.DB
+	clock clock.Clock
+}
+
+type boltVersion struct {
+	ID             string    `json:"id"`
+	ServerCode     string    `json:"serverCode"`
+	AppVersion     string    `json:"appVersion"`
+	ResVersion     string    `json:"resVersion"`
+	CreateDateTime time.Time `json:"createdAt"`
+	UpdateDateTime time.Time `json:"updatedAt"`
+}
+
+func (b boltVersion) ToUseCaseGameVersion() (use_case.GameVersion, error) {
+
+	serverCode, err := setting.ParseServerCode(b.ServerCode)
+	if err != nil {
+		return use_case.GameVersion{}, err
+	}
+
+	return use_case.GameVersion{
+		Setting: setting.Setting{
+			ID:         b.ID,
+			ServerCode: serverCode,
+		},
+		AppVersion: b.AppVersion,
+		ResVersion: b.ResVersion,
+	}, nil
+}
+
+func (b bolt) GetByID(ctx context.Context, appId string) (use_case.GameVersion, error) {
+	ctx, span := tracer.Start(ctx, "version_repository.GetByID")
+	defer span.End()
+
+	if len(appId) <= 0 {
+		zap.L().Error("missing app id", logger.WithTraceId(ctx), zap.Any("error", use_case.ErrMissingAppID))
+		span.SetStatus(codes.Error, fmt.Sprintf("missing app id: %s", use_case.ErrMissingAppID))
+		return use_case.GameVersion{}, fmt.Errorf("%w", use_case.ErrMissingAppID)
+	}
+
+	var data []byte
+	err := b.db.View(func(tx *bbolt.Tx) error {
+		if v := tx.Bucket(boltBucket).Get([]byte(appId)); v != nil {
+			data = append(data, v...)
+		}
+		return nil
+	})
+	if err != nil {
+		zap.L().Error("error while reading retrieving", logger.WithTraceId(ctx), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("error while retrieving: %s", err))
+		return use_case.GameVersion{}, fmt.Errorf("error while retrieving: %w", use_case.ErrRetrivingVersion)
+	}
+
+	if data == nil {
+		zap.L().Error("not exists", logger.WithTraceId(ctx), zap.Any("ID", appId), zap.Any("error", use_case.ErrVersionNotFound))
+		span.SetStatus(codes.Error, fmt.Sprintf("%s: %s", appId, use_case.ErrVersionNotFound))
+		return use_case.GameVersion{}, fmt.Errorf("%s: %w", appId, use_case.ErrVersionNotFound)
+	}
+
+	var o boltVersion
+	err = json.Unmarshal(data, &o)
+	if err != nil {
+		zap.L().Error("error while reading retrieving", logger.WithTraceId(ctx), zap.Any("ID", appId), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+		return use_case.GameVersion{}, fmt.Errorf("%w", use_case.ErrRetrivingVersion)
+	}
+
+	result, err := o.ToUseCaseGameVersion()
+	if err != nil {
+		zap.L().Error("error while reading retrieving", logger.WithTraceId(ctx), zap.Any("ID", appId), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+		return use_case.GameVersion{}, fmt.Errorf("%w", use_case.ErrRetrivingVersion)
+	}
+
+	return result, nil
+}
+
+// ListVersions returns every version ordered by ID, the bucket's key order.
+func (b bolt) ListVersions(ctx context.Context) ([]use_case.GameVersion, error) {
+	ctx, span := tracer.Start(ctx, "version_repository.ListVersions")
+	defer span.End()
+
+	results := []use_case.GameVersion{}
+	err := b.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(boltBucket).ForEach(func(k, v []byte) error {
+			var o boltVersion
+			if err := json.Unmarshal(v, &o); err != nil {
+				return err
+			}
+			result, err := o.ToUseCaseGameVersion()
+			if err != nil {
+				return err
+			}
+			results = append(results, result)
+			return nil
+		})
+	})
+	if err != nil {
+		zap.L().Error("error while reading retrieving", logger.WithTraceId(ctx), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("error while retrieving: %s", err))
+		return nil, fmt.Errorf("error while retrieving: %w", use_case.ErrRetrivingVersion)
+	}
+
+	return results, nil
+}
+
+func (b bolt) Create(ctx context.Context, version use_case.GameVersion) error {
+	ctx, span := tracer.Start(ctx, "version_repository.Create")
+	defer span.End()
+
+	now := b.clock.Now()
+	doc := boltVersion{
+		ID:             version.Setting.ID,
+		ServerCode:     string(version.Setting.ServerCode),
+		AppVersion:     version.AppVersion,
+		ResVersion:     version.ResVersion,
+		CreateDateTime: now,
+		UpdateDateTime: now,
+	}
+
+	err := b.db.Update(func(tx *bbolt.Tx) error {
+		data, err := json.Marshal(doc)
+		if err != nil {
+			return err
+		}
+		return tx.Bucket(boltBucket).Put([]byte(doc.ID), data)
+	})
+	if err != nil {
+		zap.L().Error("error while saving", logger.WithTraceId(ctx), zap.Any("version", version), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+		return fmt.Errorf("%w", use_case.ErrSavingVersion)
+	}
+
+	return nil
+}
+
+func (b bolt) Update(ctx context.Context, version use_case.GameVersion) error {
+	ctx, span := tracer.Start(ctx, "version_repository.Update")
+	defer span.End()
+
+	var found bool
+	err := b.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(boltBucket)
+		data := bucket.Get([]byte(version.Setting.ID))
+		if data == nil {
+			return nil
+		}
+		found = true
+
+		var doc boltVersion
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return err
+		}
+		doc.AppVersion = version.AppVersion
+		doc.ResVersion = version.ResVersion
+		doc.UpdateDateTime = b.clock.Now()
+
+		data, err := json.Marshal(doc)
+		if err != nil {
+			return err
+		}
+		return bucket.Put([]byte(doc.ID), data)
+	})
+	if err != nil {
+		zap.L().Error("error while saving", logger.WithTraceId(ctx), zap.Any("version", version), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+		return fmt.Errorf("%w", use_case.ErrSavingVersion)
+	}
+
+	if !found {
+		zap.L().Error("cannot update", logger.WithTraceId(ctx), zap.Any("version", version))
+		span.SetStatus(codes.Error, fmt.Sprintf("cannot update %s", version.Setting.ID))
+		return fmt.Errorf("update version: %s not found: %w", version.Setting.ID, use_case.ErrVersionNotFound)
+	}
+
+	return nil
+}
+
+func (b bolt) HealthCheck(ctx context.Context) error {
+	return b.db.View(func(tx *bbolt.Tx) error {
+		return nil
+	})
+}
+
+func NewBolt(db *bbolt.DB, clk clock.Clock) (use_case.VersionRepository, error) {
+	err := db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(boltBucket)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &bolt{db: db, clock: clk}, nil
+}
@@ -0,0 +1,76 @@
+package version_repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
+	"sort"
+	"sync"
+)
+
+type memory struct {
+	mu       sync.RWMutex
+	versions map[string]use_case.GameVersion
+}
+
+func (m *memory) GetByID(ctx context.Context, appId string) (use_case.GameVersion, error) {
+	if len(appId) <= 0 {
+		return use_case.GameVersion{}, fmt.Errorf("%w", use_case.ErrMissingAppID)
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result, ok := m.versions[appId]
+	if !ok {
+		return use_case.GameVersion{}, fmt.Errorf("%s: %w", appId, use_case.ErrVersionNotFound)
+	}
+
+	return result, nil
+}
+
+func (m *memory) ListVersions(ctx context.Context) ([]use_case.GameVersion, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	results := make([]use_case.GameVersion, 0, len(m.versions))
+	for _, v := range m.versions {
+		results = append(results, v)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Setting.ID < results[j].Setting.ID
+	})
+
+	return results, nil
+}
+
+func (m *memory) Create(ctx context.Context, version use_case.GameVersion) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.versions[version.Setting.ID] = version
+	return nil
+}
+
+func (m *memory) Update(ctx context.Context, version use_case.GameVersion) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	current, ok := m.versions[version.Setting.ID]
+	if !ok {
+		return fmt.Errorf("update version: %s not found: %w", version.Setting.ID, use_case.ErrVersionNotFound)
+	}
+
+	current.AppVersion = version.AppVersion
+	current.ResVersion = version.ResVersion
+	m.versions[version.Setting.ID] = current
+	return nil
+}
+
+func (m *memory) HealthCheck(ctx context.Context) error {
+	return nil
+}
+
+func NewMemory() use_case.VersionRepository {
+	return &memory{versions: map[string]use_case.GameVersion{}}
+}
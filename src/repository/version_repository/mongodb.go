@@ -3,11 +3,13 @@ package version_repository
 import (
 	"context"
 	"fmt"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/clock"
 	"github.com/SpeedxPz/pcrd-version-updater/src/entity/logger"
 	"github.com/SpeedxPz/pcrd-version-updater/src/entity/setting"
 	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.mongodb.org/mongo-driver/mongo/readpref"
 	"go.opentelemetry.io/otel/codes"
 	"go.uber.org/zap"
@@ -15,7 +17,8 @@ import (
 )
 
 type mongoDB struct {
-	col *mongo.Collection
+	col   *mongo.Collection
+	clock clock.Clock
 }
 
 type mongoDBVersion struct {
@@ -108,13 +111,54 @@ func (m mongoDB) GetByID(ctx context.Context, appId string) (use_case.GameVersio
 	return result, nil
 }
 
+// ListVersions returns every version ordered by ID.
+func (m mongoDB) ListVersions(ctx context.Context) ([]use_case.GameVersion, error) {
+	ctx, span := tracer.Start(ctx, "version_repository.ListVersions")
+	defer span.End()
+
+	cur, err := m.col.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "id", Value: 1}}))
+	if err != nil {
+		zap.L().Error("error while reading retrieving", logger.WithTraceId(ctx), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("error while retrieving: %s", err))
+		return nil, fmt.Errorf("error while retrieving: %w", use_case.ErrRetrivingVersion)
+	}
+	defer cur.Close(ctx)
+
+	results := []use_case.GameVersion{}
+	for cur.Next(ctx) {
+		var o mongoDBVersion
+		err := cur.Decode(&o)
+		if err != nil {
+			zap.L().Error("error while reading retrieving", logger.WithTraceId(ctx), zap.Any("error", err))
+			span.SetStatus(codes.Error, fmt.Sprintf("error while retrieving: %s", err))
+			return nil, fmt.Errorf("error while retrieving: %w", use_case.ErrRetrivingVersion)
+		}
+		result, err := o.ToUseCaseGameVersion()
+		if err != nil {
+			zap.L().Error("error while reading retrieving", logger.WithTraceId(ctx), zap.Any("ID", o.ID), zap.Any("error", err))
+			span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+			return nil, fmt.Errorf("%w", use_case.ErrRetrivingVersion)
+		}
+		results = append(results, result)
+	}
+
+	if err := cur.Err(); err != nil {
+		zap.L().Error("error while reading retrieving", logger.WithTraceId(ctx), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("error while retrieving: %s", err))
+		return nil, fmt.Errorf("error while retrieving: %w", use_case.ErrRetrivingVersion)
+	}
+
+	return results, nil
+}
+
 func (m mongoDB) Create(ctx context.Context, version use_case.GameVersion) error {
 	ctx, span := tracer.Start(ctx, "version_repository.Create")
 	defer span.End()
 
 	doc := newMongoDBVersion(version)
-	doc.CreateDateTime = time.Now()
-	doc.UpdateDateTime = time.Now()
+	now := m.clock.Now()
+	doc.CreateDateTime = now
+	doc.UpdateDateTime = now
 	_, err := m.col.InsertOne(ctx, doc)
 
 	if err != nil {
@@ -136,7 +180,7 @@ func (m mongoDB) Update(ctx context.Context, version use_case.GameVersion) error
 		"$set": bson.M{
 			"appVersion": version.AppVersion,
 			"resVersion": version.ResVersion,
-			"updatedAt":  time.Now(),
+			"updatedAt":  m.clock.Now(),
 		},
 	})
 
@@ -160,8 +204,8 @@ func (m mongoDB) HealthCheck(ctx context.Context) error {
 	return m.col.Database().Client().Ping(ctx, readpref.Primary())
 }
 
-func NewMongoDb(db *mongo.Database) use_case.VersionRepository {
-	m := &mongoDB{col: db.Collection("versions")}
+func NewMongoDb(db *mongo.Database, clk clock.Clock) use_case.VersionRepository {
+	m := &mongoDB{col: db.Collection("versions"), clock: clk}
 
 	return m
 }
@@ -0,0 +1,226 @@
+package version_repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/clock"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/logger"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/setting"
+	"github.com/SpeedxPz/pcrd-version-updater/src/repository/history_repository"
+	"github.com/SpeedxPz/pcrd-version-updater/src/repository/sql_schema"
+	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
+	"go.opentelemetry.io/otel/codes"
+	"go.uber.org/zap"
+)
+
+type sqlDB struct {
+	db      *sql.DB
+	dialect sql_schema.Dialect
+	clock   clock.Clock
+}
+
+func (s sqlDB) GetByID(ctx context.Context, appId string) (use_case.GameVersion, error) {
+	ctx, span := tracer.Start(ctx, "version_repository.GetByID")
+	defer span.End()
+
+	if len(appId) <= 0 {
+		zap.L().Error("missing app id", logger.WithTraceId(ctx), zap.Any("error", use_case.ErrMissingAppID))
+		span.SetStatus(codes.Error, fmt.Sprintf("missing app id: %s", use_case.ErrMissingAppID))
+		return use_case.GameVersion{}, fmt.Errorf("%w", use_case.ErrMissingAppID)
+	}
+
+	var ID, serverCode, appVersion, resVersion string
+	err := s.db.QueryRowContext(ctx, s.dialect.Rebind(
+		`SELECT id, server_code, app_version, res_version FROM versions WHERE id = ?`,
+	), appId).Scan(&ID, &serverCode, &appVersion, &resVersion)
+	if errors.Is(err, sql.ErrNoRows) {
+		zap.L().Error("not exists", logger.WithTraceId(ctx), zap.Any("ID", appId), zap.Any("error", use_case.ErrVersionNotFound))
+		span.SetStatus(codes.Error, fmt.Sprintf("%s: %s", appId, use_case.ErrVersionNotFound))
+		return use_case.GameVersion{}, fmt.Errorf("%s: %w", appId, use_case.ErrVersionNotFound)
+	}
+	if err != nil {
+		zap.L().Error("error while reading retrieving", logger.WithTraceId(ctx), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("error while retrieving: %s", err))
+		return use_case.GameVersion{}, fmt.Errorf("error while retrieving: %w", use_case.ErrRetrivingVersion)
+	}
+
+	parsedServerCode, err := setting.ParseServerCode(serverCode)
+	if err != nil {
+		zap.L().Error("error while reading retrieving", logger.WithTraceId(ctx), zap.Any("ID", appId), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+		return use_case.GameVersion{}, fmt.Errorf("%w", use_case.ErrRetrivingVersion)
+	}
+
+	return use_case.GameVersion{
+		Setting: setting.Setting{
+			ID:         ID,
+			ServerCode: parsedServerCode,
+		},
+		AppVersion: appVersion,
+		ResVersion: resVersion,
+	}, nil
+}
+
+func (s sqlDB) ListVersions(ctx context.Context) ([]use_case.GameVersion, error) {
+	ctx, span := tracer.Start(ctx, "version_repository.ListVersions")
+	defer span.End()
+
+	rows, err := s.db.QueryContext(ctx, `SELECT id, server_code, app_version, res_version FROM versions ORDER BY id`)
+	if err != nil {
+		zap.L().Error("error while reading retrieving", logger.WithTraceId(ctx), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("error while retrieving: %s", err))
+		return nil, fmt.Errorf("error while retrieving: %w", use_case.ErrRetrivingVersion)
+	}
+	defer rows.Close()
+
+	results := []use_case.GameVersion{}
+	for rows.Next() {
+		var ID, serverCode, appVersion, resVersion string
+		err := rows.Scan(&ID, &serverCode, &appVersion, &resVersion)
+		if err != nil {
+			zap.L().Error("error while reading retrieving", logger.WithTraceId(ctx), zap.Any("error", err))
+			span.SetStatus(codes.Error, fmt.Sprintf("error while retrieving: %s", err))
+			return nil, fmt.Errorf("error while retrieving: %w", use_case.ErrRetrivingVersion)
+		}
+
+		parsedServerCode, err := setting.ParseServerCode(serverCode)
+		if err != nil {
+			zap.L().Error("error while reading retrieving", logger.WithTraceId(ctx), zap.Any("ID", ID), zap.Any("error", err))
+			span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+			return nil, fmt.Errorf("%w", use_case.ErrRetrivingVersion)
+		}
+
+		results = append(results, use_case.GameVersion{
+			Setting: setting.Setting{
+				ID:         ID,
+				ServerCode: parsedServerCode,
+			},
+			AppVersion: appVersion,
+			ResVersion: resVersion,
+		})
+	}
+
+	if err := rows.Err(); err != nil {
+		zap.L().Error("error while reading retrieving", logger.WithTraceId(ctx), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("error while retrieving: %s", err))
+		return nil, fmt.Errorf("error while retrieving: %w", use_case.ErrRetrivingVersion)
+	}
+
+	return results, nil
+}
+
+func (s sqlDB) Create(ctx context.Context, version use_case.GameVersion) error {
+	ctx, span := tracer.Start(ctx, "version_repository.Create")
+	defer span.End()
+
+	now := s.clock.Now().UTC()
+	_, err := s.db.ExecContext(ctx, s.dialect.Rebind(
+		`INSERT INTO versions (id, server_code, app_version, res_version, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)`,
+	), version.Setting.ID, string(version.Setting.ServerCode), version.AppVersion, version.ResVersion, now, now)
+	if err != nil {
+		zap.L().Error("error while saving", logger.WithTraceId(ctx), zap.Any("version", version), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+		return fmt.Errorf("%w", use_case.ErrSavingVersion)
+	}
+
+	return nil
+}
+
+func (s sqlDB) Update(ctx context.Context, version use_case.GameVersion) error {
+	ctx, span := tracer.Start(ctx, "version_repository.Update")
+	defer span.End()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		zap.L().Error("error while saving", logger.WithTraceId(ctx), zap.Any("version", version), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+		return fmt.Errorf("%w", use_case.ErrSavingVersion)
+	}
+	defer tx.Rollback()
+
+	err = s.update(ctx, tx, version)
+	if err != nil {
+		span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		zap.L().Error("error while saving", logger.WithTraceId(ctx), zap.Any("version", version), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+		return fmt.Errorf("%w", use_case.ErrSavingVersion)
+	}
+
+	return nil
+}
+
+// UpdateWithHistory updates the version and appends its history entry in
+// the same transaction, so neither is written without the other.
+func (s sqlDB) UpdateWithHistory(ctx context.Context, version use_case.GameVersion) error {
+	ctx, span := tracer.Start(ctx, "version_repository.UpdateWithHistory")
+	defer span.End()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		zap.L().Error("error while saving", logger.WithTraceId(ctx), zap.Any("version", version), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+		return fmt.Errorf("%w", use_case.ErrSavingVersion)
+	}
+	defer tx.Rollback()
+
+	err = s.update(ctx, tx, version)
+	if err != nil {
+		span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+		return err
+	}
+
+	err = history_repository.InsertSQL(ctx, tx, s.dialect, version, s.clock.Now())
+	if err != nil {
+		zap.L().Error("error while saving history", logger.WithTraceId(ctx), zap.Any("version", version), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+		return fmt.Errorf("%w", use_case.ErrSavingVersion)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		zap.L().Error("error while saving", logger.WithTraceId(ctx), zap.Any("version", version), zap.Any("error", err))
+		span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+		return fmt.Errorf("%w", use_case.ErrSavingVersion)
+	}
+
+	return nil
+}
+
+func (s sqlDB) update(ctx context.Context, tx *sql.Tx, version use_case.GameVersion) error {
+	res, err := tx.ExecContext(ctx, s.dialect.Rebind(
+		`UPDATE versions SET app_version = ?, res_version = ?, updated_at = ? WHERE id = ?`,
+	), version.AppVersion, version.ResVersion, s.clock.Now().UTC(), version.Setting.ID)
+	if err != nil {
+		zap.L().Error("error while saving", logger.WithTraceId(ctx), zap.Any("version", version), zap.Any("error", err))
+		return fmt.Errorf("%w", use_case.ErrSavingVersion)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		zap.L().Error("error while saving", logger.WithTraceId(ctx), zap.Any("version", version), zap.Any("error", err))
+		return fmt.Errorf("%w", use_case.ErrSavingVersion)
+	}
+
+	if affected == 0 {
+		zap.L().Error("cannot update", logger.WithTraceId(ctx), zap.Any("version", version))
+		return fmt.Errorf("update version: %s not found: %w", version.Setting.ID, use_case.ErrVersionNotFound)
+	}
+
+	return nil
+}
+
+func (s sqlDB) HealthCheck(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+// NewSQL expects the schema to be migrated with sql_schema.Migrate.
+func NewSQL(db *sql.DB, dialect sql_schema.Dialect, clk clock.Clock) use_case.VersionRepository {
+	return &sqlDB{db: db, dialect: dialect, clock: clk}
+}
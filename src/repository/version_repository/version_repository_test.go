@@ -0,0 +1,44 @@
+package version_repository_test
+
+import (
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/clock"
+	"github.com/SpeedxPz/pcrd-version-updater/src/repository/repository_contract"
+	"github.com/SpeedxPz/pcrd-version-updater/src/repository/sql_schema"
+	"github.com/SpeedxPz/pcrd-version-updater/src/repository/version_repository"
+	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
+	"testing"
+)
+
+func TestMemory(t *testing.T) {
+	repository_contract.VersionRepository(t, func(t *testing.T) use_case.VersionRepository {
+		return version_repository.NewMemory()
+	})
+}
+
+func TestBolt(t *testing.T) {
+	repository_contract.VersionRepository(t, func(t *testing.T) use_case.VersionRepository {
+		repo, err := version_repository.NewBolt(repository_contract.NewBoltDB(t), clock.Real())
+		if err != nil {
+			t.Fatalf("NewBolt: %v", err)
+		}
+		return repo
+	})
+}
+
+func TestMongoDb(t *testing.T) {
+	repository_contract.VersionRepository(t, func(t *testing.T) use_case.VersionRepository {
+		return version_repository.NewMongoDb(repository_contract.NewMongoDatabase(t), clock.Real())
+	})
+}
+
+func TestSQLite(t *testing.T) {
+	repository_contract.VersionRepository(t, func(t *testing.T) use_case.VersionRepository {
+		return version_repository.NewSQL(repository_contract.NewSQLiteDB(t), sql_schema.DialectSQLite, clock.Real())
+	})
+}
+
+func TestPostgres(t *testing.T) {
+	repository_contract.VersionRepository(t, func(t *testing.T) use_case.VersionRepository {
+		return version_repository.NewSQL(repository_contract.NewPostgresDB(t), sql_schema.DialectPostgres, clock.Real())
+	})
+}
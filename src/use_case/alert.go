@@ -0,0 +1,162 @@
+package use_case
+
+import (
+	"context"
+	"errors"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/logger"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/setting"
+	"go.uber.org/zap"
+	"sort"
+	"time"
+)
+
+type AlertType string
+
+const (
+	// AlertRepeatedFailure means the last checks of a setting all failed.
+	AlertRepeatedFailure AlertType = "repeated_failure"
+	// AlertStale means the version of a setting has not changed for
+	// longer than expected, which usually means the check misses updates.
+	AlertStale AlertType = "stale"
+)
+
+// AlertThresholds are when alerts are raised. A zero threshold disables its
+// alert.
+type AlertThresholds struct {
+	ConsecutiveFailures int
+	StaleAfter          time.Duration
+}
+
+// Alert is the state of a setting when one of its alerts was raised or
+// resolved.
+type Alert struct {
+	Type                AlertType
+	ConsecutiveFailures int
+	LastFailureClass    FailureClass
+	LastSuccessAt       time.Time
+	LastChangedAt       time.Time
+}
+
+func (t AlertThresholds) active(s CheckState, now time.Time) []AlertType {
+	active := []AlertType{}
+	if t.ConsecutiveFailures > 0 && s.ConsecutiveFailures >= t.ConsecutiveFailures {
+		active = append(active, AlertRepeatedFailure)
+	}
+	if t.StaleAfter > 0 && !s.LastChangedAt.IsZero() && now.Sub(s.LastChangedAt) >= t.StaleAfter {
+		active = append(active, AlertStale)
+	}
+	sort.Slice(active, func(i, j int) bool { return active[i] < active[j] })
+	return active
+}
+
+// alertsMissing returns the alerts of a that are not in b.
+func alertsMissing(a []AlertType, b []AlertType) []AlertType {
+	var missing []AlertType
+	for _, x := range a {
+		found := false
+		for _, y := range b {
+			found = found || x == y
+		}
+		if !found {
+			missing = append(missing, x)
+		}
+	}
+	return missing
+}
+
+// trackCheck records the outcome of a check in the setting's state and
+// publishes an alert event for each alert raised or resolved. An alert whose
+// event could not be published keeps its previous state, so that the next
+// check tries again. Alerting never changes the outcome of the check, so its
+// failures are only logged.
+func (u UseCase) trackCheck(ctx context.Context, report RunReport) {
+	// Without a server code the setting was never read, so there is no
+	// setting to track.
+	if u.checkStateRepository == nil || len(report.ServerCode) <= 0 {
+		return
+	}
+	s := setting.Setting{ID: report.ID, ServerCode: report.ServerCode}
+	now := report.FinishedAt
+
+	state, err := u.checkStateRepository.GetCheckState(ctx, s.ID)
+	if errors.Is(err, ErrCheckStateNotFound) {
+		state, err = CheckState{ID: s.ID, LastChangedAt: now}, nil
+	}
+	if err != nil {
+		zap.L().Error("cannot read check state", logger.WithTraceId(ctx), zap.Any("ID", s.ID), zap.Error(err))
+		return
+	}
+
+	previous := state
+	state.LastCheckedAt = now
+	switch report.Outcome {
+	case RunFailed:
+		state.ConsecutiveFailures++
+		if report.Failure != nil {
+			state.LastFailureClass = report.Failure.Class
+		}
+	case RunCreated, RunUpdated:
+		state.LastChangedAt = now
+		fallthrough
+	default:
+		state.ConsecutiveFailures = 0
+		state.LastFailureClass = ""
+		state.LastSuccessAt = now
+	}
+
+	active := u.alertThresholds.active(state, now)
+	raised := alertsMissing(active, state.Alerts)
+	resolved := alertsMissing(state.Alerts, active)
+
+	alerts := alertsMissing(state.Alerts, resolved)
+	for _, alertType := range raised {
+		if u.publishAlert(ctx, VersionEventAlertRaised, s, alertType, state, now) {
+			alerts = append(alerts, alertType)
+		}
+	}
+	// A resolved alert carries the state it was raised with, e.g. the
+	// failures that ended.
+	for _, alertType := range resolved {
+		if !u.publishAlert(ctx, VersionEventAlertResolved, s, alertType, previous, now) {
+			alerts = append(alerts, alertType)
+		}
+	}
+	sort.Slice(alerts, func(i, j int) bool { return alerts[i] < alerts[j] })
+	state.Alerts = alerts
+
+	err = u.checkStateRepository.SaveCheckState(ctx, state)
+	if err != nil {
+		zap.L().Error("cannot save check state", logger.WithTraceId(ctx), zap.Any("ID", s.ID), zap.Error(err))
+	}
+}
+
+// publishAlert reports whether the alert event was published.
+func (u UseCase) publishAlert(ctx context.Context, eventType VersionEventType, s setting.Setting, alertType AlertType, state CheckState, t time.Time) bool {
+	zap.L().Warn("alert "+string(eventType),
+		logger.WithTraceId(ctx),
+		zap.Any("ID", s.ID),
+		zap.String("alert", string(alertType)),
+		zap.Int("consecutiveFailures", state.ConsecutiveFailures),
+		zap.Time("lastChangedAt", state.LastChangedAt),
+	)
+
+	event := newVersionEvent(eventType, s, nil, nil, t)
+	event.Alert = &Alert{
+		Type:                alertType,
+		ConsecutiveFailures: state.ConsecutiveFailures,
+		LastFailureClass:    state.LastFailureClass,
+		LastSuccessAt:       state.LastSuccessAt,
+		LastChangedAt:       state.LastChangedAt,
+	}
+	err := u.versionEventRepository.PublishEvent(ctx, event)
+	if err != nil {
+		zap.L().Error("cannot publish alert",
+			logger.WithTraceId(ctx),
+			zap.Any("ID", s.ID),
+			zap.String("alert", string(alertType)),
+			zap.Error(err),
+		)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,184 @@
+package use_case_test
+
+import (
+	"context"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/credential"
+	"github.com/SpeedxPz/pcrd-version-updater/src/repository/application_repository"
+	"github.com/SpeedxPz/pcrd-version-updater/src/repository/check_state_repository"
+	"github.com/SpeedxPz/pcrd-version-updater/src/repository/pcrd_jp_repository"
+	"github.com/SpeedxPz/pcrd-version-updater/src/repository/setting_repository"
+	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
+	"testing"
+	"time"
+)
+
+// flakyTHRepository fails while down is set.
+type flakyTHRepository struct {
+	resVersion string
+	down       bool
+}
+
+func (r *flakyTHRepository) GetResourceVersion(ctx context.Context, c credential.Credential, v use_case.PcrdVersion) (string, error) {
+	if r.down {
+		return "", use_case.ErrRetrieveData
+	}
+	return r.resVersion, nil
+}
+
+func (r *flakyTHRepository) HealthCheck(ctx context.Context) error {
+	return nil
+}
+
+func newAlertFixture(th use_case.PcrdTHRepository) fixture {
+	f := newFixture("")
+	f.useCase = use_case.New(
+		application_repository.NewMemory(apps...),
+		setting_repository.NewMemory(thSetting),
+		th,
+		pcrd_jp_repository.NewMemory(),
+		f.versions,
+		f.history,
+		f.events,
+		f.clock,
+	)
+	return f
+}
+
+func alertEvents(events []use_case.VersionEvent) []use_case.VersionEvent {
+	var alerts []use_case.VersionEvent
+	for _, e := range events {
+		if e.Alert != nil {
+			alerts = append(alerts, e)
+		}
+	}
+	return alerts
+}
+
+func TestAlertRepeatedFailure(t *testing.T) {
+	ctx := context.Background()
+	th := &flakyTHRepository{resVersion: "00150010", down: true}
+	f := newAlertFixture(th)
+	states := check_state_repository.NewMemory()
+	f.useCase.SetAlerting(states, use_case.AlertThresholds{ConsecutiveFailures: 2})
+
+	for i := 0; i < 3; i++ {
+		f.useCase.UpdateResourceVersion(ctx, "th.app")
+	}
+	alerts := alertEvents(f.events.Published())
+	if len(alerts) != 1 || alerts[0].Type != use_case.VersionEventAlertRaised || alerts[0].Alert.Type != use_case.AlertRepeatedFailure {
+		t.Fatalf("got %+v, want one repeated failure raised", alerts)
+	}
+	if alerts[0].Alert.ConsecutiveFailures != 2 || alerts[0].Alert.LastFailureClass != use_case.FailureRemote {
+		t.Fatalf("got %+v, want 2 remote failures", alerts[0].Alert)
+	}
+
+	th.down = false
+	if _, err := f.useCase.UpdateResourceVersion(ctx, "th.app"); err != nil {
+		t.Fatalf("UpdateResourceVersion: %v", err)
+	}
+	alerts = alertEvents(f.events.Published())
+	if len(alerts) != 2 || alerts[1].Type != use_case.VersionEventAlertResolved || alerts[1].Alert.ConsecutiveFailures != 3 {
+		t.Fatalf("got %+v, want the alert resolved after 3 failures", alerts)
+	}
+
+	state, err := states.GetCheckState(ctx, "th.app")
+	if err != nil {
+		t.Fatalf("GetCheckState: %v", err)
+	}
+	if state.ConsecutiveFailures != 0 || len(state.Alerts) != 0 || state.LastSuccessAt.IsZero() {
+		t.Fatalf("GetCheckState: got %+v, want a clean state", state)
+	}
+}
+
+func TestAlertStale(t *testing.T) {
+	ctx := context.Background()
+	th := &flakyTHRepository{resVersion: "00150010"}
+	f := newAlertFixture(th)
+	states := check_state_repository.NewMemory()
+	f.useCase.SetAlerting(states, use_case.AlertThresholds{StaleAfter: time.Hour})
+
+	if _, err := f.useCase.UpdateResourceVersion(ctx, "th.app"); err != nil {
+		t.Fatalf("UpdateResourceVersion: %v", err)
+	}
+	state, err := states.GetCheckState(ctx, "th.app")
+	if err != nil {
+		t.Fatalf("GetCheckState: %v", err)
+	}
+	state.LastChangedAt = state.LastChangedAt.Add(-2 * time.Hour)
+	if err := states.SaveCheckState(ctx, state); err != nil {
+		t.Fatalf("SaveCheckState: %v", err)
+	}
+
+	if _, err := f.useCase.UpdateResourceVersion(ctx, "th.app"); err != nil {
+		t.Fatalf("UpdateResourceVersion: %v", err)
+	}
+	alerts := alertEvents(f.events.Published())
+	if len(alerts) != 1 || alerts[0].Type != use_case.VersionEventAlertRaised || alerts[0].Alert.Type != use_case.AlertStale {
+		t.Fatalf("got %+v, want stale raised", alerts)
+	}
+
+	th.resVersion = "00150020"
+	if _, err := f.useCase.UpdateResourceVersion(ctx, "th.app"); err != nil {
+		t.Fatalf("UpdateResourceVersion: %v", err)
+	}
+	alerts = alertEvents(f.events.Published())
+	if len(alerts) != 2 || alerts[1].Type != use_case.VersionEventAlertResolved || alerts[1].Alert.Type != use_case.AlertStale {
+		t.Fatalf("got %+v, want stale resolved", alerts)
+	}
+}
+
+func TestAlertPublishFailure(t *testing.T) {
+	ctx := context.Background()
+	th := &flakyTHRepository{resVersion: "00150010", down: true}
+	f := newAlertFixture(th)
+	events := &flakyPublisher{Memory: f.events}
+	f.useCase = use_case.New(
+		application_repository.NewMemory(apps...),
+		setting_repository.NewMemory(thSetting),
+		th,
+		pcrd_jp_repository.NewMemory(),
+		f.versions,
+		f.history,
+		events,
+		f.clock,
+	)
+	states := check_state_repository.NewMemory()
+	f.useCase.SetAlerting(states, use_case.AlertThresholds{ConsecutiveFailures: 1})
+
+	// The check failure gets through, the raise does not.
+	events.up, events.down = 1, 1
+	f.useCase.UpdateResourceVersion(ctx, "th.app")
+	if alerts := alertEvents(f.events.Published()); len(alerts) != 0 {
+		t.Fatalf("got %+v, want no alert published", alerts)
+	}
+	if state, _ := states.GetCheckState(ctx, "th.app"); len(state.Alerts) != 0 {
+		t.Fatalf("GetCheckState: got %+v, want the unpublished alert left out", state)
+	}
+
+	f.useCase.UpdateResourceVersion(ctx, "th.app")
+	alerts := alertEvents(f.events.Published())
+	if len(alerts) != 1 || alerts[0].Type != use_case.VersionEventAlertRaised {
+		t.Fatalf("got %+v, want the alert raised on the next check", alerts)
+	}
+
+	// The created event gets through, the resolve does not.
+	th.down = false
+	events.up, events.down = 1, 1
+	if _, err := f.useCase.UpdateResourceVersion(ctx, "th.app"); err != nil {
+		t.Fatalf("UpdateResourceVersion: %v", err)
+	}
+	if state, _ := states.GetCheckState(ctx, "th.app"); len(state.Alerts) != 1 {
+		t.Fatalf("GetCheckState: got %+v, want the alert still active", state)
+	}
+
+	if _, err := f.useCase.UpdateResourceVersion(ctx, "th.app"); err != nil {
+		t.Fatalf("UpdateResourceVersion: %v", err)
+	}
+	alerts = alertEvents(f.events.Published())
+	if len(alerts) != 2 || alerts[1].Type != use_case.VersionEventAlertResolved {
+		t.Fatalf("got %+v, want the alert resolved on the next check", alerts)
+	}
+	if state, _ := states.GetCheckState(ctx, "th.app"); len(state.Alerts) != 0 {
+		t.Fatalf("GetCheckState: got %+v, want no alert active", state)
+	}
+}
@@ -0,0 +1,166 @@
+package use_case
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/logger"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/setting"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"sync/atomic"
+	"time"
+)
+
+// defaultCheckQueryLimit caps ListChecks when the query sets no limit.
+const defaultCheckQueryLimit = 100
+
+// CheckRecord is the audit entry of one UpdateResourceVersion run, kept
+// whatever its outcome. ExpiresAt is zero when the record is kept forever.
+type CheckRecord struct {
+	ID           string
+	SettingID    string
+	ServerCode   setting.ServerCode
+	Outcome      RunOutcome
+	FailureClass FailureClass
+	Failure      string
+	Before       *PcrdVersion
+	After        *PcrdVersion
+	Probes       ProbeCounts
+	TraceID      string
+	StartedAt    time.Time
+	FinishedAt   time.Time
+	ExpiresAt    time.Time
+}
+
+// CheckQuery filters ListChecks. Zero fields match everything; From and To
+// bound StartedAt to [From, To).
+type CheckQuery struct {
+	SettingID string
+	Outcome   RunOutcome
+	From      time.Time
+	To        time.Time
+	Limit     int
+}
+
+// ProbeResult is what a single request to the game server or CDN told us.
+type ProbeResult int
+
+const (
+	// ProbeHit means the remote confirmed a version.
+	ProbeHit ProbeResult = iota
+	// ProbeMiss means the remote answered without a version.
+	ProbeMiss
+	// ProbeError means the request failed or the answer was unreadable.
+	ProbeError
+)
+
+// ProbeCounts is how many remote requests a check sent and what they told.
+type ProbeCounts struct {
+	Sent   int
+	Hits   int
+	Errors int
+}
+
+type probeCounter struct {
+	sent, hits, errors int64
+}
+
+type probeCounterKey struct{}
+
+func withProbeCounter(ctx context.Context) (context.Context, *probeCounter) {
+	c := &probeCounter{}
+	return context.WithValue(ctx, probeCounterKey{}, c), c
+}
+
+// RecordProbe counts one remote request against the check running in ctx.
+// The remote repositories call it for every request they send; outside of a
+// check it does nothing.
+func RecordProbe(ctx context.Context, result ProbeResult) {
+	c, ok := ctx.Value(probeCounterKey{}).(*probeCounter)
+	if !ok {
+		return
+	}
+	atomic.AddInt64(&c.sent, 1)
+	switch result {
+	case ProbeHit:
+		atomic.AddInt64(&c.hits, 1)
+	case ProbeError:
+		atomic.AddInt64(&c.errors, 1)
+	}
+}
+
+func (c *probeCounter) counts() ProbeCounts {
+	return ProbeCounts{
+		Sent:   int(atomic.LoadInt64(&c.sent)),
+		Hits:   int(atomic.LoadInt64(&c.hits)),
+		Errors: int(atomic.LoadInt64(&c.errors)),
+	}
+}
+
+func newCheckRecordID() string {
+	b := make([]byte, 16)
+	// crypto/rand only fails when the OS has no entropy source, in which
+	// case the process has bigger problems than a duplicate ID.
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// recordCheck stores the audit entry of a run and deletes the expired ones.
+// Like alerting, it never changes the outcome of the check.
+func (u UseCase) recordCheck(ctx context.Context, report RunReport) {
+	if u.checkRepository == nil {
+		return
+	}
+
+	c := CheckRecord{
+		ID:         newCheckRecordID(),
+		SettingID:  report.ID,
+		ServerCode: report.ServerCode,
+		Outcome:    report.Outcome,
+		Before:     report.Before,
+		After:      report.After,
+		Probes:     report.Probes,
+		StartedAt:  report.StartedAt,
+		FinishedAt: report.FinishedAt,
+	}
+	if report.Failure != nil {
+		c.FailureClass = report.Failure.Class
+		c.Failure = report.Failure.Message
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		c.TraceID = sc.TraceID().String()
+	}
+	if u.checkRetention > 0 {
+		c.ExpiresAt = report.FinishedAt.Add(u.checkRetention)
+	}
+
+	err := u.checkRepository.CreateCheck(ctx, c)
+	if err != nil {
+		zap.L().Error("cannot record check", logger.WithTraceId(ctx), zap.Any("ID", report.ID), zap.Error(err))
+		return
+	}
+
+	deleted, err := u.checkRepository.DeleteExpiredChecks(ctx, report.FinishedAt)
+	if err != nil {
+		zap.L().Error("cannot delete expired checks", logger.WithTraceId(ctx), zap.Error(err))
+		return
+	}
+	if deleted > 0 {
+		zap.L().Debug("deleted expired checks", logger.WithTraceId(ctx), zap.Int("count", deleted))
+	}
+}
+
+// ListChecks returns the recorded checks matching q, newest first.
+func (u UseCase) ListChecks(ctx context.Context, q CheckQuery) ([]CheckRecord, error) {
+	ctx, span := tracer.Start(ctx, "use_case.ListChecks")
+	defer span.End()
+
+	if u.checkRepository == nil {
+		return []CheckRecord{}, nil
+	}
+	if q.Limit <= 0 {
+		q.Limit = defaultCheckQueryLimit
+	}
+	return u.checkRepository.ListChecks(ctx, q)
+}
@@ -0,0 +1,59 @@
+package use_case_test
+
+import (
+	"context"
+	"github.com/SpeedxPz/pcrd-version-updater/src/repository/check_repository"
+	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
+	"testing"
+	"time"
+)
+
+func TestRecordCheck(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture("", 10000010, 10000030)
+	checks := check_repository.NewMemory()
+	f.useCase.SetCheckLog(checks, time.Hour)
+
+	report, err := f.useCase.UpdateResourceVersion(ctx, "jp.app")
+	if err != nil {
+		t.Fatalf("UpdateResourceVersion: %v", err)
+	}
+	if report.Probes != (use_case.ProbeCounts{Sent: 19, Hits: 2}) {
+		t.Fatalf("got probes %+v, want 19 sent and 2 hits", report.Probes)
+	}
+	f.clock.Advance(time.Minute)
+	if _, err := f.useCase.UpdateResourceVersion(ctx, "th.app"); err == nil {
+		t.Fatalf("UpdateResourceVersion: want the TH check to fail")
+	}
+
+	got, err := f.useCase.ListChecks(ctx, use_case.CheckQuery{})
+	if err != nil {
+		t.Fatalf("ListChecks: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("ListChecks: got %+v, want 2 checks", got)
+	}
+	th, jp := got[0], got[1]
+	if jp.SettingID != "jp.app" || jp.Outcome != use_case.RunCreated || jp.After.ResVersion != "10000030" || jp.Probes != report.Probes {
+		t.Fatalf("got %+v, want the created JP check", jp)
+	}
+	if !jp.ExpiresAt.Equal(jp.FinishedAt.Add(time.Hour)) {
+		t.Fatalf("got expiry %s, want an hour after %s", jp.ExpiresAt, jp.FinishedAt)
+	}
+	if th.Outcome != use_case.RunFailed || th.FailureClass != use_case.FailureNotAvailable || th.After != nil || th.Probes.Sent != 1 {
+		t.Fatalf("got %+v, want the failed TH check", th)
+	}
+
+	failed, err := f.useCase.ListChecks(ctx, use_case.CheckQuery{Outcome: use_case.RunFailed})
+	if err != nil {
+		t.Fatalf("ListChecks: %v", err)
+	}
+	if len(failed) != 1 || failed[0].SettingID != "th.app" {
+		t.Fatalf("ListChecks: got %+v, want the TH check", failed)
+	}
+
+	deleted, err := checks.DeleteExpiredChecks(ctx, f.clock.Now().Add(2*time.Hour))
+	if err != nil || deleted != 2 {
+		t.Fatalf("DeleteExpiredChecks: got %d, %v, want both checks expired", deleted, err)
+	}
+}
@@ -3,14 +3,114 @@ package use_case
 import (
 	"context"
 	"fmt"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/logger"
+	"go.opentelemetry.io/otel/codes"
+	"go.uber.org/zap"
+	"strings"
+	"sync"
+	"time"
 )
 
-func (u UseCase) HealthCheck(ctx context.Context) error {
+// healthCheckTimeout bounds each dependency check, so a hanging dependency
+// is reported as down instead of stalling the whole report.
+const healthCheckTimeout = 5 * time.Second
 
-	err := u.applicationRepository.HealthCheck(ctx)
-	if err != nil {
-		return fmt.Errorf("applicationRepository.HealthCheck: %w", err)
+type HealthStatus string
+
+const (
+	HealthUp   HealthStatus = "up"
+	HealthDown HealthStatus = "down"
+)
+
+type DependencyHealth struct {
+	Name    string
+	Status  HealthStatus
+	Error   string
+	Latency time.Duration
+}
+
+// HealthReport is the aggregated result of a liveness or readiness check.
+// Status is down when any dependency is down.
+type HealthReport struct {
+	Status       HealthStatus
+	Dependencies []DependencyHealth
+	CheckedAt    time.Time
+}
+
+// Liveness reports whether the process itself is healthy. It checks no
+// dependency, so an outage elsewhere never gets the process restarted.
+func (u UseCase) Liveness(ctx context.Context) HealthReport {
+	return HealthReport{Status: HealthUp, Dependencies: []DependencyHealth{}, CheckedAt: u.clock.Now()}
+}
+
+// Readiness checks every dependency concurrently and reports which ones are
+// down. The error wraps ErrDependencyUnavailable and names them.
+func (u UseCase) Readiness(ctx context.Context) (HealthReport, error) {
+	ctx, span := tracer.Start(ctx, "use_case.Readiness")
+	defer span.End()
+
+	checks := []dependencyCheck{
+		{"settings", u.settingRepository.HealthCheck},
+		{"versions", u.versionRepository.HealthCheck},
+		{"histories", u.historyRepository.HealthCheck},
+		{"application", u.applicationRepository.HealthCheck},
+		{"pcrd_th", u.pcrdTHRepository.HealthCheck},
+		{"pcrd_jp", u.pcrdJPRepository.HealthCheck},
+	}
+	if u.checkStateRepository != nil {
+		checks = append(checks, dependencyCheck{"check_states", u.checkStateRepository.HealthCheck})
+	}
+	if u.checkRepository != nil {
+		checks = append(checks, dependencyCheck{"checks", u.checkRepository.HealthCheck})
 	}
+	if checker, ok := u.versionEventRepository.(HealthChecker); ok {
+		checks = append(checks, dependencyCheck{"events", checker.HealthCheck})
+	}
+
+	report := HealthReport{Status: HealthUp, Dependencies: make([]DependencyHealth, len(checks))}
+	var wg sync.WaitGroup
+	for i := range checks {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			report.Dependencies[i] = u.checkDependency(ctx, checks[i].name, checks[i].check)
+		}(i)
+	}
+	wg.Wait()
+	report.CheckedAt = u.clock.Now()
 
-	return nil
+	var down []string
+	for _, d := range report.Dependencies {
+		if d.Status == HealthDown {
+			down = append(down, d.Name)
+		}
+	}
+	if len(down) > 0 {
+		report.Status = HealthDown
+		err := fmt.Errorf("%s: %w", strings.Join(down, ", "), ErrDependencyUnavailable)
+		zap.L().Error("readiness check failed", logger.WithTraceId(ctx), zap.Error(err))
+		span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+		return report, err
+	}
+
+	return report, nil
+}
+
+type dependencyCheck struct {
+	name  string
+	check func(ctx context.Context) error
+}
+
+func (u UseCase) checkDependency(ctx context.Context, name string, check func(ctx context.Context) error) DependencyHealth {
+	ctx, cancel := u.clock.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	start := u.clock.Now()
+	err := check(ctx)
+	d := DependencyHealth{Name: name, Status: HealthUp, Latency: u.clock.Since(start)}
+	if err != nil {
+		d.Status = HealthDown
+		d.Error = err.Error()
+	}
+	return d
 }
@@ -0,0 +1,70 @@
+package use_case_test
+
+import (
+	"context"
+	"errors"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/clock"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/credential"
+	"github.com/SpeedxPz/pcrd-version-updater/src/repository/application_repository"
+	"github.com/SpeedxPz/pcrd-version-updater/src/repository/history_repository"
+	"github.com/SpeedxPz/pcrd-version-updater/src/repository/pcrd_jp_repository"
+	"github.com/SpeedxPz/pcrd-version-updater/src/repository/setting_repository"
+	"github.com/SpeedxPz/pcrd-version-updater/src/repository/version_event_repository"
+	"github.com/SpeedxPz/pcrd-version-updater/src/repository/version_repository"
+	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
+	"strings"
+	"testing"
+)
+
+type downTHRepository struct{}
+
+func (downTHRepository) GetResourceVersion(ctx context.Context, c credential.Credential, v use_case.PcrdVersion) (string, error) {
+	return "", use_case.ErrRetrieveData
+}
+
+func (downTHRepository) HealthCheck(ctx context.Context) error {
+	return use_case.ErrRetrieveData
+}
+
+func TestReadiness(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture("00150010")
+
+	report, err := f.useCase.Readiness(ctx)
+	if err != nil {
+		t.Fatalf("Readiness: %v", err)
+	}
+	if report.Status != use_case.HealthUp || len(report.Dependencies) != 6 {
+		t.Fatalf("Readiness: got %+v, want 6 dependencies up", report)
+	}
+	if !report.CheckedAt.Equal(testNow) {
+		t.Fatalf("Readiness: checked at %v, want the clock's %v", report.CheckedAt, testNow)
+	}
+
+	u := use_case.New(
+		application_repository.NewMemory(apps...),
+		setting_repository.NewMemory(thSetting),
+		downTHRepository{},
+		pcrd_jp_repository.NewMemory(),
+		version_repository.NewMemory(),
+		history_repository.NewMemory(clock.Real()),
+		version_event_repository.NewMemory(),
+		clock.Real(),
+	)
+	report, err = u.Readiness(ctx)
+	if !errors.Is(err, use_case.ErrDependencyUnavailable) || !strings.Contains(err.Error(), "pcrd_th") {
+		t.Fatalf("Readiness: got %v, want pcrd_th unavailable", err)
+	}
+	if report.Status != use_case.HealthDown {
+		t.Fatalf("Readiness: got status %s, want down", report.Status)
+	}
+	for _, d := range report.Dependencies {
+		if (d.Name == "pcrd_th") != (d.Status == use_case.HealthDown) {
+			t.Errorf("Readiness: got %+v", d)
+		}
+	}
+
+	if live := u.Liveness(ctx); live.Status != use_case.HealthUp {
+		t.Fatalf("Liveness: got %+v, want up", live)
+	}
+}
@@ -1,12 +1,98 @@
 package use_case
 
 import (
+	"fmt"
 	"github.com/SpeedxPz/pcrd-version-updater/src/entity/credential"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/platform"
 	"github.com/SpeedxPz/pcrd-version-updater/src/entity/setting"
+	"regexp"
+	"strconv"
 )
 
+// DefaultJPPlatforms and DefaultJPLocales are probed for a JP setting that
+// names none.
+var (
+	DefaultJPPlatforms = []platform.PlatformType{platform.PlatformTypeAndroid}
+	DefaultJPLocales   = []string{"Jpn"}
+)
+
+var localePattern = regexp.MustCompile(`^[A-Za-z]+$`)
+
 type PCRDSetting struct {
 	Setting           setting.Setting
 	Credential        credential.Credential
 	GuessStartVersion string
+	// Platforms and Locales are the JP manifests to probe. The first
+	// platform is the primary one: its resource version is the one stored
+	// and announced.
+	Platforms []platform.PlatformType
+	Locales   []string
+}
+
+// JPPlatforms returns the platforms to probe, DefaultJPPlatforms when the
+// setting names none.
+func (s PCRDSetting) JPPlatforms() []platform.PlatformType {
+	if len(s.Platforms) <= 0 {
+		return DefaultJPPlatforms
+	}
+	return s.Platforms
+}
+
+// JPLocales returns the locales to probe, DefaultJPLocales when the setting
+// names none.
+func (s PCRDSetting) JPLocales() []string {
+	if len(s.Locales) <= 0 {
+		return DefaultJPLocales
+	}
+	return s.Locales
+}
+
+func (s PCRDSetting) Validate() error {
+	if len(s.Setting.ID) <= 0 {
+		return fmt.Errorf("id is required: %w", ErrInvalidSetting)
+	}
+
+	serverCode, err := setting.ParseServerCode(string(s.Setting.ServerCode))
+	if err != nil {
+		return fmt.Errorf("%s: %w", err, ErrInvalidSetting)
+	}
+
+	switch serverCode {
+	case setting.ServerCodeTH:
+		if len(s.Credential.Udid) <= 0 {
+			return fmt.Errorf("credential udid is required for %s: %w", serverCode, ErrInvalidSetting)
+		}
+		if s.Credential.ShortUdid <= 0 {
+			return fmt.Errorf("credential short udid is required for %s: %w", serverCode, ErrInvalidSetting)
+		}
+		if s.Credential.ViewerID <= 0 {
+			return fmt.Errorf("credential viewer id is required for %s: %w", serverCode, ErrInvalidSetting)
+		}
+		if len(s.Platforms) > 0 || len(s.Locales) > 0 {
+			return fmt.Errorf("platforms and locales only apply to %s: %w", setting.ServerCodeJP, ErrInvalidSetting)
+		}
+	case setting.ServerCodeJP:
+		if _, err := strconv.ParseInt(s.GuessStartVersion, 10, 64); err != nil {
+			return fmt.Errorf("guess start version %q must be a number for %s: %w", s.GuessStartVersion, serverCode, ErrInvalidSetting)
+		}
+		seen := map[platform.PlatformType]struct{}{}
+		for _, p := range s.Platforms {
+			if _, err := platform.ParsePlatformType(string(p)); err != nil || p == platform.PlatformTypeNone {
+				return fmt.Errorf("platform %q is not android, ios or windows: %w", p, ErrInvalidSetting)
+			}
+			if _, ok := seen[p]; ok {
+				return fmt.Errorf("platform %s is listed twice: %w", p, ErrInvalidSetting)
+			}
+			seen[p] = struct{}{}
+		}
+		for _, l := range s.Locales {
+			if !localePattern.MatchString(l) {
+				return fmt.Errorf("locale %q must be letters only, like Jpn: %w", l, ErrInvalidSetting)
+			}
+		}
+	default:
+		return fmt.Errorf("server code is required: %w", ErrInvalidSetting)
+	}
+
+	return nil
 }
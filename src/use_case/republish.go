@@ -0,0 +1,127 @@
+package use_case
+
+import (
+	"context"
+	"fmt"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/logger"
+	"go.opentelemetry.io/otel/codes"
+	"go.uber.org/zap"
+	"time"
+)
+
+type RepublishOptions struct {
+	// Rate caps published events per second; zero is unlimited.
+	Rate float64
+	// DryRun returns the events without publishing them.
+	DryRun bool
+}
+
+// RepublishVersions publishes a snapshot event for every current version to
+// publisher. Versions without a resource version have never been announced
+// and are skipped.
+func (u UseCase) RepublishVersions(ctx context.Context, publisher VersionEventRepository, opts RepublishOptions) ([]VersionEvent, error) {
+	ctx, span := tracer.Start(ctx, "use_case.RepublishVersions")
+	defer span.End()
+
+	versions, err := u.versionRepository.ListVersions(ctx)
+	if err != nil {
+		span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+		return nil, err
+	}
+
+	now := u.clock.Now()
+	var events []VersionEvent
+	for _, v := range versions {
+		if len(v.ResVersion) <= 0 {
+			continue
+		}
+		events = append(events, newVersionEvent(VersionEventSnapshot, v.Setting, nil, &PcrdVersion{
+			AppVersion: v.AppVersion,
+			ResVersion: v.ResVersion,
+		}, now))
+	}
+
+	published, err := u.republish(ctx, publisher, events, opts)
+	if err != nil {
+		span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+	}
+	return published, err
+}
+
+// ReplayHistories publishes again the events of history entries created in
+// [from, to), oldest first. Entries before from are read too, so the first
+// replayed change of a setting still knows its before version.
+func (u UseCase) ReplayHistories(ctx context.Context, publisher VersionEventRepository, from time.Time, to time.Time, opts RepublishOptions) ([]VersionEvent, error) {
+	ctx, span := tracer.Start(ctx, "use_case.ReplayHistories")
+	defer span.End()
+
+	if !to.IsZero() && !from.Before(to) {
+		span.SetStatus(codes.Error, "empty time range")
+		return nil, fmt.Errorf("from %s is not before to %s: %w", from.Format(time.RFC3339), to.Format(time.RFC3339), ErrInvalidRequestParam)
+	}
+
+	histories, err := u.historyRepository.ListHistories(ctx, "", time.Time{}, to)
+	if err != nil {
+		span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+		return nil, err
+	}
+
+	last := map[string]*PcrdVersion{}
+	var events []VersionEvent
+	for _, h := range histories {
+		after := &PcrdVersion{
+			AppVersion: h.Version.AppVersion,
+			ResVersion: h.Version.ResVersion,
+		}
+		before := last[h.Version.Setting.ID]
+		last[h.Version.Setting.ID] = after
+
+		if h.CreatedAt.Before(from) {
+			continue
+		}
+		events = append(events, changeEvents(h.Version.Setting, before, after, h.CreatedAt)...)
+	}
+
+	published, err := u.republish(ctx, publisher, events, opts)
+	if err != nil {
+		span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+	}
+	return published, err
+}
+
+// republish publishes events in order, at most opts.Rate per second, and
+// returns those published before any failure.
+func (u UseCase) republish(ctx context.Context, publisher VersionEventRepository, events []VersionEvent, opts RepublishOptions) ([]VersionEvent, error) {
+	if opts.DryRun {
+		return events, nil
+	}
+
+	var tick <-chan time.Time
+	if opts.Rate > 0 {
+		ticker := u.clock.NewTicker(time.Duration(float64(time.Second) / opts.Rate))
+		defer ticker.Stop()
+		tick = ticker.C()
+	}
+
+	for i, event := range events {
+		if tick != nil && i > 0 {
+			select {
+			case <-ctx.Done():
+				return events[:i], ctx.Err()
+			case <-tick:
+			}
+		}
+
+		err := publisher.PublishEvent(ctx, event)
+		if err != nil {
+			return events[:i], err
+		}
+	}
+
+	zap.L().Info("use_case.republish",
+		logger.WithTraceId(ctx),
+		zap.Int("published", len(events)),
+	)
+
+	return events, nil
+}
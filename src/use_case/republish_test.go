@@ -0,0 +1,127 @@
+package use_case_test
+
+import (
+	"context"
+	"github.com/SpeedxPz/pcrd-version-updater/src/repository/version_event_repository"
+	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func eventTypes(events []use_case.VersionEvent) []use_case.VersionEventType {
+	var types []use_case.VersionEventType
+	for _, e := range events {
+		types = append(types, e.Type)
+	}
+	return types
+}
+
+// waitPublished waits for the publish running in another goroutine to reach
+// n events.
+func waitPublished(t *testing.T, m *version_event_repository.Memory, n int) {
+	deadline := time.Now().Add(5 * time.Second)
+	for len(m.Published()) < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("got %d events published, want %d", len(m.Published()), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if got := len(m.Published()); got != n {
+		t.Fatalf("got %d events published, want %d before the next tick", got, n)
+	}
+}
+
+func TestRepublishVersions(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture("00150010")
+	if _, err := f.useCase.UpdateResourceVersion(ctx, "th.app"); err != nil {
+		t.Fatalf("UpdateResourceVersion: %v", err)
+	}
+	// Not yet announced: created, but the check failed.
+	if err := f.versions.Create(ctx, use_case.GameVersion{Setting: jpSetting.Setting, AppVersion: "6.0.0"}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	target := version_event_repository.NewMemory()
+	listed, err := f.useCase.RepublishVersions(ctx, target, use_case.RepublishOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("RepublishVersions: %v", err)
+	}
+	if len(listed) != 1 || len(target.Published()) != 0 {
+		t.Fatalf("dry run: listed %d, published %d", len(listed), len(target.Published()))
+	}
+
+	published, err := f.useCase.RepublishVersions(ctx, target, use_case.RepublishOptions{})
+	if err != nil {
+		t.Fatalf("RepublishVersions: %v", err)
+	}
+	got := target.Published()
+	if len(published) != 1 || len(got) != 1 {
+		t.Fatalf("got %d events, want 1", len(got))
+	}
+	if got[0].Type != use_case.VersionEventSnapshot || got[0].Setting.ID != "th.app" || got[0].After.ResVersion != "00150010" {
+		t.Fatalf("got %+v", got[0])
+	}
+}
+
+func TestReplayHistories(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture("")
+	th := thSetting.Setting
+	record := func(app string, res string) {
+		if err := f.history.Create(ctx, use_case.GameVersion{Setting: th, AppVersion: app, ResVersion: res}); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+	}
+
+	record("3.1.0", "10")
+	f.clock.Advance(time.Minute)
+	from := f.clock.Now()
+	record("3.2.0", "10")
+	record("3.2.0", "20")
+	record("3.3.0", "30")
+
+	target := version_event_repository.NewMemory()
+	type result struct {
+		published []use_case.VersionEvent
+		err       error
+	}
+	done := make(chan result, 1)
+	go func() {
+		published, err := f.useCase.ReplayHistories(ctx, target, from, time.Time{}, use_case.RepublishOptions{Rate: 1})
+		done <- result{published, err}
+	}()
+	// One event a second: each tick of the clock lets the next one out.
+	for i := 1; i < 4; i++ {
+		waitPublished(t, target, i)
+		f.clock.Advance(time.Second)
+	}
+	r := <-done
+	published, err := r.published, r.err
+	if err != nil {
+		t.Fatalf("ReplayHistories: %v", err)
+	}
+
+	want := []use_case.VersionEventType{
+		use_case.VersionEventAppChanged,
+		use_case.VersionEventResourceChanged,
+		use_case.VersionEventAppChanged,
+		use_case.VersionEventResourceChanged,
+	}
+	if got := eventTypes(target.Published()); !reflect.DeepEqual(got, want) {
+		t.Fatalf("ReplayHistories: got %v, want %v", got, want)
+	}
+	if len(published) != len(want) {
+		t.Fatalf("ReplayHistories: returned %d events, want %d", len(published), len(want))
+	}
+	first := target.Published()[0]
+	if first.Before.AppVersion != "3.1.0" || first.After.AppVersion != "3.2.0" {
+		t.Fatalf("first replayed event: got %+v -> %+v, want the entry before from as before", first.Before, first.After)
+	}
+
+	_, err = f.useCase.ReplayHistories(ctx, target, from, from, use_case.RepublishOptions{})
+	if err == nil {
+		t.Fatalf("ReplayHistories: empty range accepted")
+	}
+}
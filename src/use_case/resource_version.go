@@ -4,110 +4,298 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/application"
 	"github.com/SpeedxPz/pcrd-version-updater/src/entity/logger"
 	"github.com/SpeedxPz/pcrd-version-updater/src/entity/setting"
 	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
+	"time"
 )
 
+// afterRunBudget bounds each write made once a run has ended: the check
+// failed event, the alert state and the check record.
+const afterRunBudget = 10 * time.Second
+
+// UpdateResourceVersion checks the setting ID against the store and the game
+// server, stores and announces any change, and reports what it did. The
+// report is filled in on failure too; err is then also returned. With
+// alerting set, the check is then tracked for alerts, and with a check log
+// set, recorded, even when ctx was cancelled or ran out of time.
 func (u UseCase) UpdateResourceVersion(
 	ctx context.Context,
 	ID string,
-) error {
+) (RunReport, error) {
 	ctx, span := tracer.Start(ctx, fmt.Sprintf("use_case.UpdateResourceVersion(%s)", ID))
 	defer span.End()
+
+	ctx, probes := withProbeCounter(ctx)
+	report, err := u.updateResourceVersion(ctx, ID, true)
+	report.Probes = probes.counts()
+
+	trackCtx, cancel := u.afterRunContext(ctx)
+	u.trackCheck(trackCtx, report)
+	cancel()
+	recordCtx, cancel := u.afterRunContext(ctx)
+	u.recordCheck(recordCtx, report)
+	cancel()
+	return report, err
+}
+
+// PlanResourceVersion checks the setting ID like UpdateResourceVersion but
+// only reports the outcome the check would have: it stores, publishes,
+// tracks and records nothing. The remote calls are still made.
+func (u UseCase) PlanResourceVersion(
+	ctx context.Context,
+	ID string,
+) (RunReport, error) {
+	ctx, span := tracer.Start(ctx, fmt.Sprintf("use_case.PlanResourceVersion(%s)", ID))
+	defer span.End()
+
+	ctx, probes := withProbeCounter(ctx)
+	report, err := u.updateResourceVersion(ctx, ID, false)
+	report.Probes = probes.counts()
+	return report, err
+}
+
+// updateResourceVersion runs a check; only with apply does it write the
+// versions and publish the events.
+func (u UseCase) updateResourceVersion(
+	ctx context.Context,
+	ID string,
+	apply bool,
+) (RunReport, error) {
+	span := trace.SpanFromContext(ctx)
 	zap.L().Info("use_case.UpdateResourceVersion",
 		logger.WithTraceId(ctx),
 		zap.Any("ID", ID),
 	)
 
-	appSetting, err := u.settingRepository.GetSettingByID(ctx, ID)
-	if err != nil {
+	report := RunReport{ID: ID, StartedAt: u.clock.Now(), clock: u.clock}
+	fail := func(s setting.Setting, before *PcrdVersion, err error) (RunReport, error) {
 		span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
-		return err
+		if apply {
+			failedCtx, cancel := u.afterRunContext(ctx)
+			u.publishCheckFailed(failedCtx, s, before, err)
+			cancel()
+		}
+		report.finish(RunFailed, err)
+		return report, err
 	}
 
-	application, err := u.applicationRepository.GetAndroidAppByID(ctx, appSetting.Setting.ID)
+	var appSetting PCRDSetting
+	err := report.runStep(ctx, "setting", u.budgets.Storage, func(ctx context.Context) (err error) {
+		appSetting, err = u.settingRepository.GetSettingByID(ctx, ID)
+		return err
+	})
 	if err != nil {
-		span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+		return fail(setting.Setting{ID: ID}, nil, err)
+	}
+	report.ServerCode = appSetting.Setting.ServerCode
+
+	var currentVersion GameVersion
+	err = report.runStep(ctx, "version", u.budgets.Storage, func(ctx context.Context) (err error) {
+		currentVersion, err = u.versionRepository.GetByID(ctx, appSetting.Setting.ID)
 		return err
+	})
+	if err != nil && !errors.Is(err, ErrVersionNotFound) {
+		return fail(appSetting.Setting, nil, err)
 	}
+	created := err != nil
 
-	currentVersion, err := u.versionRepository.GetByID(ctx, appSetting.Setting.ID)
-	if err != nil {
-		if errors.Is(err, ErrVersionNotFound) {
-			gameVersion := GameVersion{
-				Setting:    appSetting.Setting,
-				AppVersion: application.Version,
-				ResVersion: "",
-			}
-			err = u.versionRepository.Create(ctx, gameVersion)
-			if err != nil {
-				span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
-				return err
-			}
-			currentVersion = gameVersion
-		} else {
-			span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
-			return err
+	// A version without a resource version was stored by a check that
+	// failed afterwards, so it has not been announced yet either.
+	var before *PcrdVersion
+	if !created && len(currentVersion.ResVersion) > 0 {
+		before = &PcrdVersion{
+			AppVersion: currentVersion.AppVersion,
+			ResVersion: currentVersion.ResVersion,
 		}
 	}
+	report.Before = before
 
-	var version string
+	var app application.Application
+	err = report.runStep(ctx, "application", u.budgets.Application, func(ctx context.Context) (err error) {
+		app, err = u.applicationRepository.GetAndroidAppByID(ctx, appSetting.Setting.ID)
+		return err
+	})
+	if err != nil {
+		return fail(appSetting.Setting, before, err)
+	}
 
-	if appSetting.Setting.ServerCode == setting.ServerCodeTH {
-		result, err := u.pcrdTHRepository.GetResourceVersion(ctx, appSetting.Credential, PcrdVersion{
-			AppVersion: application.Version,
+	if created && apply {
+		gameVersion := GameVersion{
+			Setting:    appSetting.Setting,
+			AppVersion: app.Version,
+			ResVersion: "",
+		}
+		err = report.runStep(ctx, "create", u.budgets.Storage, func(ctx context.Context) error {
+			return u.versionRepository.Create(ctx, gameVersion)
 		})
 		if err != nil {
-			span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+			return fail(appSetting.Setting, before, err)
+		}
+		currentVersion = gameVersion
+	}
+
+	var version string
+	err = report.runStep(ctx, "resource", u.budgets.Resource, func(ctx context.Context) (err error) {
+		if appSetting.Setting.ServerCode == setting.ServerCodeTH {
+			version, err = u.pcrdTHRepository.GetResourceVersion(ctx, appSetting.Credential, PcrdVersion{
+				AppVersion: app.Version,
+			})
 			return err
 		}
-		version = result
-	} else {
+
 		// Japan Logic
 		guessVersion := currentVersion.ResVersion
 		if len(guessVersion) <= 0 {
 			guessVersion = appSetting.GuessStartVersion
 		}
 
-		result, err := u.pcrdJPRepository.GetResourceVersion(ctx, guessVersion)
+		platforms, err := u.pcrdJPRepository.GetResourceVersions(ctx, guessVersion, appSetting.JPPlatforms(), appSetting.JPLocales())
 		if err != nil {
-			span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
 			return err
 		}
-		version = result
+		if len(platforms) <= 0 {
+			return fmt.Errorf("no platform version returned: %w", ErrRetrieveData)
+		}
+		version = platforms[0].ResVersion
+		report.Platforms = platforms
+		report.PlatformsDisagree = platformsDisagree(platforms)
+		return nil
+	})
+	if err != nil {
+		return fail(appSetting.Setting, before, err)
+	}
+	if report.PlatformsDisagree {
+		zap.L().Warn("platforms disagree on the resource version",
+			logger.WithTraceId(ctx),
+			zap.String("ID", ID),
+			zap.Any("platforms", report.Platforms),
+		)
+	}
+
+	after := &PcrdVersion{
+		AppVersion: app.Version,
+		ResVersion: version,
 	}
+	report.After = after
+
+	events := changeEvents(appSetting.Setting, before, after, u.clock.Now())
 
-	if currentVersion.ResVersion == version {
+	if len(events) <= 0 {
 		zap.L().Info("use_case.UpdateResourceVersion",
 			logger.WithTraceId(ctx),
 			zap.Any("message", "nothing to update"),
 		)
 		// Nothing to update
-		return nil
+		report.finish(RunUnchanged, nil)
+		return report, nil
+	}
+
+	outcome := RunUpdated
+	if before == nil {
+		outcome = RunCreated
+	}
+	if !apply {
+		report.finish(outcome, nil)
+		return report, nil
 	}
 
 	currentVersion.ResVersion = version
-	currentVersion.AppVersion = application.Version
+	currentVersion.AppVersion = app.Version
 
-	err = u.versionRepository.Update(ctx, currentVersion)
+	// The events go out before the version is stored: a run failing in
+	// between then leaves the change to the next run, which announces it
+	// again, instead of storing a change nobody heard of.
+	err = report.runStep(ctx, "publish", u.budgets.Publish, func(ctx context.Context) error {
+		for _, event := range events {
+			if err := u.versionEventRepository.PublishEvent(ctx, event); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 	if err != nil {
-		span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
-		return err
+		return fail(appSetting.Setting, before, err)
 	}
 
-	err = u.historyRepository.Create(ctx, currentVersion)
+	err = report.runStep(ctx, "store", u.budgets.Storage, func(ctx context.Context) error {
+		return u.updateVersionWithHistory(ctx, currentVersion)
+	})
 	if err != nil {
-		span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
-		return err
+		return fail(appSetting.Setting, before, err)
 	}
 
-	u.versionEventRepository.PublishVersion(ctx, currentVersion)
+	report.finish(outcome, nil)
+	return report, nil
+}
+
+// publishCheckFailed announces a failed check. The check's own error is what
+// the caller returns, so a publish failure here is only logged.
+func (u UseCase) publishCheckFailed(ctx context.Context, s setting.Setting, before *PcrdVersion, checkErr error) {
+	err := u.versionEventRepository.PublishEvent(ctx, newCheckFailedEvent(s, before, checkErr, u.clock.Now()))
+	if err != nil {
+		zap.L().Error("cannot publish check failure",
+			logger.WithTraceId(ctx),
+			zap.Any("ID", s.ID),
+			zap.Error(err),
+		)
+	}
+}
+
+func (u UseCase) updateVersionWithHistory(ctx context.Context, version GameVersion) error {
+	if repo, ok := u.versionRepository.(VersionHistoryRepository); ok {
+		return repo.UpdateWithHistory(ctx, version)
+	}
+
+	err := u.versionRepository.Update(ctx, version)
 	if err != nil {
-		span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
 		return err
 	}
 
+	return u.historyRepository.Create(ctx, version)
+}
+
+// platformsDisagree reports whether any platform is on another resource
+// version than the primary one, usually because iOS or the PC build lags
+// Android.
+func platformsDisagree(platforms []PlatformVersion) bool {
+	for _, p := range platforms[1:] {
+		if p.ResVersion != platforms[0].ResVersion {
+			return true
+		}
+	}
+	return false
+}
+
+// detachedContext carries the values of its parent, such as the trace and
+// the probe counter, but never ends with it.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (detachedContext) Err() error {
 	return nil
 }
+
+func (c detachedContext) Value(key interface{}) interface{} {
+	return c.parent.Value(key)
+}
+
+// afterRunContext is the context of a write made once the run has ended. A
+// run that timed out or was interrupted is the one operators most need to
+// hear about, so the write gets afterRunBudget of its own instead of what is
+// left of ctx.
+func (u UseCase) afterRunContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return u.clock.WithTimeout(detachedContext{ctx}, afterRunBudget)
+}
@@ -0,0 +1,586 @@
+package use_case_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/application"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/clock"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/credential"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/platform"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/setting"
+	"github.com/SpeedxPz/pcrd-version-updater/src/repository/application_repository"
+	"github.com/SpeedxPz/pcrd-version-updater/src/repository/check_repository"
+	"github.com/SpeedxPz/pcrd-version-updater/src/repository/check_state_repository"
+	"github.com/SpeedxPz/pcrd-version-updater/src/repository/history_repository"
+	"github.com/SpeedxPz/pcrd-version-updater/src/repository/pcrd_jp_repository"
+	"github.com/SpeedxPz/pcrd-version-updater/src/repository/pcrd_th_repository"
+	"github.com/SpeedxPz/pcrd-version-updater/src/repository/setting_repository"
+	"github.com/SpeedxPz/pcrd-version-updater/src/repository/version_event_repository"
+	"github.com/SpeedxPz/pcrd-version-updater/src/repository/version_repository"
+	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	thSetting = use_case.PCRDSetting{
+		Setting:    setting.Setting{ID: "th.app", ServerCode: setting.ServerCodeTH},
+		Credential: credential.Credential{Udid: "udid", ShortUdid: 1, ViewerID: 2},
+	}
+	jpSetting = use_case.PCRDSetting{
+		Setting:           setting.Setting{ID: "jp.app", ServerCode: setting.ServerCodeJP},
+		GuessStartVersion: "10000000",
+	}
+	// testNow is when the fixture's fake clock starts.
+	testNow = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	apps    = []application.Application{
+		{BundleID: "th.app", Version: "3.1.0"},
+		{BundleID: "jp.app", Version: "6.0.0"},
+	}
+)
+
+type fixture struct {
+	useCase  *use_case.UseCase
+	versions use_case.VersionRepository
+	history  *history_repository.Memory
+	events   *version_event_repository.Memory
+	clock    *clock.Fake
+}
+
+func newFixture(thResVersion string, jpPublished ...int64) fixture {
+	clk := clock.NewFake(testNow)
+	f := fixture{
+		versions: version_repository.NewMemory(),
+		history:  history_repository.NewMemory(clk),
+		events:   version_event_repository.NewMemory(),
+		clock:    clk,
+	}
+	f.useCase = use_case.New(
+		application_repository.NewMemory(apps...),
+		setting_repository.NewMemory(thSetting, jpSetting),
+		pcrd_th_repository.NewMemory(thResVersion),
+		pcrd_jp_repository.NewMemory(jpPublished...),
+		f.versions,
+		f.history,
+		f.events,
+		f.clock,
+	)
+	return f
+}
+
+func TestUpdateResourceVersionTH(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture("00150010")
+
+	if _, err := f.useCase.UpdateResourceVersion(ctx, "th.app"); err != nil {
+		t.Fatalf("UpdateResourceVersion: %v", err)
+	}
+
+	got, err := f.versions.GetByID(ctx, "th.app")
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if got.AppVersion != "3.1.0" || got.ResVersion != "00150010" {
+		t.Fatalf("GetByID: got %+v", got)
+	}
+	if len(f.history.Histories()) != 1 || len(f.events.Published()) != 1 {
+		t.Fatalf("got %d histories and %d events, want 1 each", len(f.history.Histories()), len(f.events.Published()))
+	}
+	if e := f.events.Published()[0]; e.Type != use_case.VersionEventCreated || e.Before != nil || e.After.ResVersion != "00150010" {
+		t.Fatalf("got %+v, want version created", e)
+	}
+
+	if _, err := f.useCase.UpdateResourceVersion(ctx, "th.app"); err != nil {
+		t.Fatalf("UpdateResourceVersion: %v", err)
+	}
+	if len(f.history.Histories()) != 1 || len(f.events.Published()) != 1 {
+		t.Fatalf("unchanged version was recorded again")
+	}
+}
+
+func TestUpdateResourceVersionJP(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture("", 10000010, 10000030)
+
+	if _, err := f.useCase.UpdateResourceVersion(ctx, "jp.app"); err != nil {
+		t.Fatalf("UpdateResourceVersion: %v", err)
+	}
+
+	got, err := f.versions.GetByID(ctx, "jp.app")
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if got.ResVersion != "10000030" {
+		t.Fatalf("GetByID: got %+v, want resource version 10000030", got)
+	}
+}
+
+func TestUpdateResourceVersionJPPlatforms(t *testing.T) {
+	ctx := context.Background()
+	jp := jpSetting
+	jp.Platforms = []platform.PlatformType{platform.PlatformTypeAndroid, platform.PlatformTypeIOS}
+	newUseCase := func(published map[platform.PlatformType][]int64) (*use_case.UseCase, use_case.VersionRepository) {
+		versions := version_repository.NewMemory()
+		return use_case.New(
+			application_repository.NewMemory(apps...),
+			setting_repository.NewMemory(jp),
+			pcrd_th_repository.NewMemory(""),
+			pcrd_jp_repository.NewMemoryByPlatform(published),
+			versions,
+			history_repository.NewMemory(clock.Real()),
+			version_event_repository.NewMemory(),
+			clock.Real(),
+		), versions
+	}
+
+	for _, c := range []struct {
+		name      string
+		published map[platform.PlatformType][]int64
+		want      []use_case.PlatformVersion
+		disagree  bool
+	}{
+		{
+			name:      "agree",
+			published: map[platform.PlatformType][]int64{platform.PlatformTypeNone: {10000010, 10000030}},
+			want: []use_case.PlatformVersion{
+				{Platform: platform.PlatformTypeAndroid, ResVersion: "10000030"},
+				{Platform: platform.PlatformTypeIOS, ResVersion: "10000030"},
+			},
+		},
+		{
+			name: "ios lags",
+			published: map[platform.PlatformType][]int64{
+				platform.PlatformTypeNone:    {10000010},
+				platform.PlatformTypeAndroid: {10000030},
+			},
+			want: []use_case.PlatformVersion{
+				{Platform: platform.PlatformTypeAndroid, ResVersion: "10000030"},
+				{Platform: platform.PlatformTypeIOS, ResVersion: "10000010"},
+			},
+			disagree: true,
+		},
+	} {
+		u, versions := newUseCase(c.published)
+		report, err := u.UpdateResourceVersion(ctx, "jp.app")
+		if err != nil {
+			t.Fatalf("%s: UpdateResourceVersion: %v", c.name, err)
+		}
+		if !reflect.DeepEqual(report.Platforms, c.want) || report.PlatformsDisagree != c.disagree {
+			t.Fatalf("%s: got platforms %+v, disagree %v, want %+v, %v", c.name, report.Platforms, report.PlatformsDisagree, c.want, c.disagree)
+		}
+		// The primary platform's version is the one kept.
+		got, err := versions.GetByID(ctx, "jp.app")
+		if err != nil || got.ResVersion != "10000030" {
+			t.Fatalf("%s: GetByID: got %+v, %v, want the android version", c.name, got, err)
+		}
+	}
+}
+
+func TestUpdateResourceVersionTHNotAvailable(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture("")
+
+	report, err := f.useCase.UpdateResourceVersion(ctx, "th.app")
+	if !errors.Is(err, use_case.ErrResVerNotAvailable) {
+		t.Fatalf("UpdateResourceVersion: got %v, want %v", err, use_case.ErrResVerNotAvailable)
+	}
+	if report.Outcome != use_case.RunFailed || report.Failure == nil || report.Failure.Class != use_case.FailureNotAvailable {
+		t.Fatalf("got report %+v, want not available failure", report)
+	}
+	if report.After != nil || report.FinishedAt.Before(report.StartedAt) {
+		t.Fatalf("got report %+v, want no after version and a finish time", report)
+	}
+	events := f.events.Published()
+	if len(events) != 1 || events[0].Type != use_case.VersionEventCheckFailed {
+		t.Fatalf("got %+v, want one check failed event", events)
+	}
+	if events[0].Failure.Class != use_case.FailureNotAvailable || events[0].Before != nil || events[0].After != nil {
+		t.Fatalf("got %+v, want not available failure without versions", events[0])
+	}
+}
+
+func TestUpdateResourceVersionMissingSetting(t *testing.T) {
+	f := newFixture("")
+
+	_, err := f.useCase.UpdateResourceVersion(context.Background(), "missing")
+	if !errors.Is(err, use_case.ErrSettingNotExists) {
+		t.Fatalf("UpdateResourceVersion: got %v, want %v", err, use_case.ErrSettingNotExists)
+	}
+}
+
+func TestUpdateResourceVersionAppAndResourceChanged(t *testing.T) {
+	ctx := context.Background()
+	versions := version_repository.NewMemory()
+	events := version_event_repository.NewMemory()
+	newUseCase := func(appVersion string, resVersion string) *use_case.UseCase {
+		return use_case.New(
+			application_repository.NewMemory(application.Application{BundleID: "th.app", Version: appVersion}),
+			setting_repository.NewMemory(thSetting),
+			pcrd_th_repository.NewMemory(resVersion),
+			pcrd_jp_repository.NewMemory(),
+			versions,
+			history_repository.NewMemory(clock.Real()),
+			events,
+			clock.Real(),
+		)
+	}
+
+	for _, step := range []struct {
+		appVersion string
+		resVersion string
+		want       []use_case.VersionEventType
+		outcome    use_case.RunOutcome
+	}{
+		{"3.1.0", "00150010", []use_case.VersionEventType{use_case.VersionEventCreated}, use_case.RunCreated},
+		{"3.2.0", "00150010", []use_case.VersionEventType{use_case.VersionEventAppChanged}, use_case.RunUpdated},
+		{"3.2.0", "00150020", []use_case.VersionEventType{use_case.VersionEventResourceChanged}, use_case.RunUpdated},
+		{"3.3.0", "00150030", []use_case.VersionEventType{use_case.VersionEventAppChanged, use_case.VersionEventResourceChanged}, use_case.RunUpdated},
+		{"3.3.0", "00150030", nil, use_case.RunUnchanged},
+	} {
+		before := len(events.Published())
+		report, err := newUseCase(step.appVersion, step.resVersion).UpdateResourceVersion(ctx, "th.app")
+		if err != nil {
+			t.Fatalf("UpdateResourceVersion(%s, %s): %v", step.appVersion, step.resVersion, err)
+		}
+		if report.Outcome != step.outcome || report.ServerCode != setting.ServerCodeTH {
+			t.Fatalf("UpdateResourceVersion(%s, %s): got report %+v, want %s", step.appVersion, step.resVersion, report, step.outcome)
+		}
+		if report.After == nil || report.After.AppVersion != step.appVersion || report.After.ResVersion != step.resVersion {
+			t.Fatalf("UpdateResourceVersion(%s, %s): got report after %+v", step.appVersion, step.resVersion, report.After)
+		}
+
+		var got []use_case.VersionEventType
+		for _, e := range events.Published()[before:] {
+			got = append(got, e.Type)
+			if e.After.AppVersion != step.appVersion || e.After.ResVersion != step.resVersion {
+				t.Fatalf("%s: after got %+v", e.Type, e.After)
+			}
+		}
+		if !reflect.DeepEqual(got, step.want) {
+			t.Fatalf("UpdateResourceVersion(%s, %s): got %v, want %v", step.appVersion, step.resVersion, got, step.want)
+		}
+	}
+
+	got, err := versions.GetByID(ctx, "th.app")
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if got.AppVersion != "3.3.0" || got.ResVersion != "00150030" {
+		t.Fatalf("GetByID: got %+v", got)
+	}
+}
+
+type failingPublisher struct{}
+
+func (failingPublisher) PublishEvent(ctx context.Context, event use_case.VersionEvent) error {
+	return use_case.ErrVersionPublish
+}
+
+func TestUpdateResourceVersionPublishFailure(t *testing.T) {
+	u := use_case.New(
+		application_repository.NewMemory(apps...),
+		setting_repository.NewMemory(thSetting),
+		pcrd_th_repository.NewMemory("00150010"),
+		pcrd_jp_repository.NewMemory(),
+		version_repository.NewMemory(),
+		history_repository.NewMemory(clock.Real()),
+		failingPublisher{},
+		clock.Real(),
+	)
+
+	_, err := u.UpdateResourceVersion(context.Background(), "th.app")
+	if !errors.Is(err, use_case.ErrVersionPublish) {
+		t.Fatalf("UpdateResourceVersion: got %v, want %v", err, use_case.ErrVersionPublish)
+	}
+}
+
+// flakyPublisher lets up events through, then fails the next down ones.
+type flakyPublisher struct {
+	*version_event_repository.Memory
+	up   int
+	down int
+}
+
+func (p *flakyPublisher) PublishEvent(ctx context.Context, event use_case.VersionEvent) error {
+	if p.up > 0 {
+		p.up--
+	} else if p.down > 0 {
+		p.down--
+		return use_case.ErrVersionPublish
+	}
+	return p.Memory.PublishEvent(ctx, event)
+}
+
+func TestUpdateResourceVersionPublishRetried(t *testing.T) {
+	ctx := context.Background()
+	versions := version_repository.NewMemory()
+	events := &flakyPublisher{Memory: version_event_repository.NewMemory()}
+	newUseCase := func(appVersion string, resVersion string) *use_case.UseCase {
+		return use_case.New(
+			application_repository.NewMemory(application.Application{BundleID: "th.app", Version: appVersion}),
+			setting_repository.NewMemory(thSetting),
+			pcrd_th_repository.NewMemory(resVersion),
+			pcrd_jp_repository.NewMemory(),
+			versions,
+			history_repository.NewMemory(clock.Real()),
+			events,
+			clock.Real(),
+		)
+	}
+	if _, err := newUseCase("3.1.0", "00150010").UpdateResourceVersion(ctx, "th.app"); err != nil {
+		t.Fatalf("UpdateResourceVersion: %v", err)
+	}
+
+	// Only the app change gets through, then the failure is announced.
+	events.up, events.down = 1, 1
+	before := len(events.Published())
+	_, err := newUseCase("3.2.0", "00150020").UpdateResourceVersion(ctx, "th.app")
+	if !errors.Is(err, use_case.ErrVersionPublish) {
+		t.Fatalf("UpdateResourceVersion: got %v, want %v", err, use_case.ErrVersionPublish)
+	}
+	got, err := versions.GetByID(ctx, "th.app")
+	if err != nil || got.AppVersion != "3.1.0" || got.ResVersion != "00150010" {
+		t.Fatalf("GetByID: got %+v, %v, want the version before the unannounced change", got, err)
+	}
+	var failed []use_case.VersionEventType
+	for _, e := range events.Published()[before:] {
+		failed = append(failed, e.Type)
+	}
+	if want := []use_case.VersionEventType{use_case.VersionEventAppChanged, use_case.VersionEventCheckFailed}; !reflect.DeepEqual(failed, want) {
+		t.Fatalf("got events %v, want %v", failed, want)
+	}
+
+	before = len(events.Published())
+	report, err := newUseCase("3.2.0", "00150020").UpdateResourceVersion(ctx, "th.app")
+	if err != nil || report.Outcome != use_case.RunUpdated {
+		t.Fatalf("UpdateResourceVersion: got %s, %v, want the change announced again", report.Outcome, err)
+	}
+	var types []use_case.VersionEventType
+	for _, e := range events.Published()[before:] {
+		types = append(types, e.Type)
+	}
+	want := []use_case.VersionEventType{use_case.VersionEventAppChanged, use_case.VersionEventResourceChanged}
+	if !reflect.DeepEqual(types, want) {
+		t.Fatalf("got events %v, want %v", types, want)
+	}
+	got, err = versions.GetByID(ctx, "th.app")
+	if err != nil || got.AppVersion != "3.2.0" || got.ResVersion != "00150020" {
+		t.Fatalf("GetByID: got %+v, %v, want the announced version", got, err)
+	}
+}
+
+// hangingTHRepository lets a minute pass, then waits until ctx is done.
+type hangingTHRepository struct {
+	clock *clock.Fake
+}
+
+func (r hangingTHRepository) GetResourceVersion(ctx context.Context, c credential.Credential, v use_case.PcrdVersion) (string, error) {
+	r.clock.Advance(time.Minute)
+	<-ctx.Done()
+	return "", fmt.Errorf("request failed: %s: %w", ctx.Err(), use_case.ErrRetrieveData)
+}
+
+func (hangingTHRepository) HealthCheck(ctx context.Context) error {
+	return nil
+}
+
+func TestUpdateResourceVersionStepBudget(t *testing.T) {
+	clk := clock.NewFake(testNow)
+	u := use_case.New(
+		application_repository.NewMemory(apps...),
+		setting_repository.NewMemory(thSetting),
+		hangingTHRepository{clk},
+		pcrd_jp_repository.NewMemory(),
+		version_repository.NewMemory(),
+		history_repository.NewMemory(clk),
+		version_event_repository.NewMemory(),
+		clk,
+	)
+	u.SetStepBudgets(use_case.StepBudgets{Resource: 20 * time.Second})
+
+	report, err := u.UpdateResourceVersion(context.Background(), "th.app")
+	if !errors.Is(err, use_case.ErrRetrieveData) || !strings.Contains(err.Error(), "resource step exceeded") {
+		t.Fatalf("UpdateResourceVersion: got %v, want resource budget exceeded", err)
+	}
+	if report.Outcome != use_case.RunFailed || report.Failure.Class != use_case.FailureTimeout {
+		t.Fatalf("got report %+v, want a timeout failure", report)
+	}
+	last := report.Steps[len(report.Steps)-1]
+	if last.Step != "resource" || last.Duration != time.Minute {
+		t.Fatalf("got last step %+v, want resource after its budget", last)
+	}
+}
+
+// cancellingTHRepository cancels the run it is called in, like a SIGTERM
+// arriving while the game server is asked.
+type cancellingTHRepository struct {
+	cancel context.CancelFunc
+}
+
+func (r cancellingTHRepository) GetResourceVersion(ctx context.Context, c credential.Credential, v use_case.PcrdVersion) (string, error) {
+	r.cancel()
+	<-ctx.Done()
+	return "", fmt.Errorf("request failed: %s: %w", ctx.Err(), use_case.ErrRetrieveData)
+}
+
+func (cancellingTHRepository) HealthCheck(ctx context.Context) error {
+	return nil
+}
+
+// The wrappers below reject a done context, as Kafka and the databases do.
+
+type liveEvents struct {
+	use_case.VersionEventRepository
+}
+
+func (r liveEvents) PublishEvent(ctx context.Context, event use_case.VersionEvent) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return r.VersionEventRepository.PublishEvent(ctx, event)
+}
+
+type liveCheckStates struct {
+	use_case.CheckStateRepository
+}
+
+func (r liveCheckStates) GetCheckState(ctx context.Context, ID string) (use_case.CheckState, error) {
+	if ctx.Err() != nil {
+		return use_case.CheckState{}, ctx.Err()
+	}
+	return r.CheckStateRepository.GetCheckState(ctx, ID)
+}
+
+func (r liveCheckStates) SaveCheckState(ctx context.Context, s use_case.CheckState) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return r.CheckStateRepository.SaveCheckState(ctx, s)
+}
+
+type liveChecks struct {
+	use_case.CheckRepository
+}
+
+func (r liveChecks) CreateCheck(ctx context.Context, c use_case.CheckRecord) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return r.CheckRepository.CreateCheck(ctx, c)
+}
+
+func TestUpdateResourceVersionInterrupted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	f := newFixture("")
+	states := check_state_repository.NewMemory()
+	checks := check_repository.NewMemory()
+	f.useCase = use_case.New(
+		application_repository.NewMemory(apps...),
+		setting_repository.NewMemory(thSetting),
+		cancellingTHRepository{cancel},
+		pcrd_jp_repository.NewMemory(),
+		f.versions,
+		f.history,
+		liveEvents{f.events},
+		f.clock,
+	)
+	f.useCase.SetAlerting(liveCheckStates{states}, use_case.AlertThresholds{ConsecutiveFailures: 1})
+	f.useCase.SetCheckLog(liveChecks{checks}, 0)
+
+	_, err := f.useCase.UpdateResourceVersion(ctx, "th.app")
+	if err == nil || !strings.Contains(err.Error(), "resource step interrupted") {
+		t.Fatalf("UpdateResourceVersion: got %v, want the resource step interrupted", err)
+	}
+
+	var types []use_case.VersionEventType
+	for _, e := range f.events.Published() {
+		types = append(types, e.Type)
+	}
+	want := []use_case.VersionEventType{use_case.VersionEventCheckFailed, use_case.VersionEventAlertRaised}
+	if !reflect.DeepEqual(types, want) {
+		t.Fatalf("got events %v, want %v", types, want)
+	}
+	state, err := states.GetCheckState(context.Background(), "th.app")
+	if err != nil || state.ConsecutiveFailures != 1 {
+		t.Fatalf("GetCheckState: got %+v, %v, want one failure", state, err)
+	}
+	records, err := checks.ListChecks(context.Background(), use_case.CheckQuery{})
+	if err != nil || len(records) != 1 || records[0].Outcome != use_case.RunFailed || records[0].FailureClass != use_case.FailureTimeout {
+		t.Fatalf("ListChecks: got %+v, %v, want the check failed on a timeout", records, err)
+	}
+}
+
+func TestUpdateResourceVersionClock(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture("00150010")
+
+	report, err := f.useCase.UpdateResourceVersion(ctx, "th.app")
+	if err != nil {
+		t.Fatalf("UpdateResourceVersion: %v", err)
+	}
+	if !report.StartedAt.Equal(testNow) || !report.FinishedAt.Equal(testNow) {
+		t.Fatalf("got run from %v to %v, want both at %v", report.StartedAt, report.FinishedAt, testNow)
+	}
+	if events := f.events.Published(); len(events) != 1 || !events[0].Time.Equal(testNow) {
+		t.Fatalf("got events %+v, want one at %v", events, testNow)
+	}
+	if histories := f.history.Histories(); len(histories) != 1 || !histories[0].CreatedAt.Equal(testNow) {
+		t.Fatalf("got histories %+v, want one at %v", histories, testNow)
+	}
+}
+
+func TestPlanResourceVersion(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture("00150010")
+
+	report, err := f.useCase.PlanResourceVersion(ctx, "th.app")
+	if err != nil {
+		t.Fatalf("PlanResourceVersion: %v", err)
+	}
+	if report.Outcome != use_case.RunCreated || report.After == nil || report.After.ResVersion != "00150010" {
+		t.Fatalf("got %+v, want a created version", report)
+	}
+	if _, err := f.versions.GetByID(ctx, "th.app"); !errors.Is(err, use_case.ErrVersionNotFound) {
+		t.Fatalf("GetByID: got %v, want the plan to store nothing", err)
+	}
+	if len(f.history.Histories()) != 0 || len(f.events.Published()) != 0 {
+		t.Fatalf("got %d histories and %d events, want none", len(f.history.Histories()), len(f.events.Published()))
+	}
+
+	if _, err := f.useCase.UpdateResourceVersion(ctx, "th.app"); err != nil {
+		t.Fatalf("UpdateResourceVersion: %v", err)
+	}
+	report, err = f.useCase.PlanResourceVersion(ctx, "th.app")
+	if err != nil {
+		t.Fatalf("PlanResourceVersion: %v", err)
+	}
+	if report.Outcome != use_case.RunUnchanged {
+		t.Fatalf("got %s, want unchanged", report.Outcome)
+	}
+}
+
+func TestListHistory(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture("00150010", 10000010)
+
+	for _, ID := range []string{"th.app", "jp.app"} {
+		if _, err := f.useCase.UpdateResourceVersion(ctx, ID); err != nil {
+			t.Fatalf("UpdateResourceVersion(%s): %v", ID, err)
+		}
+	}
+
+	got, err := f.useCase.ListHistory(ctx, "jp.app", time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatalf("ListHistory: %v", err)
+	}
+	if len(got) != 1 || got[0].Version.Setting.ID != "jp.app" {
+		t.Fatalf("ListHistory: got %+v, want the jp.app entry only", got)
+	}
+	if _, err := f.useCase.ListHistory(ctx, "", time.Time{}, time.Time{}); !errors.Is(err, use_case.ErrMissingAppID) {
+		t.Fatalf("ListHistory without id: got %v, want ErrMissingAppID", err)
+	}
+}
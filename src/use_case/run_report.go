@@ -0,0 +1,140 @@
+package use_case
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/clock"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/setting"
+	"time"
+)
+
+// RunOutcome is how a single UpdateResourceVersion run ended.
+type RunOutcome string
+
+const (
+	// RunUnchanged means the remote versions match the stored ones.
+	RunUnchanged RunOutcome = "unchanged"
+	// RunCreated means the setting had no announced version before.
+	RunCreated RunOutcome = "created"
+	// RunUpdated means the application or resource version changed.
+	RunUpdated RunOutcome = "updated"
+	// RunFailed means the check did not finish; see RunReport.Failure.
+	RunFailed RunOutcome = "failed"
+)
+
+func ParseRunOutcome(s string) (d RunOutcome, e error) {
+	dataTypes := map[RunOutcome]struct{}{
+		RunUnchanged: {},
+		RunCreated:   {},
+		RunUpdated:   {},
+		RunFailed:    {},
+	}
+
+	dat := RunOutcome(s)
+	_, ok := dataTypes[dat]
+	if !ok {
+		return d, fmt.Errorf("cannot parse:[%s] as run outcome: %w", s, ErrInvalidRequestParam)
+	}
+	return dat, nil
+}
+
+// StepTiming is how long one step of a run took.
+type StepTiming struct {
+	Step     string
+	Duration time.Duration
+}
+
+// RunReport describes the result of UpdateResourceVersion. Before is nil
+// when nothing had been announced yet, After is nil when the check failed
+// before the remote versions were known.
+type RunReport struct {
+	ID         string
+	ServerCode setting.ServerCode
+	Outcome    RunOutcome
+	Before     *PcrdVersion
+	After      *PcrdVersion
+	Failure    *CheckFailure
+	StartedAt  time.Time
+	FinishedAt time.Time
+	Steps      []StepTiming
+	Probes     ProbeCounts
+	// Platforms is the version found on each probed JP platform, the
+	// primary one first. PlatformsDisagree is set when they differ.
+	Platforms         []PlatformVersion
+	PlatformsDisagree bool
+
+	clock clock.Clock
+}
+
+func (r RunReport) Duration() time.Duration {
+	return r.FinishedAt.Sub(r.StartedAt)
+}
+
+// StepBudgets is how long each step of a run may take. A zero budget leaves
+// the step bounded only by the run's own deadline.
+type StepBudgets struct {
+	// Storage bounds each read and write of settings and versions.
+	Storage     time.Duration
+	Application time.Duration
+	// Resource bounds the TH game server call or the whole JP guessing.
+	Resource time.Duration
+	Publish  time.Duration
+}
+
+// runStep runs fn within budget and records how long it took. An error
+// caused by the budget running out, or by the run being interrupted, says so
+// and still wraps fn's error.
+func (r *RunReport) runStep(ctx context.Context, step string, budget time.Duration, fn func(ctx context.Context) error) error {
+	stepCtx := ctx
+	if budget > 0 {
+		var cancel context.CancelFunc
+		stepCtx, cancel = r.clock.WithTimeout(ctx, budget)
+		defer cancel()
+	}
+
+	start := r.clock.Now()
+	err := fn(stepCtx)
+	r.Steps = append(r.Steps, StepTiming{Step: step, Duration: r.clock.Since(start)})
+
+	switch {
+	case err == nil:
+	case ctx.Err() != nil:
+		return stepError{fmt.Sprintf("%s step interrupted: %s: %s", step, ctx.Err(), err), err, ctx.Err()}
+	case errors.Is(stepCtx.Err(), context.DeadlineExceeded):
+		return stepError{fmt.Sprintf("%s step exceeded its %s budget: %s", step, budget, err), err, context.DeadlineExceeded}
+	}
+	return err
+}
+
+// stepError is the error of a step cut short by its context. It unwraps to
+// the step's own error and also is the context's, so that ClassifyFailure
+// tells a timeout.
+type stepError struct {
+	message string
+	err     error
+	cause   error
+}
+
+func (e stepError) Error() string {
+	return e.message
+}
+
+func (e stepError) Unwrap() error {
+	return e.err
+}
+
+func (e stepError) Is(target error) bool {
+	return target == e.cause
+}
+
+func (r *RunReport) finish(outcome RunOutcome, err error) {
+	r.Outcome = outcome
+	if err != nil {
+		r.Failure = &CheckFailure{
+			Class:   ClassifyFailure(err),
+			Message: err.Error(),
+		}
+	}
+	r.FinishedAt = r.clock.Now()
+}
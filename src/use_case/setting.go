@@ -0,0 +1,139 @@
+package use_case
+
+import (
+	"context"
+	"fmt"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/logger"
+	"go.opentelemetry.io/otel/codes"
+	"go.uber.org/zap"
+)
+
+func (u UseCase) GetSetting(ctx context.Context, ID string) (PCRDSetting, error) {
+	ctx, span := tracer.Start(ctx, fmt.Sprintf("use_case.GetSetting(%s)", ID))
+	defer span.End()
+
+	result, err := u.settingRepository.GetSettingByID(ctx, ID)
+	if err != nil {
+		span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+		return PCRDSetting{}, err
+	}
+
+	return result, nil
+}
+
+func (u UseCase) ListSettings(ctx context.Context) ([]PCRDSetting, error) {
+	ctx, span := tracer.Start(ctx, "use_case.ListSettings")
+	defer span.End()
+
+	results, err := u.settingRepository.ListSettings(ctx)
+	if err != nil {
+		span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+		return nil, err
+	}
+
+	return results, nil
+}
+
+func (u UseCase) CreateSetting(ctx context.Context, s PCRDSetting) error {
+	ctx, span := tracer.Start(ctx, fmt.Sprintf("use_case.CreateSetting(%s)", s.Setting.ID))
+	defer span.End()
+	zap.L().Info("use_case.CreateSetting",
+		logger.WithTraceId(ctx),
+		zap.Any("ID", s.Setting.ID),
+		zap.Any("ServerCode", s.Setting.ServerCode),
+	)
+
+	err := s.Validate()
+	if err != nil {
+		span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+		return err
+	}
+
+	err = u.settingRepository.CreateSetting(ctx, s)
+	if err != nil {
+		span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+		return err
+	}
+
+	return nil
+}
+
+func (u UseCase) UpdateSetting(ctx context.Context, s PCRDSetting) error {
+	ctx, span := tracer.Start(ctx, fmt.Sprintf("use_case.UpdateSetting(%s)", s.Setting.ID))
+	defer span.End()
+	zap.L().Info("use_case.UpdateSetting",
+		logger.WithTraceId(ctx),
+		zap.Any("ID", s.Setting.ID),
+		zap.Any("ServerCode", s.Setting.ServerCode),
+	)
+
+	err := s.Validate()
+	if err != nil {
+		span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+		return err
+	}
+
+	err = u.settingRepository.UpdateSetting(ctx, s)
+	if err != nil {
+		span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+		return err
+	}
+
+	return nil
+}
+
+func (u UseCase) DeleteSetting(ctx context.Context, ID string) error {
+	ctx, span := tracer.Start(ctx, fmt.Sprintf("use_case.DeleteSetting(%s)", ID))
+	defer span.End()
+	zap.L().Info("use_case.DeleteSetting",
+		logger.WithTraceId(ctx),
+		zap.Any("ID", ID),
+	)
+
+	if len(ID) <= 0 {
+		span.SetStatus(codes.Error, fmt.Sprintf("%s", ErrMissingAppID))
+		return fmt.Errorf("%w", ErrMissingAppID)
+	}
+
+	err := u.settingRepository.DeleteSettingByID(ctx, ID)
+	if err != nil {
+		span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+		return err
+	}
+
+	return nil
+}
+
+// ReEncryptSettings rewrites every setting so its credential is sealed with
+// the repository's current key. It is used after adding a new key to rotate
+// away from the old one, and to encrypt settings stored before encryption.
+// Without a key it fails rather than rewrite the settings in plaintext.
+func (u UseCase) ReEncryptSettings(ctx context.Context) (int, error) {
+	ctx, span := tracer.Start(ctx, "use_case.ReEncryptSettings")
+	defer span.End()
+
+	if repo, ok := u.settingRepository.(EncryptingSettingRepository); !ok || !repo.Encrypts() {
+		span.SetStatus(codes.Error, fmt.Sprintf("no credential key configured: %s", ErrInvalidSetting))
+		return 0, fmt.Errorf("no credential key configured: %w", ErrInvalidSetting)
+	}
+
+	results, err := u.settingRepository.ListSettings(ctx)
+	if err != nil {
+		span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+		return 0, err
+	}
+
+	for i := range results {
+		err = u.settingRepository.UpdateSetting(ctx, results[i])
+		if err != nil {
+			span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+			return i, err
+		}
+		zap.L().Info("use_case.ReEncryptSettings",
+			logger.WithTraceId(ctx),
+			zap.Any("ID", results[i].Setting.ID),
+		)
+	}
+
+	return len(results), nil
+}
@@ -0,0 +1,54 @@
+package use_case_test
+
+import (
+	"context"
+	"errors"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/clock"
+	"github.com/SpeedxPz/pcrd-version-updater/src/repository/setting_repository"
+	"github.com/SpeedxPz/pcrd-version-updater/src/use_case"
+	"testing"
+)
+
+// sealingSettings is a setting repository with a credential key.
+type sealingSettings struct {
+	use_case.SettingRepository
+	updates int
+}
+
+func (s *sealingSettings) Encrypts() bool {
+	return true
+}
+
+func (s *sealingSettings) UpdateSetting(ctx context.Context, p use_case.PCRDSetting) error {
+	s.updates++
+	return s.SettingRepository.UpdateSetting(ctx, p)
+}
+
+func newSettingUseCase(settings use_case.SettingRepository) *use_case.UseCase {
+	return use_case.New(nil, settings, nil, nil, nil, nil, nil, clock.NewFake(testNow))
+}
+
+func TestReEncryptSettings(t *testing.T) {
+	ctx := context.Background()
+	settings := &sealingSettings{SettingRepository: setting_repository.NewMemory(thSetting, jpSetting)}
+
+	count, err := newSettingUseCase(settings).ReEncryptSettings(ctx)
+	if err != nil {
+		t.Fatalf("ReEncryptSettings: %v", err)
+	}
+	if count != 2 || settings.updates != 2 {
+		t.Fatalf("got %d re-encrypted and %d updates, want 2 each", count, settings.updates)
+	}
+}
+
+func TestReEncryptSettingsWithoutKey(t *testing.T) {
+	ctx := context.Background()
+	// The memory repository has no key to seal with.
+	_, err := newSettingUseCase(setting_repository.NewMemory(thSetting, jpSetting)).ReEncryptSettings(ctx)
+	if !errors.Is(err, use_case.ErrInvalidSetting) {
+		t.Fatalf("ReEncryptSettings: got %v, want %v", err, use_case.ErrInvalidSetting)
+	}
+	if class := use_case.ClassifyFailure(err); class != use_case.FailureConfiguration {
+		t.Fatalf("ClassifyFailure: got %q, want %q", class, use_case.FailureConfiguration)
+	}
+}
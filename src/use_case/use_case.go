@@ -4,27 +4,42 @@ import (
 	"context"
 	"errors"
 	"github.com/SpeedxPz/pcrd-version-updater/src/entity/application"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/clock"
 	"github.com/SpeedxPz/pcrd-version-updater/src/entity/credential"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/platform"
 	"github.com/SpeedxPz/pcrd-version-updater/src/entity/setting"
 	"go.opentelemetry.io/otel"
+	"time"
 )
 
 var (
-	ErrPermissionDenied     = errors.New("permission denied")
-	ErrDataTransform        = errors.New("data transformation error")
-	ErrInvalidRequestParam  = errors.New("invalid request parameter")
-	ErrResVerNotAvailable   = errors.New("resource version not available from remote")
-	ErrRetrieveData         = errors.New("data retrieve failed")
-	ErrRetrivingSetting     = errors.New("failed to retrieving setting data")
-	ErrSettingNotExists     = errors.New("setting not found")
-	ErrMissingAppID         = errors.New("app id is required")
-	ErrRetrivingApplication = errors.New("failed to retrieving application data")
-	ErrApplicationNotFound  = errors.New("application not found")
-	ErrRetrivingVersion     = errors.New("failed to retrieving version data")
-	ErrVersionNotFound      = errors.New("version not found")
-	ErrSavingVersion        = errors.New("failed to save version")
-	ErrVersionPublish       = errors.New("cannot publish version")
-	ErrSavingSetting        = errors.New("failed to save setting")
+	ErrPermissionDenied      = errors.New("permission denied")
+	ErrDataTransform         = errors.New("data transformation error")
+	ErrInvalidRequestParam   = errors.New("invalid request parameter")
+	ErrResVerNotAvailable    = errors.New("resource version not available from remote")
+	ErrRetrieveData          = errors.New("data retrieve failed")
+	ErrRetrivingSetting      = errors.New("failed to retrieving setting data")
+	ErrSettingNotExists      = errors.New("setting not found")
+	ErrMissingAppID          = errors.New("app id is required")
+	ErrRetrivingApplication  = errors.New("failed to retrieving application data")
+	ErrApplicationNotFound   = errors.New("application not found")
+	ErrRetrivingVersion      = errors.New("failed to retrieving version data")
+	ErrVersionNotFound       = errors.New("version not found")
+	ErrSavingVersion         = errors.New("failed to save version")
+	ErrVersionPublish        = errors.New("cannot publish version")
+	ErrSavingSetting         = errors.New("failed to save setting")
+	ErrDeletingSetting       = errors.New("failed to delete setting")
+	ErrSettingAlreadyExists  = errors.New("setting already exists")
+	ErrInvalidSetting        = errors.New("invalid setting")
+	ErrRetrivingDelivery     = errors.New("failed to retrieving delivery data")
+	ErrSavingDelivery        = errors.New("failed to save delivery")
+	ErrDeliveryNotFound      = errors.New("delivery not found")
+	ErrDependencyUnavailable = errors.New("dependency unavailable")
+	ErrRetrivingCheckState   = errors.New("failed to retrieving check state")
+	ErrSavingCheckState      = errors.New("failed to save check state")
+	ErrCheckStateNotFound    = errors.New("check state not found")
+	ErrRetrivingCheck        = errors.New("failed to retrieving check data")
+	ErrSavingCheck           = errors.New("failed to save check")
 )
 
 var tracer = otel.Tracer("use_case")
@@ -37,6 +52,12 @@ type UseCase struct {
 	versionRepository      VersionRepository
 	historyRepository      HistoryRepository
 	versionEventRepository VersionEventRepository
+	budgets                StepBudgets
+	checkStateRepository   CheckStateRepository
+	alertThresholds        AlertThresholds
+	checkRepository        CheckRepository
+	checkRetention         time.Duration
+	clock                  clock.Clock
 }
 
 type ApplicationRepository interface {
@@ -47,18 +68,40 @@ type ApplicationRepository interface {
 type SettingRepository interface {
 	HealthCheck(ctx context.Context) error
 	GetSettingByID(ctx context.Context, ID string) (PCRDSetting, error)
+	ListSettings(ctx context.Context) ([]PCRDSetting, error)
+	CreateSetting(ctx context.Context, s PCRDSetting) error
+	UpdateSetting(ctx context.Context, s PCRDSetting) error
+	DeleteSettingByID(ctx context.Context, ID string) error
+}
+
+// EncryptingSettingRepository is optionally implemented by a
+// SettingRepository that can seal credentials. Encrypts reports whether it
+// has a key to seal them with.
+type EncryptingSettingRepository interface {
+	Encrypts() bool
 }
 
 type VersionRepository interface {
 	HealthCheck(ctx context.Context) error
 	GetByID(ctx context.Context, appId string) (GameVersion, error)
+	ListVersions(ctx context.Context) ([]GameVersion, error)
 	Create(ctx context.Context, version GameVersion) error
 	Update(ctx context.Context, version GameVersion) error
 }
 
+// VersionHistoryRepository is optionally implemented by a VersionRepository
+// that can update a version and record its history in one transaction.
+type VersionHistoryRepository interface {
+	UpdateWithHistory(ctx context.Context, version GameVersion) error
+}
+
 type HistoryRepository interface {
 	HealthCheck(ctx context.Context) error
 	Create(ctx context.Context, version GameVersion) error
+	// ListHistories returns the entries of the setting ID created in
+	// [from, to), oldest first. An empty ID matches every setting and a
+	// zero to has no upper bound.
+	ListHistories(ctx context.Context, ID string, from time.Time, to time.Time) ([]VersionHistory, error)
 }
 
 type PcrdTHRepository interface {
@@ -66,13 +109,62 @@ type PcrdTHRepository interface {
 	HealthCheck(ctx context.Context) error
 }
 
+// PcrdJPRepository guesses the resource versions published after
+// startVersion. A platform has a version once its manifest is there in every
+// locale; the versions come back in the order of platforms.
 type PcrdJPRepository interface {
-	GetResourceVersion(ctx context.Context, startVersion string) (string, error)
+	GetResourceVersions(ctx context.Context, startVersion string, platforms []platform.PlatformType, locales []string) ([]PlatformVersion, error)
 	HealthCheck(ctx context.Context) error
 }
 
 type VersionEventRepository interface {
-	PublishVersion(ctx context.Context, version GameVersion) error
+	PublishEvent(ctx context.Context, event VersionEvent) error
+}
+
+// HealthChecker is optionally implemented by a VersionEventRepository that
+// can check that its broker is reachable.
+type HealthChecker interface {
+	HealthCheck(ctx context.Context) error
+}
+
+// DeliveryRepository keeps event deliveries that failed after every retry,
+// until they are redelivered.
+type DeliveryRepository interface {
+	HealthCheck(ctx context.Context) error
+	SaveFailedDelivery(ctx context.Context, d FailedDelivery) error
+	ListFailedDeliveries(ctx context.Context) ([]FailedDelivery, error)
+	DeleteFailedDelivery(ctx context.Context, ID string) error
+}
+
+// CheckStateRepository keeps the state alerting tracks for each setting.
+type CheckStateRepository interface {
+	HealthCheck(ctx context.Context) error
+	GetCheckState(ctx context.Context, ID string) (CheckState, error)
+	// SaveCheckState creates or replaces the state of s.ID.
+	SaveCheckState(ctx context.Context, s CheckState) error
+}
+
+type CheckRepository interface {
+	HealthCheck(ctx context.Context) error
+	CreateCheck(ctx context.Context, c CheckRecord) error
+	// ListChecks returns the checks matching q, newest first.
+	ListChecks(ctx context.Context, q CheckQuery) ([]CheckRecord, error)
+	// DeleteExpiredChecks deletes the checks that expired before now and
+	// returns how many it deleted.
+	DeleteExpiredChecks(ctx context.Context, now time.Time) (int, error)
+}
+
+// CheckState summarises the past checks of a setting. LastChangedAt starts
+// at the first tracked check, since earlier changes are unknown.
+type CheckState struct {
+	ID                  string
+	ConsecutiveFailures int
+	LastFailureClass    FailureClass
+	LastCheckedAt       time.Time
+	LastSuccessAt       time.Time
+	LastChangedAt       time.Time
+	// Alerts are the alerts raised and not resolved yet, sorted.
+	Alerts []AlertType
 }
 
 type PcrdVersion struct {
@@ -80,6 +172,28 @@ type PcrdVersion struct {
 	ResVersion string
 }
 
+// PlatformVersion is the latest resource version found on one JP platform.
+type PlatformVersion struct {
+	Platform   platform.PlatformType
+	ResVersion string
+}
+
+type FailedDelivery struct {
+	ID            string
+	URL           string
+	EventID       string
+	Body          []byte
+	Attempts      int
+	LastError     string
+	FirstFailedAt time.Time
+	LastAttemptAt time.Time
+}
+
+type VersionHistory struct {
+	Version   GameVersion
+	CreatedAt time.Time
+}
+
 type GameVersion struct {
 	Setting    setting.Setting
 	AppVersion string
@@ -94,6 +208,7 @@ func New(
 	versionRepo VersionRepository,
 	historyRepo HistoryRepository,
 	versionEventRepo VersionEventRepository,
+	clk clock.Clock,
 ) *UseCase {
 	return &UseCase{
 		settingRepository:      settingRepo,
@@ -103,5 +218,25 @@ func New(
 		versionRepository:      versionRepo,
 		historyRepository:      historyRepo,
 		versionEventRepository: versionEventRepo,
+		clock:                  clk,
 	}
 }
+
+// SetAlerting makes UpdateResourceVersion track each setting's checks in
+// repo and publish alerts when thresholds are crossed.
+func (u *UseCase) SetAlerting(repo CheckStateRepository, thresholds AlertThresholds) {
+	u.checkStateRepository = repo
+	u.alertThresholds = thresholds
+}
+
+// SetCheckLog makes UpdateResourceVersion record every run in repo. Records
+// expire after retention; zero keeps them forever.
+func (u *UseCase) SetCheckLog(repo CheckRepository, retention time.Duration) {
+	u.checkRepository = repo
+	u.checkRetention = retention
+}
+
+// SetStepBudgets bounds the steps of UpdateResourceVersion.
+func (u *UseCase) SetStepBudgets(b StepBudgets) {
+	u.budgets = b
+}
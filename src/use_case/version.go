@@ -0,0 +1,53 @@
+package use_case
+
+import (
+	"context"
+	"fmt"
+	"go.opentelemetry.io/otel/codes"
+	"time"
+)
+
+func (u UseCase) GetVersion(ctx context.Context, ID string) (GameVersion, error) {
+	ctx, span := tracer.Start(ctx, fmt.Sprintf("use_case.GetVersion(%s)", ID))
+	defer span.End()
+
+	result, err := u.versionRepository.GetByID(ctx, ID)
+	if err != nil {
+		span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+		return GameVersion{}, err
+	}
+
+	return result, nil
+}
+
+func (u UseCase) ListVersions(ctx context.Context) ([]GameVersion, error) {
+	ctx, span := tracer.Start(ctx, "use_case.ListVersions")
+	defer span.End()
+
+	results, err := u.versionRepository.ListVersions(ctx)
+	if err != nil {
+		span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// ListHistory returns the history entries of the setting ID created in
+// [from, to), oldest first. A zero to has no upper bound.
+func (u UseCase) ListHistory(ctx context.Context, ID string, from time.Time, to time.Time) ([]VersionHistory, error) {
+	ctx, span := tracer.Start(ctx, fmt.Sprintf("use_case.ListHistory(%s)", ID))
+	defer span.End()
+
+	if len(ID) <= 0 {
+		span.SetStatus(codes.Error, "missing id")
+		return nil, fmt.Errorf("%w", ErrMissingAppID)
+	}
+
+	histories, err := u.historyRepository.ListHistories(ctx, ID, from, to)
+	if err != nil {
+		span.SetStatus(codes.Error, fmt.Sprintf("%s", err))
+		return nil, err
+	}
+	return histories, nil
+}
@@ -0,0 +1,141 @@
+package use_case
+
+import (
+	"context"
+	"errors"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/configuration"
+	"github.com/SpeedxPz/pcrd-version-updater/src/entity/setting"
+	"time"
+)
+
+type VersionEventType string
+
+const (
+	// VersionEventCreated is the first sighting of a setting's version.
+	VersionEventCreated VersionEventType = "version_created"
+	// VersionEventAppChanged is a new store version of the application.
+	VersionEventAppChanged VersionEventType = "app_version_changed"
+	// VersionEventResourceChanged is a new resource version from the game
+	// server or CDN.
+	VersionEventResourceChanged VersionEventType = "resource_version_changed"
+	// VersionEventCheckFailed is a check that could not finish.
+	VersionEventCheckFailed VersionEventType = "check_failed"
+	// VersionEventSnapshot restates a setting's current version, for
+	// backfilling consumers and compacted topics.
+	VersionEventSnapshot VersionEventType = "version_snapshot"
+	// VersionEventAlertRaised is a setting crossing an alert threshold.
+	VersionEventAlertRaised VersionEventType = "alert_raised"
+	// VersionEventAlertResolved is a setting back under the threshold of a
+	// raised alert.
+	VersionEventAlertResolved VersionEventType = "alert_resolved"
+)
+
+// FailureClass groups check errors by what a consumer can do about them.
+type FailureClass string
+
+const (
+	// FailureNotAvailable means the remote answered but has no version,
+	// e.g. during maintenance. Usually resolves on its own.
+	FailureNotAvailable FailureClass = "not_available"
+	// FailureApplication means the store lookup of the application failed.
+	FailureApplication FailureClass = "application"
+	// FailureRemote means the game server or CDN could not be reached or
+	// answered with something unreadable.
+	FailureRemote FailureClass = "remote"
+	// FailureStorage means reading or writing our own storage failed.
+	FailureStorage FailureClass = "storage"
+	// FailureConfiguration means the setting itself is missing or wrong.
+	FailureConfiguration FailureClass = "configuration"
+	// FailurePublish means the events could not be sent to a sink.
+	FailurePublish FailureClass = "publish"
+	// FailureTimeout means the check ran out of time or was interrupted.
+	FailureTimeout FailureClass = "timeout"
+	// FailureDependency means a dependency failed its health check. Only
+	// commands other than a check, such as health and migrate, end so.
+	FailureDependency FailureClass = "dependency"
+	FailureUnknown    FailureClass = "unknown"
+)
+
+// ClassifyFailure maps an error returned during a check, or any other
+// command, to its FailureClass. A deadline or interruption comes first, as
+// it is why a step failed.
+func ClassifyFailure(err error) FailureClass {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, context.Canceled):
+		return FailureTimeout
+	case errors.Is(err, ErrResVerNotAvailable):
+		return FailureNotAvailable
+	case errors.Is(err, ErrRetrivingApplication), errors.Is(err, ErrApplicationNotFound):
+		return FailureApplication
+	case errors.Is(err, ErrRetrieveData), errors.Is(err, ErrDataTransform):
+		return FailureRemote
+	case errors.Is(err, ErrRetrivingVersion), errors.Is(err, ErrSavingVersion),
+		errors.Is(err, ErrRetrivingSetting), errors.Is(err, ErrRetrivingDelivery),
+		errors.Is(err, ErrSavingDelivery):
+		return FailureStorage
+	case errors.Is(err, ErrSettingNotExists), errors.Is(err, ErrInvalidSetting),
+		errors.Is(err, ErrInvalidRequestParam), errors.Is(err, ErrMissingAppID),
+		errors.Is(err, configuration.ErrInvalidConfig):
+		return FailureConfiguration
+	case errors.Is(err, ErrVersionPublish):
+		return FailurePublish
+	case errors.Is(err, ErrDependencyUnavailable):
+		return FailureDependency
+	default:
+		return FailureUnknown
+	}
+}
+
+type CheckFailure struct {
+	Class   FailureClass
+	Message string
+}
+
+// VersionEvent is what the use case publishes. Before is nil for
+// VersionEventCreated and After is nil for VersionEventCheckFailed. Alert
+// events carry an Alert and no version.
+type VersionEvent struct {
+	Type    VersionEventType
+	Setting setting.Setting
+	Before  *PcrdVersion
+	After   *PcrdVersion
+	Failure *CheckFailure
+	Alert   *Alert
+	Time    time.Time
+}
+
+func newVersionEvent(eventType VersionEventType, s setting.Setting, before *PcrdVersion, after *PcrdVersion, t time.Time) VersionEvent {
+	return VersionEvent{
+		Type:    eventType,
+		Setting: s,
+		Before:  before,
+		After:   after,
+		Time:    t,
+	}
+}
+
+// changeEvents returns the events announcing a move from before to after,
+// app version first. A nil before is a first sighting.
+func changeEvents(s setting.Setting, before *PcrdVersion, after *PcrdVersion, t time.Time) []VersionEvent {
+	if before == nil {
+		return []VersionEvent{newVersionEvent(VersionEventCreated, s, nil, after, t)}
+	}
+
+	var events []VersionEvent
+	if before.AppVersion != after.AppVersion {
+		events = append(events, newVersionEvent(VersionEventAppChanged, s, before, after, t))
+	}
+	if before.ResVersion != after.ResVersion {
+		events = append(events, newVersionEvent(VersionEventResourceChanged, s, before, after, t))
+	}
+	return events
+}
+
+func newCheckFailedEvent(s setting.Setting, before *PcrdVersion, err error, t time.Time) VersionEvent {
+	e := newVersionEvent(VersionEventCheckFailed, s, before, nil, t)
+	e.Failure = &CheckFailure{
+		Class:   ClassifyFailure(err),
+		Message: err.Error(),
+	}
+	return e
+}
@@ -0,0 +1,25 @@
+package use_case
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestClassifyFailure(t *testing.T) {
+	for err, want := range map[error]FailureClass{
+		ErrResVerNotAvailable:                             FailureNotAvailable,
+		fmt.Errorf("request failed: %w", ErrRetrieveData): FailureRemote,
+		ErrApplicationNotFound:                            FailureApplication,
+		ErrSavingVersion:                                  FailureStorage,
+		fmt.Errorf("x: %w", ErrSettingNotExists):          FailureConfiguration,
+		fmt.Errorf("kafka: %w", ErrVersionPublish):        FailurePublish,
+		fmt.Errorf("x: %w", context.DeadlineExceeded):     FailureTimeout,
+		context.Canceled:                                  FailureTimeout,
+		fmt.Errorf("boom"):                                FailureUnknown,
+	} {
+		if got := ClassifyFailure(err); got != want {
+			t.Errorf("ClassifyFailure(%v): got %s, want %s", err, got, want)
+		}
+	}
+}